@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+)
+
+// JWK – публічний ключ у форматі RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS повертає публічні частини асиметричних ключів. HS256-ключі є секретами
+// і ніколи не публікуються.
+func (m *KeyManager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range m.Keys() {
+		switch pub := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     k.ID,
+				Algorithm: k.Algorithm,
+				Use:       "sig",
+				N:         b64(pub.N.Bytes()),
+				E:         b64(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     k.ID,
+				Algorithm: k.Algorithm,
+				Use:       "sig",
+				Curve:     "Ed25519",
+				X:         b64(pub),
+			})
+		}
+	}
+	return set
+}
+
+// JWKSHandler віддає /.well-known/jwks.json для сервісів, що перевіряють наші токени
+func (m *KeyManager) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(m.JWKS())
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
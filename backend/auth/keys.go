@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Підтримувані алгоритми підпису
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// kid ключа, створеного зі старої змінної JWT_SECRET
+const legacyKID = "default"
+
+// Key – один ключ підпису. Ключ без приватної частини використовується лише для перевірки
+// (наприклад, виведений з ротації ключ, токени якого ще не прострочені).
+type Key struct {
+	ID        string
+	Algorithm string
+
+	signKey   interface{} // []byte | *rsa.PrivateKey | ed25519.PrivateKey
+	verifyKey interface{} // []byte | *rsa.PublicKey | ed25519.PublicKey
+}
+
+func NewHMACKey(kid string, secret []byte) *Key {
+	return &Key{ID: kid, Algorithm: AlgHS256, signKey: secret, verifyKey: secret}
+}
+
+func NewRSAKey(kid string, priv *rsa.PrivateKey) *Key {
+	return &Key{ID: kid, Algorithm: AlgRS256, signKey: priv, verifyKey: &priv.PublicKey}
+}
+
+func NewEd25519Key(kid string, priv ed25519.PrivateKey) *Key {
+	return &Key{ID: kid, Algorithm: AlgEdDSA, signKey: priv, verifyKey: priv.Public()}
+}
+
+// NewPublicKey створює ключ лише для перевірки (RSA або Ed25519)
+func NewPublicKey(kid string, pub interface{}) (*Key, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: kid, Algorithm: AlgRS256, verifyKey: pub}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Algorithm: AlgEdDSA, verifyKey: pub}, nil
+	default:
+		return nil, fmt.Errorf("key %s: unsupported public key type %T", kid, pub)
+	}
+}
+
+// CanSign повідомляє, чи є в ключа приватна частина
+func (k *Key) CanSign() bool { return k.signKey != nil }
+
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// KeyManager тримає набір активних ключів, ідентифікованих kid. Токени підписуються
+// поточним ключем, а перевіряються будь-яким ключем з набору – так ротація не розлогінює
+// користувачів, поки старий ключ лишається в наборі.
+type KeyManager struct {
+	mu      sync.RWMutex
+	keys    map[string]*Key
+	current string
+	allowed []string
+}
+
+// NewKeyManager перевіряє набір ключів. allowedAlgs – дозволені алгоритми;
+// порожній список означає "лише алгоритми наявних ключів".
+func NewKeyManager(keys []*Key, currentKID string, allowedAlgs []string) (*KeyManager, error) {
+	m := &KeyManager{}
+	if err := m.Replace(keys, currentKID, allowedAlgs); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Replace атомарно підміняє набір ключів – так виконується ротація без перезапуску
+func (m *KeyManager) Replace(keys []*Key, currentKID string, allowedAlgs []string) error {
+	if len(keys) == 0 {
+		return errors.New("no signing keys configured")
+	}
+	set := make(map[string]*Key, len(keys))
+	for _, k := range keys {
+		if k.ID == "" {
+			return errors.New("key without kid")
+		}
+		if k.method() == nil {
+			return fmt.Errorf("key %s: unsupported algorithm %q", k.ID, k.Algorithm)
+		}
+		if _, dup := set[k.ID]; dup {
+			return fmt.Errorf("duplicate kid %s", k.ID)
+		}
+		set[k.ID] = k
+	}
+
+	if currentKID == "" {
+		signers := make([]string, 0, len(set))
+		for kid, k := range set {
+			if k.CanSign() {
+				signers = append(signers, kid)
+			}
+		}
+		if len(signers) != 1 {
+			return errors.New("current signing key is ambiguous, set JWT_ACTIVE_KID")
+		}
+		currentKID = signers[0]
+	}
+	cur, ok := set[currentKID]
+	if !ok {
+		return fmt.Errorf("current key %s not found", currentKID)
+	}
+	if !cur.CanSign() {
+		return fmt.Errorf("current key %s has no private part", currentKID)
+	}
+
+	if len(allowedAlgs) == 0 {
+		for _, k := range set {
+			if !slices.Contains(allowedAlgs, k.Algorithm) {
+				allowedAlgs = append(allowedAlgs, k.Algorithm)
+			}
+		}
+	}
+	for _, k := range set {
+		if !slices.Contains(allowedAlgs, k.Algorithm) {
+			return fmt.Errorf("key %s uses algorithm %s which is not allowed", k.ID, k.Algorithm)
+		}
+	}
+
+	m.mu.Lock()
+	m.keys, m.current, m.allowed = set, currentKID, allowedAlgs
+	m.mu.Unlock()
+	return nil
+}
+
+// Sign підписує claims поточним ключем і додає kid у заголовок
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	k := m.keys[m.current]
+	m.mu.RUnlock()
+
+	token := jwt.NewWithClaims(k.method(), claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.signKey)
+}
+
+// Parse перевіряє токен. Алгоритм має бути в allow-list і збігатися з алгоритмом
+// ключа kid – інакше можлива атака підміни алгоритму (HS256 з публічним RSA-ключем).
+func (m *KeyManager) Parse(tokenStr string, claims jwt.Claims) (*jwt.Token, error) {
+	m.mu.RLock()
+	allowed := m.allowed
+	m.mu.RUnlock()
+
+	return jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			kid = legacyKID
+		}
+		m.mu.RLock()
+		k, ok := m.keys[kid]
+		m.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		if t.Method.Alg() != k.Algorithm {
+			return nil, fmt.Errorf("algorithm %s does not match key %s", t.Method.Alg(), kid)
+		}
+		return k.verifyKey, nil
+	}, jwt.WithValidMethods(allowed))
+}
+
+// Keys повертає ключі, відсортовані за kid
+func (m *KeyManager) Keys() []*Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*Key, 0, len(m.keys))
+	for _, k := range m.keys {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// LoadFromEnv читає конфігурацію ключів:
+//   - JWT_KEYS_DIR – каталог з файлами <kid>.pem (приватний RSA/Ed25519 ключ),
+//     <kid>.pub.pem (лише перевірка) та <kid>.secret (HS256);
+//   - JWT_ACTIVE_KID – ключ, яким підписуються нові токени;
+//   - JWT_ALLOWED_ALGS – allow-list алгоритмів через кому;
+//   - JWT_SECRET – якщо задано, додається як HS256 ключ з kid "default".
+func LoadFromEnv() (*KeyManager, error) {
+	keys, err := loadKeys(os.Getenv("JWT_KEYS_DIR"), os.Getenv("JWT_SECRET"))
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyManager(keys, os.Getenv("JWT_ACTIVE_KID"), splitList(os.Getenv("JWT_ALLOWED_ALGS")))
+}
+
+// ReloadFromEnv перечитує ключі в наявний менеджер (наприклад, по SIGHUP)
+func (m *KeyManager) ReloadFromEnv() error {
+	keys, err := loadKeys(os.Getenv("JWT_KEYS_DIR"), os.Getenv("JWT_SECRET"))
+	if err != nil {
+		return err
+	}
+	return m.Replace(keys, os.Getenv("JWT_ACTIVE_KID"), splitList(os.Getenv("JWT_ALLOWED_ALGS")))
+}
+
+func loadKeys(dir, secret string) ([]*Key, error) {
+	var keys []*Key
+	if secret != "" {
+		keys = append(keys, NewHMACKey(legacyKID, []byte(secret)))
+	}
+	if dir == "" {
+		return keys, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read keys dir: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read key %s: %w", name, err)
+		}
+		var k *Key
+		switch {
+		case strings.HasSuffix(name, ".pub.pem"):
+			k, err = ParsePublicKeyPEM(strings.TrimSuffix(name, ".pub.pem"), data)
+		case strings.HasSuffix(name, ".pem"):
+			k, err = ParsePrivateKeyPEM(strings.TrimSuffix(name, ".pem"), data)
+		case strings.HasSuffix(name, ".secret"):
+			k = NewHMACKey(strings.TrimSuffix(name, ".secret"), []byte(strings.TrimSpace(string(data))))
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// ParsePrivateKeyPEM розбирає приватний RSA (PKCS#1/PKCS#8) або Ed25519 (PKCS#8) ключ
+func ParsePrivateKeyPEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM block", kid)
+	}
+	if priv, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewRSAKey(kid, priv), nil
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", kid, err)
+	}
+	switch p := priv.(type) {
+	case *rsa.PrivateKey:
+		return NewRSAKey(kid, p), nil
+	case ed25519.PrivateKey:
+		return NewEd25519Key(kid, p), nil
+	default:
+		return nil, fmt.Errorf("key %s: unsupported private key type %T", kid, priv)
+	}
+}
+
+// ParsePublicKeyPEM розбирає публічний ключ у форматі PKIX
+func ParsePublicKeyPEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM block", kid)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", kid, err)
+	}
+	return NewPublicKey(kid, pub)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
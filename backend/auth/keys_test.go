@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testRSAKey(t *testing.T, kid string) *Key {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("не вдалося згенерувати RSA ключ: %v", err)
+	}
+	return NewRSAKey(kid, priv)
+}
+
+func testEdKey(t *testing.T, kid string) *Key {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("не вдалося згенерувати Ed25519 ключ: %v", err)
+	}
+	return NewEd25519Key(kid, priv)
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": "user-1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestKeyManager_SignAndParse(t *testing.T) {
+	for _, k := range []*Key{
+		NewHMACKey("hs", []byte("secret")),
+		testRSAKey(t, "rs"),
+		testEdKey(t, "ed"),
+	} {
+		m, err := NewKeyManager([]*Key{k}, "", nil)
+		if err != nil {
+			t.Fatalf("%s: NewKeyManager: %v", k.Algorithm, err)
+		}
+		tok, err := m.Sign(claims())
+		if err != nil {
+			t.Fatalf("%s: Sign: %v", k.Algorithm, err)
+		}
+		got := jwt.MapClaims{}
+		parsed, err := m.Parse(tok, got)
+		if err != nil || !parsed.Valid {
+			t.Fatalf("%s: Parse: %v", k.Algorithm, err)
+		}
+		if parsed.Header["kid"] != k.ID || got["user_id"] != "user-1" {
+			t.Errorf("%s: неправильний токен: header=%v claims=%v", k.Algorithm, parsed.Header, got)
+		}
+	}
+}
+
+func TestKeyManager_RotationKeepsOldTokensValid(t *testing.T) {
+	oldKey, newKey := testEdKey(t, "2025-01"), testEdKey(t, "2025-02")
+	m, err := NewKeyManager([]*Key{oldKey}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldTok, _ := m.Sign(claims())
+
+	if err := m.Replace([]*Key{oldKey, newKey}, "2025-02", nil); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	newTok, _ := m.Sign(claims())
+
+	if _, err := m.Parse(oldTok, jwt.MapClaims{}); err != nil {
+		t.Errorf("старий токен має лишатися дійсним після ротації: %v", err)
+	}
+	parsed, err := m.Parse(newTok, jwt.MapClaims{})
+	if err != nil || parsed.Header["kid"] != "2025-02" {
+		t.Errorf("новий токен має бути підписаний новим ключем: %v", err)
+	}
+
+	// після виведення старого ключа з набору його токени більше не приймаються
+	if err := m.Replace([]*Key{newKey}, "2025-02", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Parse(oldTok, jwt.MapClaims{}); err == nil {
+		t.Error("токен з видаленим kid не повинен прийматися")
+	}
+}
+
+func TestKeyManager_RejectsAlgorithmConfusion(t *testing.T) {
+	rs := testRSAKey(t, "rs")
+	m, err := NewKeyManager([]*Key{rs}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// HS256-токен, "підписаний" публічним RSA-ключем, з kid RSA-ключа
+	pubDER, _ := x509.MarshalPKIXPublicKey(rs.verifyKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	forged.Header["kid"] = "rs"
+	tok, _ := forged.SignedString(pubDER)
+
+	if _, err := m.Parse(tok, jwt.MapClaims{}); err == nil {
+		t.Error("токен з алгоритмом поза allow-list не повинен прийматися")
+	}
+}
+
+func TestKeyManager_RejectsAlgNotMatchingKey(t *testing.T) {
+	hs := NewHMACKey("hs", []byte("secret"))
+	ed := testEdKey(t, "ed")
+	m, err := NewKeyManager([]*Key{hs, ed}, "hs", []string{AlgHS256, AlgEdDSA})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// алгоритм дозволений, але kid вказує на ключ іншого типу
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	forged.Header["kid"] = "ed"
+	tok, _ := forged.SignedString([]byte("secret"))
+
+	if _, err := m.Parse(tok, jwt.MapClaims{}); err == nil {
+		t.Error("алгоритм токена має збігатися з алгоритмом ключа")
+	}
+}
+
+func TestKeyManager_UnknownKID(t *testing.T) {
+	m, _ := NewKeyManager([]*Key{NewHMACKey("a", []byte("secret"))}, "", nil)
+	other, _ := NewKeyManager([]*Key{NewHMACKey("b", []byte("secret"))}, "", nil)
+	tok, _ := other.Sign(claims())
+
+	if _, err := m.Parse(tok, jwt.MapClaims{}); err == nil {
+		t.Error("токен з невідомим kid не повинен прийматися")
+	}
+}
+
+func TestNewKeyManager_Validation(t *testing.T) {
+	ed := testEdKey(t, "ed")
+	if _, err := NewKeyManager(nil, "", nil); err == nil {
+		t.Error("очікував помилку для порожнього набору")
+	}
+	if _, err := NewKeyManager([]*Key{ed}, "missing", nil); err == nil {
+		t.Error("очікував помилку для невідомого поточного kid")
+	}
+	if _, err := NewKeyManager([]*Key{ed}, "", []string{AlgRS256}); err == nil {
+		t.Error("очікував помилку для ключа з алгоритмом поза allow-list")
+	}
+	pub, _ := NewPublicKey("pub", ed.verifyKey)
+	if _, err := NewKeyManager([]*Key{pub}, "pub", nil); err == nil {
+		t.Error("ключ без приватної частини не може підписувати")
+	}
+	if _, err := NewKeyManager([]*Key{ed, testEdKey(t, "ed2")}, "", nil); err == nil {
+		t.Error("очікував помилку, коли поточний ключ неоднозначний")
+	}
+}
+
+func TestJWKS_PublishesOnlyPublicKeys(t *testing.T) {
+	m, err := NewKeyManager([]*Key{
+		NewHMACKey("hs", []byte("secret")),
+		testRSAKey(t, "rs"),
+		testEdKey(t, "ed"),
+	}, "ed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	m.JWKSHandler(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", rec.Code)
+	}
+	var set JWKSet
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("не вдалося розпарсити JWKS: %v", err)
+	}
+	if len(set.Keys) != 2 {
+		t.Fatalf("очікував 2 публічні ключі, отримав %+v", set.Keys)
+	}
+	for _, k := range set.Keys {
+		if k.KeyID == "hs" {
+			t.Error("HS256-секрет не повинен публікуватися")
+		}
+		if k.KeyID == "rs" && (k.KeyType != "RSA" || k.N == "" || k.E == "") {
+			t.Errorf("неповний RSA JWK: %+v", k)
+		}
+		if k.KeyID == "ed" && (k.KeyType != "OKP" || k.Curve != "Ed25519" || k.X == "") {
+			t.Errorf("неповний Ed25519 JWK: %+v", k)
+		}
+	}
+}
+
+func TestLoadFromEnv_KeysDir(t *testing.T) {
+	dir := t.TempDir()
+	_, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edPriv)
+	os.WriteFile(filepath.Join(dir, "ed-1.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+
+	rsPriv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubDER, _ := x509.MarshalPKIXPublicKey(&rsPriv.PublicKey)
+	os.WriteFile(filepath.Join(dir, "rs-old.pub.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o600)
+
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_ACTIVE_KID", "ed-1")
+	t.Setenv("JWT_ALLOWED_ALGS", "EdDSA, RS256")
+
+	m, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv: %v", err)
+	}
+	keys := m.Keys()
+	if len(keys) != 2 || keys[0].ID != "ed-1" || !keys[0].CanSign() || keys[1].ID != "rs-old" || keys[1].CanSign() {
+		t.Errorf("неправильний набір ключів: %+v", keys)
+	}
+}
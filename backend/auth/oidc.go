@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig – налаштування клієнта зовнішнього провайдера (SSO)
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string // порожній для публічного клієнта: PKCE все одно обов'язковий
+	RedirectURL  string // наш /api/auth/oidc/callback
+	Scopes       []string
+}
+
+// OIDCConfigFromEnv читає OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET,
+// OIDC_REDIRECT_URL та OIDC_SCOPES. ok=false, якщо SSO не налаштовано.
+func OIDCConfigFromEnv() (cfg OIDCConfig, ok bool) {
+	cfg = OIDCConfig{
+		Issuer:       os.Getenv("OIDC_ISSUER"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       splitList(os.Getenv("OIDC_SCOPES")),
+	}
+	return cfg, cfg.Issuer != "" && cfg.ClientID != ""
+}
+
+// OIDCIdentity – перевірені дані користувача з ID-токена
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// OIDCProvider виконує authorization code flow з PKCE і перевіряє ID-токени
+type OIDCProvider struct {
+	Issuer   string
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider завантажує discovery-документ провайдера
+func NewOIDCProvider(ctx context.Context, cfg OIDCConfig) (*OIDCProvider, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"email"}
+	}
+	return &OIDCProvider{
+		Issuer: cfg.Issuer,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, scopes...),
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+// AuthCodeURL будує адресу сторінки входу провайдера з S256 code_challenge
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	return p.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier))
+}
+
+// Exchange обмінює code на токени і перевіряє ID-токен: підпис (JWKS провайдера),
+// iss, aud, exp та nonce, виданий разом зі state.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*OIDCIdentity, error) {
+	tok, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange: %w", err)
+	}
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok || raw == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	idToken, err := p.verifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("id_token claims: %w", err)
+	}
+	return &OIDCIdentity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
+
+// NewPKCEVerifier генерує code_verifier за RFC 7636
+func NewPKCEVerifier() string {
+	return oauth2.GenerateVerifier()
+}
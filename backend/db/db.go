@@ -3,39 +3,116 @@ package db
 import (
 	"errors"
 	"fmt"
-	"os"
+	"net/url"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+
+	"moodtracker/migrations"
 )
 
-var DB Database
+// Dialect – діалект SQL, що визначається схемою DATABASE_URL
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+func init() {
+	// sqlx не знає драйвер modernc.org/sqlite, тож Rebind має перетворювати "?" як для sqlite3
+	sqlx.BindDriver("sqlite", sqlx.QUESTION)
+}
 
 type Database struct {
 	*sqlx.DB
+	Dialect Dialect
 }
 
-func NewDB() error {
-	connStr := os.Getenv("DATABASE_URL")
-	var err error
-	DB.DB, err = sqlx.Connect("postgres", connStr)
+// Open підключається до БД за рядком підключення connStr:
+// postgres://... або postgresql://... – PostgreSQL,
+// sqlite://шлях/до/файлу.db (або sqlite:///абсолютний/шлях.db) – вбудований SQLite.
+func Open(connStr string) (*Database, error) {
+	dialect, dsn, err := parseURL(connStr)
 	if err != nil {
-		return fmt.Errorf("opening new DB connection: %w", err)
+		return nil, err
 	}
 
-	return nil
+	conn, err := sqlx.Connect(string(dialect), dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening new DB connection: %w", err)
+	}
+	if dialect == SQLite {
+		// SQLite дозволяє лише одного writer'а: одне з'єднання знімає "database is locked"
+		conn.SetMaxOpenConns(1)
+	}
+
+	return &Database{DB: conn, Dialect: dialect}, nil
 }
 
-func (db *Database) MigrateUp() error {
-	filePath := "file:///app/migrations"
-	connStr := os.Getenv("DATABASE_URL")
+// parseURL повертає діалект і DSN для драйвера database/sql
+func parseURL(connStr string) (Dialect, string, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return "", "", fmt.Errorf("parse DATABASE_URL: %w", err)
+	}
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		return Postgres, connStr, nil
+	case "sqlite":
+		path := u.Host + u.Path
+		if u.Opaque != "" {
+			path = u.Opaque // sqlite:mood.db
+		}
+		if path == "" {
+			return "", "", errors.New("sqlite DATABASE_URL has no file path")
+		}
+		// Часові мітки пишемо в одному форматі, щоб їх можна було порівнювати як рядки
+		return SQLite, "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite", nil
+	default:
+		return "", "", fmt.Errorf("unsupported DATABASE_URL scheme %q", u.Scheme)
+	}
+}
+
+// Migrator повертає golang-migrate поверх відкритого з'єднання і вбудованих міграцій
+// для діалекту БД. Закривати його не потрібно: з'єднанням володіє Database.
+func (db *Database) Migrator() (*migrate.Migrate, error) {
+	var (
+		driver database.Driver
+		err    error
+	)
+	switch db.Dialect {
+	case SQLite:
+		driver, err = sqlite.WithInstance(db.DB.DB, &sqlite.Config{})
+	default:
+		driver, err = postgres.WithInstance(db.DB.DB, &postgres.Config{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create migration err: %w", err)
+	}
 
-	m, err := migrate.New(filePath, connStr)
+	src, err := iofs.New(migrations.FS, string(db.Dialect))
+	if err != nil {
+		return nil, fmt.Errorf("create migration err: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", src, string(db.Dialect), driver)
+	if err != nil {
+		return nil, fmt.Errorf("create migration err: %w", err)
+	}
+	return m, nil
+}
+
+// MigrateUp застосовує всі ще не застосовані міграції
+func (db *Database) MigrateUp() error {
+	m, err := db.Migrator()
 	if err != nil {
-		return fmt.Errorf("create migration err: %w", err)
+		return err
 	}
 
 	err = m.Up()
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	cases := []struct {
+		url     string
+		dialect Dialect
+		dsn     string
+	}{
+		{"postgres://u:p@db:5432/mood?sslmode=disable", Postgres, "postgres://u:p@db:5432/mood?sslmode=disable"},
+		{"postgresql://u@localhost/mood", Postgres, "postgresql://u@localhost/mood"},
+		{"sqlite://data/mood.db", SQLite, "file:data/mood.db?"},
+		{"sqlite:///var/lib/mood/mood.db", SQLite, "file:/var/lib/mood/mood.db?"},
+		{"sqlite:mood.db", SQLite, "file:mood.db?"},
+	}
+	for _, c := range cases {
+		dialect, dsn, err := parseURL(c.url)
+		if err != nil {
+			t.Errorf("%s: %v", c.url, err)
+			continue
+		}
+		if dialect != c.dialect || !strings.HasPrefix(dsn, c.dsn) {
+			t.Errorf("%s: отримав %s %q", c.url, dialect, dsn)
+		}
+	}
+	if dsn := mustDSN(t, "sqlite://mood.db"); !strings.Contains(dsn, "foreign_keys(1)") || !strings.Contains(dsn, "_time_format=sqlite") {
+		t.Errorf("DSN SQLite без потрібних параметрів: %q", dsn)
+	}
+
+	for _, bad := range []string{"mysql://localhost/mood", "sqlite://", ""} {
+		if _, _, err := parseURL(bad); err == nil {
+			t.Errorf("%q: очікував помилку", bad)
+		}
+	}
+}
+
+func mustDSN(t *testing.T, url string) string {
+	_, dsn, err := parseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dsn
+}
@@ -0,0 +1,270 @@
+// Package entries – внесення настрою за тими самими правилами для всіх клієнтів:
+// HTTP API і Telegram-бот перевіряють іконку, дату і режим внесення однаково.
+package entries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"moodtracker/i18n"
+	"moodtracker/models"
+	"moodtracker/repository"
+	"moodtracker/validate"
+)
+
+// Помилки полів, які перевіряє сервіс поверх тегів Input (див. validate.Field)
+var (
+	ErrCommentRequired = validate.Field("comment", validate.Required)
+	ErrUnknownIcon     = validate.Field("icon", "unknown_icon")
+)
+
+// ErrExists – у режимі "один запис на день" на цю дату вже є запис
+var ErrExists = i18n.NewError("mood_already_exists")
+
+// Input – новий запис настрою. Довжина icon відповідає колонці VARCHAR(50).
+type Input struct {
+	Icon     string     `json:"icon" validate:"required,max=50"`
+	Comment  string     `json:"comment" validate:"required,max=1000"`
+	Date     string     `json:"date" validate:"date"` // "YYYY-MM-DD"; порожня – день logged_at або сьогодні в поясі користувача
+	LoggedAt *time.Time `json:"logged_at"`            // необов'язковий час запису (RFC 3339)
+}
+
+// DefaultBackfillDays – вікно внесення заднім числом, якщо MOOD_BACKFILL_DAYS не задано
+const DefaultBackfillDays = 365
+
+// BackfillDaysFromEnv читає MOOD_BACKFILL_DAYS: кількість днів або 0 – без обмежень
+func BackfillDaysFromEnv() (int, error) {
+	v := os.Getenv("MOOD_BACKFILL_DAYS")
+	if v == "" {
+		return DefaultBackfillDays, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid MOOD_BACKFILL_DAYS %q, want a non-negative number of days", v)
+	}
+	return n, nil
+}
+
+// Service створює записи настрою
+type Service struct {
+	Moods repository.MoodRepository
+	Users repository.UserRepository
+	Icons repository.IconRepository
+	// BackfillDays – за скільки днів до сьогодні можна внести настрій; 0 – без обмежень
+	BackfillDays int
+}
+
+// Create перевіряє in і зберігає запис. Помилки: validate.Errors, ErrExists або помилка сховища.
+func (s *Service) Create(ctx context.Context, userID string, in Input) (*models.Mood, error) {
+	if err := validate.Struct(in); err != nil {
+		return nil, err
+	}
+	settings, err := s.Users.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Без явної дати беремо день з logged_at або сьогодні в поясі користувача
+	now := time.Now()
+	today := settings.Today(now)
+	var dt time.Time
+	switch {
+	case in.Date != "":
+		dt, _ = time.Parse("2006-01-02", in.Date) // формат перевірено тегом date
+		if in.LoggedAt != nil && in.LoggedAt.Format("2006-01-02") != in.Date {
+			return nil, validate.Errors{validate.Field("logged_at", "logged_at_date")}
+		}
+	case in.LoggedAt != nil:
+		dt = *in.LoggedAt
+	default:
+		dt = today
+	}
+	field := "date"
+	if in.Date == "" {
+		field = "logged_at"
+	}
+	if err := checkLoggedAt(in.LoggedAt, now); err != nil {
+		return nil, err
+	}
+	if err := s.checkDay(field, dt, today); err != nil {
+		return nil, err
+	}
+
+	icon, err := ResolveIcon(ctx, s.Icons, userID, in.Icon)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, validate.Errors{ErrUnknownIcon}
+	} else if err != nil {
+		return nil, err
+	}
+	single := settings.EntryMode != models.EntryModeMultiple
+
+	// У режимі "один на день" враховуємо й записи, зроблені раніше в режимі "кілька на день"
+	if single {
+		day := repository.MoodFilter{From: &dt, To: &dt}
+		existing, err := s.Moods.List(ctx, userID, day)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			return nil, ErrExists
+		}
+	}
+
+	m := models.Mood{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Date:      dt,
+		LoggedAt:  in.LoggedAt,
+		Icon:      in.Icon,
+		Score:     &icon.Score,
+		Comment:   in.Comment,
+		OnePerDay: single,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Паралельний запис на той самий день відсікає унікальний індекс у БД
+	if err := s.Moods.Create(ctx, &m); errors.Is(err, repository.ErrConflict) {
+		return nil, ErrExists
+	} else if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// clockSkew – наскільки logged_at може випереджати годинник сервера
+const clockSkew = 5 * time.Minute
+
+// checkLoggedAt не пускає час запису в майбутнє
+func checkLoggedAt(at *time.Time, now time.Time) error {
+	if at != nil && at.After(now.Add(clockSkew)) {
+		return validate.Errors{validate.Field("logged_at", "future_date")}
+	}
+	return nil
+}
+
+// checkDay не пускає записи в майбутнє і раніше за вікно BackfillDays.
+// День dt порівнюється з today – сьогодні в поясі користувача; field – поле, з якого взято день.
+func (s *Service) checkDay(field string, dt, today time.Time) error {
+	day := time.Date(dt.Year(), dt.Month(), dt.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case day.After(today):
+		return validate.Errors{validate.Field(field, "future_date")}
+	case s.BackfillDays > 0 && day.Before(today.AddDate(0, 0, -s.BackfillDays)):
+		return validate.Errors{validate.FieldN(field, "date_too_old", s.BackfillDays)}
+	}
+	return nil
+}
+
+// Patch – часткова зміна запису за JSON Merge Patch: відсутні поля не змінюються,
+// "logged_at": null прибирає час запису. Якщо date змінено без logged_at,
+// час запису переноситься на новий день разом із записом.
+type Patch struct {
+	Icon     validate.Optional[string]    `json:"icon" validate:"required,max=50"`
+	Comment  validate.Optional[string]    `json:"comment" validate:"required,max=1000"`
+	Date     validate.Optional[string]    `json:"date" validate:"required,date"`
+	LoggedAt validate.Optional[time.Time] `json:"logged_at"`
+	// Version – версія, яку бачив клієнт (If-Match); 0 – без перевірки
+	Version int `json:"-"`
+}
+
+// Update застосовує p до запису id і повертає оновлений запис.
+// Помилки: validate.Errors, ErrExists (день уже зайнято), repository.ErrNotFound,
+// repository.ErrStale (запис змінили після того, як клієнт його прочитав) або помилка сховища.
+func (s *Service) Update(ctx context.Context, userID, id string, p Patch) (*models.Mood, error) {
+	if err := validate.Struct(p); err != nil {
+		return nil, err
+	}
+	m, err := s.Moods.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	// Між читанням і записом версію ще раз перевіряє Moods.Update
+	if p.Version != 0 && m.Version != p.Version {
+		return nil, repository.ErrStale
+	}
+	if !p.Icon.Set && !p.Comment.Set && !p.Date.Set && !p.LoggedAt.Set {
+		return m, nil
+	}
+	settings, err := s.Users.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	if p.Icon.Set {
+		icon, err := ResolveIcon(ctx, s.Icons, userID, p.Icon.Value)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, validate.Errors{ErrUnknownIcon}
+		} else if err != nil {
+			return nil, err
+		}
+		m.Icon, m.Score = p.Icon.Value, &icon.Score
+	}
+	if p.Comment.Set {
+		m.Comment = p.Comment.Value
+	}
+
+	date := m.Date.Format("2006-01-02")
+	moved := p.Date.Set && p.Date.Value != date
+	if moved {
+		dt, _ := time.Parse("2006-01-02", p.Date.Value) // формат перевірено тегом date
+		if err := s.checkDay("date", dt, settings.Today(now)); err != nil {
+			return nil, err
+		}
+		if m.LoggedAt != nil && !p.LoggedAt.Set {
+			// зсув у поясі користувача зберігає годину запису і при переході на літній час
+			days := int(dt.Sub(time.Date(m.Date.Year(), m.Date.Month(), m.Date.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+			at := m.LoggedAt.In(settings.Location()).AddDate(0, 0, days)
+			m.LoggedAt = &at
+		}
+		m.Date, date = dt, p.Date.Value
+	}
+	switch {
+	case p.LoggedAt.Null:
+		m.LoggedAt = nil
+	case p.LoggedAt.Set:
+		if err := checkLoggedAt(&p.LoggedAt.Value, now); err != nil {
+			return nil, err
+		}
+		if p.LoggedAt.Value.Format("2006-01-02") != date {
+			return nil, validate.Errors{validate.Field("logged_at", "logged_at_date")}
+		}
+		m.LoggedAt = &p.LoggedAt.Value
+	}
+
+	// Перенесення в режимі "один на день": день має бути вільним, як і при створенні
+	if moved && settings.EntryMode != models.EntryModeMultiple {
+		day := repository.MoodFilter{From: &m.Date, To: &m.Date}
+		existing, err := s.Moods.List(ctx, userID, day)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			return nil, ErrExists
+		}
+	}
+
+	m.UpdatedAt = now
+	if err := s.Moods.Update(ctx, m); errors.Is(err, repository.ErrConflict) {
+		return nil, ErrExists
+	} else if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ResolveIcon шукає icon у вбудованому каталозі, а потім серед власних іконок користувача.
+// Повертає repository.ErrNotFound, якщо іконки немає ніде.
+func ResolveIcon(ctx context.Context, icons repository.IconRepository, userID, icon string) (*models.MoodIcon, error) {
+	if mi, ok := models.CatalogIcon(icon); ok {
+		return &mi, nil
+	}
+	return icons.Get(ctx, userID, icon)
+}
@@ -1,74 +1,266 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
-	"moodtracker/db"
+	"moodtracker/auth"
+	"moodtracker/i18n"
+	"moodtracker/mailer"
+	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/repository"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+const (
+	loginCodeTTL          = 15 * time.Minute
+	maxLoginCodeAttempts  = 5         // спроб на один код
+	maxLoginEmailAttempts = 10        // спроб на всі коди одного email за loginCodeWindow
+	maxLoginCodesPerHour  = 5         // виданих кодів на один email
+	loginCodeWindow       = time.Hour // вікно лімітів; старші коди можна видаляти
+)
+
+// loginLimits – ліміти видачі кодів і спроб на момент now
+func loginLimits(now time.Time) repository.LoginLimits {
+	return repository.LoginLimits{
+		Since:         now.Add(-loginCodeWindow),
+		Codes:         maxLoginCodesPerHour,
+		CodeAttempts:  maxLoginCodeAttempts,
+		EmailAttempts: maxLoginEmailAttempts,
+	}
+}
+
+// AuthHandler обслуговує /auth: вхід за кодом з email, SSO та сесії
+type AuthHandler struct {
+	Users      repository.UserRepository
+	LoginCodes repository.LoginCodeRepository
+	Sessions   repository.SessionRepository
+	Identities repository.IdentityRepository
+	Keys       *auth.KeyManager
+	Mailer     mailer.Mailer
+	OIDC       *auth.OIDCProvider // nil, якщо вхід через SSO вимкнено
+}
+
+func NewAuthHandler(repos repository.Set, keys *auth.KeyManager, m mailer.Mailer) *AuthHandler {
+	return &AuthHandler{
+		Users:      repos.Users,
+		LoginCodes: repos.LoginCodes,
+		Sessions:   repos.Sessions,
+		Identities: repos.Identities,
+		Keys:       keys,
+		Mailer:     m,
+	}
+}
+
 type authRequest struct {
 	Email string `json:"email"`
 }
 
-type authResponse struct {
-	Token string `json:"token"`
+type verifyRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
 }
 
-// RegisterAuthRoutes підключає маршрути /auth
-func RegisterAuthRoutes(r chi.Router) {
-	r.Post("/login", LoginHandler)
+// Routes підключає маршрути /auth
+func (h *AuthHandler) Routes(r chi.Router) {
+	r.Post("/login", h.Login)
+	r.Post("/verify", h.Verify)
+	r.Post("/refresh", h.Refresh)
+	r.Get("/oidc/login", h.OIDCLogin)
+	r.Get("/oidc/callback", h.OIDCCallback)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.JWTAuth(h.Keys, h.Sessions))
+		r.Post("/logout", h.Logout)
+		r.Post("/logout-all", h.LogoutAll)
+	})
 }
 
-// loginHandler – створює користувача (якщо нового) і повертає JWT
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+// Login – генерує одноразовий код і надсилає його (разом із посиланням) на email
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req authRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		fail(w, r, http.StatusBadRequest, "invalid_json")
 		return
 	}
-	if req.Email == "" {
-		http.Error(w, "email is required", http.StatusBadRequest)
+	email := normalizeEmail(req.Email)
+	if email == "" {
+		fail(w, r, http.StatusBadRequest, "email_required")
 		return
 	}
 
-	// Шукаємо або створюємо користувача
-	var userID string
-	err := db.DB.Get(&userID, "SELECT id FROM users WHERE email=$1", req.Email)
-	if err == sql.ErrNoRows {
-		userID = uuid.NewString()
-		_, err = db.DB.Exec(
-			`INSERT INTO users (id, email) VALUES ($1, $2)`,
-			userID, req.Email,
-		)
-		if err != nil {
-			http.Error(w, "failed to create user: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	code, err := newLoginCode()
+	if err != nil {
+		failInternal(w, r, fmt.Errorf("generate code: %w", err))
+		return
+	}
+	// Обмежуємо кількість кодів, щоб не перетворити нас на спам-розсилку;
+	// ліміт перевіряється разом із записом, тож паралельні запити його не обійдуть
+	now := time.Now()
+	created, err := h.LoginCodes.CreateLimited(r.Context(), &models.LoginCode{
+		ID:        uuid.NewString(),
+		Email:     email,
+		CodeHash:  hashSecret(code),
+		ExpiresAt: now.Add(loginCodeTTL),
+	}, loginLimits(now))
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	if !created {
+		fail(w, r, http.StatusTooManyRequests, "too_many_login_requests")
+		return
+	}
+
+	if err := h.Mailer.Send(r.Context(), loginMessage(locale(r), email, code)); err != nil {
+		log.Printf("failed to send login code to %s: %v", email, err)
+		fail(w, r, http.StatusInternalServerError, "login_email_failed")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Prune видаляє коди входу, які вже не потрібні ні для входу, ні для ліміту видачі,
+// прострочені state незавершених входів через SSO і мертві refresh-токени
+func (h *AuthHandler) Prune(ctx context.Context, now time.Time) error {
+	if err := h.LoginCodes.Prune(ctx, now.Add(-loginCodeWindow)); err != nil {
+		return err
+	}
+	if err := h.Sessions.Prune(ctx, now.Add(-sessionRetention)); err != nil {
+		return err
+	}
+	return h.Identities.PruneStates(ctx, now)
+}
+
+// Verify – обмінює одноразовий код на access- і refresh-токени, створюючи користувача за потреби
+func (h *AuthHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req verifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	email := normalizeEmail(req.Email)
+	code := strings.TrimSpace(req.Code)
+	if email == "" || code == "" {
+		fail(w, r, http.StatusBadRequest, "email_and_code_required")
+		return
+	}
+
+	// Перевіряємо лише найсвіжіший активний код для email
+	lc, err := h.LoginCodes.LatestActive(r.Context(), email, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusUnauthorized, "invalid_code")
+		return
 	} else if err != nil {
-		http.Error(w, "db error: "+err.Error(), http.StatusInternalServerError)
+		failInternal(w, r, err)
+		return
+	}
+	// Спробу зараховуємо до порівняння коду: паралельні вгадування не обійдуть ліміт.
+	// Ліміт діє і на всі коди email разом, тож новий код не дає нових спроб без кінця.
+	allowed, err := h.LoginCodes.IncrementAttempts(r.Context(), lc, loginLimits(time.Now()))
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	if !allowed {
+		fail(w, r, http.StatusTooManyRequests, "too_many_attempts")
 		return
 	}
 
-	// Створюємо JWT
-	secret := []byte(os.Getenv("JWT_SECRET"))
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(72 * time.Hour).Unix(), // термін 3 дні
-	})
-	tokenStr, err := token.SignedString(secret)
+	if subtle.ConstantTimeCompare([]byte(lc.CodeHash), []byte(hashSecret(code))) != 1 {
+		fail(w, r, http.StatusUnauthorized, "invalid_code")
+		return
+	}
+
+	// Позначаємо код використаним; повторне використання відхиляється
+	used, err := h.LoginCodes.MarkUsed(r.Context(), lc.ID, time.Now())
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	if !used {
+		fail(w, r, http.StatusUnauthorized, "invalid_code")
+		return
+	}
+
+	userID, err := h.findOrCreateUser(r.Context(), email)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	pair, err := h.startSession(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "failed to sign token: "+err.Error(), http.StatusInternalServerError)
+		failInternal(w, r, fmt.Errorf("issue tokens: %w", err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(authResponse{Token: tokenStr})
+	json.NewEncoder(w).Encode(pair)
+}
+
+// findOrCreateUser шукає користувача за email або створює нового
+func (h *AuthHandler) findOrCreateUser(ctx context.Context, email string) (string, error) {
+	u, err := h.Users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		u = &models.User{ID: uuid.NewString(), Email: email}
+		if err := h.Users.Create(ctx, u); err != nil {
+			return "", fmt.Errorf("create user: %w", err)
+		}
+		return u.ID, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return u.ID, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// newLoginCode генерує 6-значний код криптографічно стійким генератором
+func newLoginCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashSecret – у БД зберігаємо лише SHA-256 від кодів входу та refresh-токенів
+func hashSecret(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func loginMessage(l i18n.Locale, email, code string) mailer.Message {
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost:5173"
+	}
+	link := fmt.Sprintf("%s/login/verify?email=%s&code=%s",
+		strings.TrimRight(base, "/"), url.QueryEscape(email), code)
+
+	return mailer.Message{
+		To:      email,
+		Subject: i18n.T(l, "email.login.subject"),
+		Body:    i18n.T(l, "email.login.body", code, link) + "\n\n" + i18n.N(l, "email.login.ttl", int(loginCodeTTL.Minutes())),
+	}
 }
@@ -2,128 +2,415 @@ package handlers
 
 import (
 	"bytes"
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"regexp"
+	"sync"
 	"testing"
+	"time"
 
-	"moodtracker/db"
+	"moodtracker/auth"
+	"moodtracker/mailer"
+	"moodtracker/models"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
 
-	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/jmoiron/sqlx"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-func setupAuthTest(t *testing.T) (sqlmock.Sqlmock, func()) {
-	mockDB, mock, err := sqlmock.New()
+var errDB = errors.New("db down")
+
+// fakeMailer запам'ятовує відправлені листи замість доставки
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+	err  error
+}
+
+func (f *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// codeFrom витягує 6-значний код з тексту листа
+func codeFrom(t *testing.T, msg mailer.Message) string {
+	code := regexp.MustCompile(`\b\d{6}\b`).FindString(msg.Body)
+	if code == "" {
+		t.Fatalf("у листі немає коду: %q", msg.Body)
+	}
+	return code
+}
+
+// testKeys – HS256-ключ для підпису токенів у тестах
+func testKeys(t *testing.T) *auth.KeyManager {
+	km, err := auth.NewKeyManager([]*auth.Key{auth.NewHMACKey("test", []byte("testsecret"))}, "", nil)
 	if err != nil {
-		t.Fatalf("не вдалося створити sqlmock: %v", err)
+		t.Fatalf("не вдалося створити менеджер ключів: %v", err)
 	}
-	sqlxDB := sqlx.NewDb(mockDB, "postgres")
-	db.DB.DB = sqlxDB
+	return km
+}
+
+// setupAuthTest створює AuthHandler поверх порожніх репозиторіїв у пам'яті
+func setupAuthTest(t *testing.T) (*AuthHandler, repository.Set, *fakeMailer) {
+	repos := memory.New()
+	fm := &fakeMailer{}
+	return NewAuthHandler(repos, testKeys(t), fm), repos, fm
+}
 
-	// встановлюємо секрет для підпису JWT
-	if err := os.Setenv("JWT_SECRET", "testsecret"); err != nil {
-		t.Fatalf("не вдалося встановити JWT_SECRET: %v", err)
+// userFromToken повертає user_id з access-токена
+func userFromToken(t *testing.T, h *AuthHandler, token string) string {
+	claims := jwt.MapClaims{}
+	if _, err := h.Keys.Parse(token, claims); err != nil {
+		t.Fatalf("невалідний access-токен: %v", err)
 	}
+	userID, _ := claims["user_id"].(string)
+	return userID
+}
 
-	return mock, func() { mockDB.Close() }
+// seedCode зберігає активний код входу для email
+func seedCode(t *testing.T, repos repository.Set, email, code string, attempts int) {
+	err := repos.LoginCodes.Create(context.Background(), &models.LoginCode{
+		ID:        "code-1",
+		Email:     email,
+		CodeHash:  hashSecret(code),
+		Attempts:  attempts,
+		ExpiresAt: time.Now().Add(loginCodeTTL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
 }
 
-func TestLoginHandler_BadJSON(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString("not-json"))
+// racingCodes імітує паралельний запит, що встиг використати код першим
+type racingCodes struct{ repository.LoginCodeRepository }
+
+func (racingCodes) MarkUsed(context.Context, string, time.Time) (bool, error) { return false, nil }
+
+// brokenCodes імітує недоступну БД
+type brokenCodes struct{ repository.LoginCodeRepository }
+
+func (brokenCodes) CreateLimited(context.Context, *models.LoginCode, repository.LoginLimits) (bool, error) {
+	return false, errDB
+}
+
+func doLoginRequest(h *AuthHandler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(body))
 	w := httptest.NewRecorder()
+	h.Login(w, req)
+	return w
+}
 
-	LoginHandler(w, req)
+func TestLoginHandler_BadJSON(t *testing.T) {
+	h, _, _ := setupAuthTest(t)
 
+	w := doLoginRequest(h, "not-json")
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("очікував 400, отримав %d", w.Code)
 	}
 }
 
 func TestLoginHandler_EmptyEmail(t *testing.T) {
-	body, _ := json.Marshal(map[string]string{"email": ""})
-	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+	h, _, _ := setupAuthTest(t)
+
+	w := doLoginRequest(h, `{"email":""}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("очікував 400, отримав %d", w.Code)
+	}
+}
+
+func TestLoginHandler_SendsCode(t *testing.T) {
+	h, repos, fm := setupAuthTest(t)
+
+	// email нормалізується до нижнього регістру
+	w := doLoginRequest(h, `{"email":" Test@Example.com "}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("очікував 202, отримав %d", w.Code)
+	}
+	if len(fm.sent) != 1 || fm.sent[0].To != "test@example.com" {
+		t.Fatalf("очікував один лист на test@example.com, отримав %+v", fm.sent)
+	}
+	code := codeFrom(t, fm.sent[0])
+	if bytes.Contains(w.Body.Bytes(), []byte("token")) {
+		t.Error("login не повинен повертати токен")
+	}
+
+	// у сховищі лише хеш коду
+	lc, err := repos.LoginCodes.LatestActive(context.Background(), "test@example.com", time.Now())
+	if err != nil {
+		t.Fatalf("код не збережено: %v", err)
+	}
+	if lc.CodeHash != hashSecret(code) {
+		t.Errorf("очікував хеш коду, отримав %q", lc.CodeHash)
+	}
+}
+
+func TestLoginHandler_RateLimited(t *testing.T) {
+	h, _, fm := setupAuthTest(t)
+
+	for i := 0; i < maxLoginCodesPerHour; i++ {
+		if w := doLoginRequest(h, `{"email":"test@example.com"}`); w.Code != http.StatusAccepted {
+			t.Fatalf("запит %d: очікував 202, отримав %d", i, w.Code)
+		}
+	}
+
+	w := doLoginRequest(h, `{"email":"test@example.com"}`)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("очікував 429, отримав %d", w.Code)
+	}
+	if len(fm.sent) != maxLoginCodesPerHour {
+		t.Errorf("зайвий лист не мав відправлятися, отримав %d", len(fm.sent))
+	}
+}
+
+func TestLoginHandler_ConcurrentRateLimit(t *testing.T) {
+	h, _, fm := setupAuthTest(t)
+
+	// паралельні запити не мають проскочити ліміт між перевіркою і записом коду
+	const requests = 20
+	codes := make(chan int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- doLoginRequest(h, `{"email":"test@example.com"}`).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for c := range codes {
+		counts[c]++
+	}
+	if counts[http.StatusAccepted] != maxLoginCodesPerHour || counts[http.StatusTooManyRequests] != requests-maxLoginCodesPerHour {
+		t.Errorf("очікував %d×202 і %d×429, отримав %v", maxLoginCodesPerHour, requests-maxLoginCodesPerHour, counts)
+	}
+	if len(fm.sent) != maxLoginCodesPerHour {
+		t.Errorf("очікував %d листів, отримав %d", maxLoginCodesPerHour, len(fm.sent))
+	}
+}
+
+func TestLoginHandler_DBError(t *testing.T) {
+	h, _, _ := setupAuthTest(t)
+	h.LoginCodes = brokenCodes{h.LoginCodes}
+
+	w := doLoginRequest(h, `{"email":"test@example.com"}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("очікував 500, отримав %d", w.Code)
+	}
+}
+
+func TestLoginHandler_MailerError(t *testing.T) {
+	h, _, fm := setupAuthTest(t)
+	fm.err = errors.New("smtp down")
+
+	w := doLoginRequest(h, `{"email":"test@example.com"}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("очікував 500, отримав %d", w.Code)
+	}
+}
+
+func doVerify(h *AuthHandler, email, code string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"email": email, "code": code})
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewBuffer(body))
 	w := httptest.NewRecorder()
+	h.Verify(w, req)
+	return w
+}
 
-	LoginHandler(w, req)
+func TestVerifyHandler_MissingFields(t *testing.T) {
+	h, _, _ := setupAuthTest(t)
 
+	w := doVerify(h, "test@example.com", "")
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("очікував 400, отримав %d", w.Code)
 	}
 }
 
-func TestLoginHandler_ExistingUser(t *testing.T) {
-	mock, teardown := setupAuthTest(t)
-	defer teardown()
+func TestVerifyHandler_NoActiveCode(t *testing.T) {
+	h, _, _ := setupAuthTest(t)
 
-	// налаштуємо очікування SELECT id
-	rows := sqlmock.NewRows([]string{"id"}).AddRow("user-123")
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users WHERE email=$1")).
-		WithArgs("test@example.com").
-		WillReturnRows(rows)
+	w := doVerify(h, "test@example.com", "123456")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+}
 
-	// виконуємо запит
-	body, _ := json.Marshal(map[string]string{"email": "test@example.com"})
-	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
-	w := httptest.NewRecorder()
+func TestVerifyHandler_WrongCode(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedCode(t, repos, "test@example.com", "123456", 0)
 
-	LoginHandler(w, req)
-	if w.Code != http.StatusOK {
-		t.Fatalf("очікував 200, отримав %d", w.Code)
+	w := doVerify(h, "test@example.com", "654321")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+	lc, err := repos.LoginCodes.LatestActive(context.Background(), "test@example.com", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc.Attempts != 1 {
+		t.Errorf("очікував 1 невдалу спробу, отримав %d", lc.Attempts)
 	}
+}
+
+func TestVerifyHandler_ConcurrentGuesses(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedCode(t, repos, "test@example.com", "123456", 0)
 
-	var resp struct {
-		Token string `json:"token"`
+	// паралельні вгадування читають той самий лічильник, але зарахувати можна лише maxLoginCodeAttempts
+	const guesses = 20
+	codes := make(chan int, guesses)
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes <- doVerify(h, "test@example.com", fmt.Sprintf("%06d", i)).Code
+		}(i)
 	}
-	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
-		t.Fatalf("не вдалося розпарсити JSON: %v", err)
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for c := range codes {
+		counts[c]++
+	}
+	if counts[http.StatusUnauthorized] != maxLoginCodeAttempts || counts[http.StatusTooManyRequests] != guesses-maxLoginCodeAttempts {
+		t.Errorf("очікував %d×401 і %d×429, отримав %v", maxLoginCodeAttempts, guesses-maxLoginCodeAttempts, counts)
+	}
+	lc, err := repos.LoginCodes.LatestActive(context.Background(), "test@example.com", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc.Attempts != maxLoginCodeAttempts {
+		t.Errorf("очікував %d зарахованих спроб, отримав %d", maxLoginCodeAttempts, lc.Attempts)
+	}
+}
+
+func TestVerifyHandler_TooManyAttempts(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	// навіть правильний код не приймається після вичерпання спроб
+	seedCode(t, repos, "test@example.com", "123456", maxLoginCodeAttempts)
+
+	w := doVerify(h, "test@example.com", "123456")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("очікував 429, отримав %d", w.Code)
+	}
+}
+
+func TestVerifyHandler_EmailAttemptsAcrossCodes(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	// попередні коди вичерпали ліміт спроб email; новий код не дає нових спроб
+	for i, attempts := range []int{maxLoginCodeAttempts, maxLoginEmailAttempts - maxLoginCodeAttempts} {
+		err := repos.LoginCodes.Create(context.Background(), &models.LoginCode{
+			ID: fmt.Sprintf("old-%d", i), Email: "test@example.com", CodeHash: hashSecret("000000"),
+			Attempts: attempts, ExpiresAt: time.Now().Add(loginCodeTTL), CreatedAt: time.Now().Add(-time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
 	}
-	if resp.Token == "" {
-		t.Error("очікував непустий токен")
+	seedCode(t, repos, "test@example.com", "123456", 0)
+
+	if w := doVerify(h, "test@example.com", "123456"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("очікував 429, отримав %d", w.Code)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("не виконані очікування sqlmock: %v", err)
+	// спроби старші за вікно ліміту вже не рахуються
+	h2, repos2, _ := setupAuthTest(t)
+	err := repos2.LoginCodes.Create(context.Background(), &models.LoginCode{
+		ID: "old", Email: "test@example.com", CodeHash: hashSecret("000000"),
+		Attempts: maxLoginEmailAttempts, ExpiresAt: time.Now(), CreatedAt: time.Now().Add(-2 * loginCodeWindow),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedCode(t, repos2, "test@example.com", "123456", 0)
+	if w := doVerify(h2, "test@example.com", "123456"); w.Code != http.StatusOK {
+		t.Errorf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
 	}
 }
 
-func TestLoginHandler_NewUser(t *testing.T) {
-	mock, teardown := setupAuthTest(t)
-	defer teardown()
+func TestVerifyHandler_AlreadyUsed(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedCode(t, repos, "test@example.com", "123456", 0)
+	h.LoginCodes = racingCodes{h.LoginCodes}
+
+	w := doVerify(h, "test@example.com", "123456")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+}
 
-	// SELECT повертає ErrNoRows
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users WHERE email=$1")).
-		WithArgs("new@example.com").
-		WillReturnError(sql.ErrNoRows)
+func TestVerifyHandler_CodeIsSingleUse(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedCode(t, repos, "test@example.com", "123456", 0)
 
-	// очікуємо INSERT
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users")).
-		WithArgs(sqlmock.AnyArg(), "new@example.com").
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	if w := doVerify(h, "test@example.com", "123456"); w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	if w := doVerify(h, "test@example.com", "123456"); w.Code != http.StatusUnauthorized {
+		t.Errorf("повторне використання: очікував 401, отримав %d", w.Code)
+	}
+}
 
-	body, _ := json.Marshal(map[string]string{"email": "new@example.com"})
-	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
-	w := httptest.NewRecorder()
+func TestVerifyHandler_ExistingUser(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	ctx := context.Background()
+	if err := repos.Users.Create(ctx, &models.User{ID: "user-123", Email: "test@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	seedCode(t, repos, "test@example.com", "123456", 0)
 
-	LoginHandler(w, req)
+	w := doVerify(h, "test@example.com", "123456")
 	if w.Code != http.StatusOK {
 		t.Fatalf("очікував 200, отримав %d", w.Code)
 	}
 
-	var resp struct {
-		Token string `json:"token"`
-	}
+	var resp tokenPair
 	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("не вдалося розпарсити JSON: %v", err)
 	}
-	if resp.Token == "" {
-		t.Error("очікував непустий токен")
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("очікував непусті access- і refresh-токени: %+v", resp)
+	}
+	if got := userFromToken(t, h, resp.Token); got != "user-123" {
+		t.Errorf("очікував токен для user-123, отримав %q", got)
+	}
+	s, err := repos.Sessions.GetByTokenHash(ctx, hashSecret(resp.RefreshToken))
+	if err != nil || s.UserID != "user-123" {
+		t.Errorf("refresh-токен не збережено для user-123: %+v, %v", s, err)
+	}
+}
+
+func TestVerifyHandler_NewUser(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedCode(t, repos, "new@example.com", "123456", 0)
+
+	w := doVerify(h, "new@example.com", "123456")
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("не виконані очікування sqlmock: %v", err)
+	var resp tokenPair
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("не вдалося розпарсити JSON: %v", err)
+	}
+	u, err := repos.Users.GetByEmail(context.Background(), "new@example.com")
+	if err != nil {
+		t.Fatalf("користувача не створено: %v", err)
+	}
+	if got := userFromToken(t, h, resp.Token); got != u.ID {
+		t.Errorf("очікував токен для %q, отримав %q", u.ID, got)
 	}
 }
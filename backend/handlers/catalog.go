@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var colorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// CatalogHandler обслуговує /moods/catalog: вбудований каталог і власні іконки користувача
+type CatalogHandler struct {
+	Icons repository.IconRepository
+	Auth  func(http.Handler) http.Handler
+}
+
+func NewCatalogHandler(icons repository.IconRepository, authMW func(http.Handler) http.Handler) *CatalogHandler {
+	return &CatalogHandler{Icons: icons, Auth: authMW}
+}
+
+func (h *CatalogHandler) Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.Auth)
+		r.Get("/", h.List)
+		r.Post("/", h.CreateCustom)
+		r.Delete("/{icon}", h.DeleteCustom)
+	})
+}
+
+type catalogResp struct {
+	MinScore int               `json:"min_score"`
+	MaxScore int               `json:"max_score"`
+	Icons    []models.MoodIcon `json:"icons"`
+}
+
+// List повертає вбудовані іконки з назвами мовою запиту, а за ними – власні іконки користувача
+func (h *CatalogHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	custom, err := h.Icons.List(r.Context(), userID)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	icons := append(models.LocalCatalog(locale(r)), custom...)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(catalogResp{MinScore: models.MinScore, MaxScore: models.MaxScore, Icons: icons})
+}
+
+func (h *CatalogHandler) CreateCustom(w http.ResponseWriter, r *http.Request) {
+	var in models.MoodIcon
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	in.Icon, in.Label = strings.TrimSpace(in.Icon), strings.TrimSpace(in.Label)
+	switch {
+	case in.Icon == "" || utf8.RuneCountInString(in.Icon) > 50:
+		fail(w, r, http.StatusBadRequest, "icon_length", 50)
+		return
+	case in.Label == "" || utf8.RuneCountInString(in.Label) > 50:
+		fail(w, r, http.StatusBadRequest, "label_length", 50)
+		return
+	case in.Score < models.MinScore || in.Score > models.MaxScore:
+		fail(w, r, http.StatusBadRequest, "score_range", models.MinScore, models.MaxScore)
+		return
+	case in.Color != "" && !colorRe.MatchString(in.Color):
+		fail(w, r, http.StatusBadRequest, "invalid_color")
+		return
+	}
+	if _, ok := models.CatalogIcon(in.Icon); ok {
+		fail(w, r, http.StatusConflict, "icon_in_catalog")
+		return
+	}
+	// Без кольору беремо колір вбудованої іконки з тим самим балом
+	if in.Color == "" {
+		for _, mi := range models.Catalog {
+			if mi.Score == in.Score {
+				in.Color = mi.Color
+			}
+		}
+	}
+	in.Custom = true
+
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	if err := h.Icons.Create(r.Context(), userID, &in); errors.Is(err, repository.ErrConflict) {
+		fail(w, r, http.StatusConflict, "icon_exists")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(in)
+}
+
+// DeleteCustom видаляє власну іконку; вже внесені з нею записи зберігають свій бал
+func (h *CatalogHandler) DeleteCustom(w http.ResponseWriter, r *http.Request) {
+	icon, err := url.PathUnescape(chi.URLParam(r, "icon"))
+	if err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_icon")
+		return
+	}
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	err = h.Icons.Delete(r.Context(), userID, icon)
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusNotFound, "not_found")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
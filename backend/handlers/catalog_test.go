@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"moodtracker/models"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupCatalogTest(t *testing.T) *CatalogHandler {
+	return NewCatalogHandler(memory.New().Icons, nil)
+}
+
+func postIcon(h *CatalogHandler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.CreateCustom(w, newRequest(http.MethodPost, "/moods/catalog", []byte(body), ""))
+	return w
+}
+
+func TestCatalog_ListBuiltIn(t *testing.T) {
+	h := setupCatalogTest(t)
+
+	w := httptest.NewRecorder()
+	h.List(w, newRequest(http.MethodGet, "/moods/catalog", nil, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	var resp catalogResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.MinScore != 0 || resp.MaxScore != 5 || len(resp.Icons) != 6 {
+		t.Fatalf("неправильний каталог: %+v", resp)
+	}
+	for _, mi := range resp.Icons {
+		if mi.Label == "" || mi.Color == "" || mi.Custom {
+			t.Errorf("неповний запис каталогу: %+v", mi)
+		}
+	}
+	if resp.Icons[0].Label != "Чудово" {
+		t.Errorf("без Accept-Language назви українською, отримав %q", resp.Icons[0].Label)
+	}
+
+	w = httptest.NewRecorder()
+	req := newRequest(http.MethodGet, "/moods/catalog", nil, "")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	h.List(w, req)
+	resp = catalogResp{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Icons) != 6 || resp.Icons[0].Label != "Great" || resp.Icons[5].Label != "Angry" {
+		t.Errorf("очікував англійські назви: %+v", resp.Icons)
+	}
+}
+
+func TestCatalog_CustomIcon(t *testing.T) {
+	h := setupCatalogTest(t)
+
+	w := postIcon(h, `{"icon":"🦄","label":"Казково","score":5}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("очікував 201, отримав %d: %s", w.Code, w.Body.String())
+	}
+	var created models.MoodIcon
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Color != "#2e7d32" || !created.Custom {
+		t.Errorf("очікував колір вбудованої іконки з тим самим балом: %+v", created)
+	}
+	if w := postIcon(h, `{"icon":"🦄","label":"Ще раз","score":4}`); w.Code != http.StatusConflict {
+		t.Errorf("повторна іконка: очікував 409, отримав %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.List(w, newRequest(http.MethodGet, "/moods/catalog", nil, ""))
+	var resp catalogResp
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Icons) != 7 || resp.Icons[6].Icon != "🦄" || !resp.Icons[6].Custom {
+		t.Errorf("власна іконка має йти після вбудованих: %+v", resp.Icons)
+	}
+
+	// іконка в адресі приходить закодованою
+	req := newRequest(http.MethodDelete, "/moods/catalog/x", nil, "")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("icon", url.PathEscape("🦄"))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w = httptest.NewRecorder()
+	h.DeleteCustom(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("очікував 204, отримав %d", w.Code)
+	}
+	if _, err := h.Icons.Get(context.Background(), "user-1", "🦄"); err != repository.ErrNotFound {
+		t.Errorf("іконку не видалено: %v", err)
+	}
+}
+
+func TestCatalog_CustomIconInvalid(t *testing.T) {
+	h := setupCatalogTest(t)
+
+	cases := map[string]int{
+		`not-json`:                                            http.StatusBadRequest,
+		`{"icon":"","label":"x","score":1}`:                   http.StatusBadRequest,
+		`{"icon":"🦄","label":"","score":1}`:                   http.StatusBadRequest,
+		`{"icon":"🦄","label":"x","score":6}`:                  http.StatusBadRequest,
+		`{"icon":"🦄","label":"x","score":1,"color":"purple"}`: http.StatusBadRequest,
+		`{"icon":"😃","label":"Моя радість","score":5}`:        http.StatusConflict,
+	}
+	for body, want := range cases {
+		if w := postIcon(h, body); w.Code != want {
+			t.Errorf("%s: очікував %d, отримав %d", body, want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"moodtracker/validate"
+)
+
+// maxMoodBody – ліміт тіла запитів із записом настрою (коментар до 1000 символів з запасом)
+const maxMoodBody = 16 << 10
+
+// decodeJSON суворо розбирає тіло запиту розміром до limit байтів у dst (див. validate.DecodeJSON).
+// При помилці відповідає 400 з полями або 413 і повертає false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any, limit int64) bool {
+	err := validate.DecodeJSON(http.MaxBytesReader(w, r.Body, limit), dst)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &tooLarge):
+		fail(w, r, http.StatusRequestEntityTooLarge, "request_too_large")
+	default:
+		failErr(w, r, http.StatusBadRequest, err)
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"moodtracker/i18n"
+	"moodtracker/problem"
+)
+
+// locale – мова відповіді за заголовком Accept-Language
+func locale(r *http.Request) i18n.Locale {
+	return problem.Locale(r)
+}
+
+// fail відповідає статусом status і помилкою з кодом key (див. problem.Write)
+func fail(w http.ResponseWriter, r *http.Request, status int, key string, args ...any) {
+	problem.Write(w, r, status, key, args...)
+}
+
+// failErr відповідає статусом status з кодом і текстом помилки каталогу err
+func failErr(w http.ResponseWriter, r *http.Request, status int, err error) {
+	problem.WriteError(w, r, status, err)
+}
+
+// failInternal відповідає на помилку сховища без подробиць для клієнта (див. problem.Internal)
+func failInternal(w http.ResponseWriter, r *http.Request, err error) {
+	problem.Internal(w, r, err)
+}
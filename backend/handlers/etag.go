@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"moodtracker/models"
+)
+
+// moodETag – сильний ETag запису настрою: номер його версії
+func moodETag(m *models.Mood) string {
+	return `"` + strconv.Itoa(m.Version) + `"`
+}
+
+// bodyETag – ETag відповіді, яка складається з кількох записів (список, статистика): хеш тіла
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatch шукає etag у значенні If-Match або If-None-Match ("*" підходить до будь-якого).
+// If-Match порівнює сильно (weak=false): слабкі W/"..." не збігаються ні з чим;
+// If-None-Match – слабко, без урахування префікса W/.
+func etagMatch(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if tag == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCached відповідає v у JSON з ETag etag ("" – хеш тіла). Якщо клієнт уже має
+// цю версію (If-None-Match), відповідь – 304 без тіла. Браузер перепитує сервер
+// щоразу (no-cache), тож опитування графіків не тягне ті самі дані повторно.
+func writeCached(w http.ResponseWriter, r *http.Request, etag string, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	if etag == "" {
+		etag = bodyETag(body)
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatch(inm, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
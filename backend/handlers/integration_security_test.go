@@ -3,160 +3,175 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"strings"
 	"testing"
 
-	"moodtracker/db"
+	"moodtracker/middleware"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-chi/chi/v5"
-	"github.com/jmoiron/sqlx"
 )
 
+type integration struct {
+	handler http.Handler
+	repos   repository.Set
+	mail    *fakeMailer
+}
+
 // helper для підготовки інтеграційного сервера
-func setupIntegration(t *testing.T) (handler http.Handler, mock sqlmock.Sqlmock, teardown func()) {
-	// мок DB
-	sqlDB, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
-	if err != nil {
-		t.Fatalf("не вдалося створити sqlmock: %v", err)
-	}
-	db.DB.DB = sqlx.NewDb(sqlDB, "postgres")
-	// секрет для JWT
-	os.Setenv("JWT_SECRET", "testsecret")
+func setupIntegration(t *testing.T) *integration {
+	repos := memory.New()
+	keys := testKeys(t)
+	fm := &fakeMailer{}
+	authMW := middleware.JWTAuth(keys, repos.Sessions)
 
 	// збираємо роутер як у main.go
 	r := chi.NewRouter()
-	r.Route("/auth", RegisterAuthRoutes)
-	r.Route("/mood", RegisterMoodRoutes)
-	r.Route("/user/telegram", RegisterTelegramRoutes)
+	r.Route("/auth", NewAuthHandler(repos, keys, fm).Routes)
+	r.Route("/mood", NewMoodHandler(repos, authMW).Routes)
+	r.Route("/user/telegram", NewTelegramHandler(repos.Users, repos.TelegramLinks, "mood_bot", authMW).Routes)
 
-	return r, m, func() { sqlDB.Close() }
+	return &integration{handler: r, repos: repos, mail: fm}
 }
 
-// робимо POST /auth/login, повертаємо токен
-func doLogin(t *testing.T, handler http.Handler, mock sqlmock.Sqlmock, email string) string {
-	// підготувати очікування DB: спочатку SELECT
-	rows := sqlmock.NewRows([]string{"id"}).AddRow("user-1")
-	mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).
-		WithArgs(email).
-		WillReturnRows(rows)
-	// не вставляємо нового користувача
-	// підготуємо запит
-	payload := map[string]string{"email": email}
-	data, _ := json.Marshal(payload)
-	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(data))
+func (it *integration) do(method, url, token string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, bytes.NewBuffer(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 	rec := httptest.NewRecorder()
+	it.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// проходимо вхід через /auth/login + /auth/verify, повертаємо токен
+func (it *integration) login(t *testing.T, email string) string {
+	// 1) запит коду
+	data, _ := json.Marshal(map[string]string{"email": email})
+	rec := it.do(http.MethodPost, "/auth/login", "", data)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Login: очікував 202, отримав %d", rec.Code)
+	}
+	code := codeFrom(t, it.mail.sent[len(it.mail.sent)-1])
 
-	handler.ServeHTTP(rec, req)
+	// 2) обмін коду на токен
+	data, _ = json.Marshal(map[string]string{"email": email, "code": code})
+	rec = it.do(http.MethodPost, "/auth/verify", "", data)
 	if rec.Code != http.StatusOK {
-		t.Fatalf("Login: очікував 200, отримав %d", rec.Code)
+		t.Fatalf("Verify: очікував 200, отримав %d", rec.Code)
 	}
 	var resp struct {
 		Token string `json:"token"`
 	}
 	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
-		t.Fatalf("Login: не вдалося розпарсити JSON: %v", err)
+		t.Fatalf("Verify: не вдалося розпарсити JSON: %v", err)
 	}
 	return resp.Token
 }
 
 func Test_Security_Mood_NoToken(t *testing.T) {
-	handler, _, teardown := setupIntegration(t)
-	defer teardown()
-
-	req := httptest.NewRequest(http.MethodPost, "/mood", nil)
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	it := setupIntegration(t)
 
+	rec := it.do(http.MethodPost, "/mood", "", nil)
 	if rec.Code != http.StatusUnauthorized {
 		t.Errorf("NoToken: очікував 401, отримав %d", rec.Code)
 	}
 }
 
 func Test_Security_Mood_InvalidToken(t *testing.T) {
-	handler, _, teardown := setupIntegration(t)
-	defer teardown()
-
-	req := httptest.NewRequest(http.MethodGet, "/mood", nil)
-	req.Header.Set("Authorization", "Bearer invalid.token.here")
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	it := setupIntegration(t)
 
+	rec := it.do(http.MethodGet, "/mood", "invalid.token.here", nil)
 	if rec.Code != http.StatusUnauthorized {
 		t.Errorf("InvalidToken: очікував 401, отримав %d", rec.Code)
 	}
 }
 
 func Test_Security_Mood_WithValidToken(t *testing.T) {
-	handler, mock, teardown := setupIntegration(t)
-	defer teardown()
-
-	// 1) логін → отримати токен
-	token := doLogin(t, handler, mock, "test@example.com")
-
-	// 2) підготувати мок на вставку mood
-	mock.ExpectExec(`INSERT INTO mood`).
-		WithArgs(sqlmock.AnyArg(), "user-1", sqlmock.AnyArg(), "🙂", "ok", sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	// відправляємо запит
-	payload := map[string]string{"icon": "🙂", "comment": "ok"}
-	body, _ := json.Marshal(payload)
-	req := httptest.NewRequest(http.MethodPost, "/mood", bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer "+token)
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	it := setupIntegration(t)
+	token := it.login(t, "test@example.com")
 
+	body, _ := json.Marshal(map[string]string{"icon": "😊", "comment": "ok"})
+	rec := it.do(http.MethodPost, "/mood", token, body)
 	if rec.Code != http.StatusCreated {
-		t.Errorf("WithValidToken Mood: очікував 201, отримав %d", rec.Code)
+		t.Fatalf("WithValidToken Mood: очікував 201, отримав %d", rec.Code)
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("Mood mock expectations: %v", err)
+	// запис належить саме користувачу з токена
+	rec = it.do(http.MethodGet, "/mood", token, nil)
+	var resp page[map[string]interface{}]
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if list := resp.Data; len(list) != 1 || list[0]["icon"] != "😊" {
+		t.Errorf("WithValidToken Mood: очікував створений запис, отримав %s", rec.Body.String())
 	}
 }
 
-func Test_Security_Telegram_NoToken(t *testing.T) {
-	handler, _, teardown := setupIntegration(t)
-	defer teardown()
+func Test_Security_Mood_OtherUsersRecord(t *testing.T) {
+	it := setupIntegration(t)
+	alice := it.login(t, "alice@example.com")
+	bob := it.login(t, "bob@example.com")
 
-	req := httptest.NewRequest(http.MethodPost, "/user/telegram/register", nil)
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	body, _ := json.Marshal(map[string]string{"icon": "😊", "comment": "private"})
+	rec := it.do(http.MethodPost, "/mood", alice, body)
+	var created struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
 
+	if rec := it.do(http.MethodGet, "/mood/"+created.ID, bob, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("чужий запис: очікував 404, отримав %d", rec.Code)
+	}
+	if rec := it.do(http.MethodDelete, "/mood/"+created.ID, bob, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("видалення чужого запису: очікував 404, отримав %d", rec.Code)
+	}
+}
+
+func Test_Security_Mood_RevokedSession(t *testing.T) {
+	it := setupIntegration(t)
+	token := it.login(t, "test@example.com")
+
+	if rec := it.do(http.MethodPost, "/auth/logout", token, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("Logout: очікував 204, отримав %d", rec.Code)
+	}
+
+	// після logout сесія вже не активна, хоча токен ще не прострочений
+	rec := it.do(http.MethodGet, "/mood", token, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("RevokedSession: очікував 401, отримав %d", rec.Code)
+	}
+}
+
+func Test_Security_Telegram_NoToken(t *testing.T) {
+	it := setupIntegration(t)
+
+	rec := it.do(http.MethodPost, "/user/telegram/link", "", nil)
 	if rec.Code != http.StatusUnauthorized {
 		t.Errorf("Telegram NoToken: очікував 401, отримав %d", rec.Code)
 	}
 }
 
 func Test_Security_Telegram_WithValidToken(t *testing.T) {
-	handler, mock, teardown := setupIntegration(t)
-	defer teardown()
-
-	// логін для токена
-	token := doLogin(t, handler, mock, "test2@example.com")
-
-	// мок на оновлення telegram_chat_id
-	mock.ExpectExec(`UPDATE users SET telegram_chat_id=\$1 WHERE id=\$2`).
-		WithArgs(int64(7777), "user-1").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	// запит
-	payload := map[string]int64{"chat_id": 7777}
-	body, _ := json.Marshal(payload)
-	req := httptest.NewRequest(http.MethodPost, "/user/telegram/register", bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer "+token)
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	it := setupIntegration(t)
+	token := it.login(t, "test2@example.com")
 
-	if rec.Code != http.StatusNoContent {
-		t.Errorf("WithValidToken Telegram: очікував 204, отримав %d", rec.Code)
+	rec := it.do(http.MethodPost, "/user/telegram/link", token, nil)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), "https://t.me/mood_bot?start=") {
+		t.Fatalf("WithValidToken Telegram: очікував 201 з посиланням, отримав %d %s", rec.Code, rec.Body.String())
 	}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("Telegram mock expectations: %v", err)
+	// chat_id від клієнта більше не приймається: чат прив'язує лише бот
+	body, _ := json.Marshal(map[string]int64{"chat_id": 7777})
+	it.do(http.MethodPost, "/user/telegram/register", token, body)
+	users, err := it.repos.Users.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].TelegramChatID != nil {
+		t.Errorf("WithValidToken Telegram: чат прив'язано без бота: %+v", users)
 	}
 }
@@ -1,171 +1,360 @@
 package handlers
 
 import (
-	"database/sql"
+	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
-	"moodtracker/db"
+	"moodtracker/entries"
+	"moodtracker/i18n"
 	"moodtracker/middleware"
 	"moodtracker/models"
+	"moodtracker/repository"
+	"moodtracker/stats"
+	"moodtracker/streaks"
+	"moodtracker/validate"
+)
 
-	"encoding/json"
+// maxStatsDays обмежує період /mood/stats, щоб звіт зі списком пропущених днів лишався невеликим
+const maxStatsDays = 366
 
-	"github.com/google/uuid"
-)
+// MoodHandler обслуговує /mood
+type MoodHandler struct {
+	Moods   repository.MoodRepository
+	Users   repository.UserRepository   // режим внесення (один чи кілька записів на день)
+	Icons   repository.IconRepository   // власні іконки користувача поза вбудованим каталогом
+	Freezes repository.FreezeRepository // заморожені дні для серій
+	Streaks *streaks.Service
+	Auth    func(http.Handler) http.Handler
+	// BackfillDays – за скільки днів назад можна внести настрій (див. entries.Service)
+	BackfillDays int
+}
 
-func RegisterMoodRoutes(r chi.Router) {
+func NewMoodHandler(repos repository.Set, authMW func(http.Handler) http.Handler) *MoodHandler {
+	return &MoodHandler{
+		Moods:   repos.Moods,
+		Users:   repos.Users,
+		Icons:   repos.Icons,
+		Freezes: repos.Freezes,
+		Streaks: streaks.NewService(repos.Moods, repos.Freezes),
+		Auth:    authMW,
+	}
+}
+
+func (h *MoodHandler) Routes(r chi.Router) {
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.JWTAuth)
-		r.Post("/", CreateMood)
-		r.Get("/", ListMood)
-		r.Get("/{id}", getMoodByID)
-		r.Put("/{id}", UpdateMood)
-		r.Delete("/{id}", DeleteMood)
+		r.Use(h.Auth)
+		r.Post("/", h.Create)
+		r.Get("/", h.List)
+		r.Get("/stats", h.Stats)
+		r.Get("/streaks", h.GetStreaks)
+		r.Post("/streaks/freezes", h.AddFreeze)
+		r.Delete("/streaks/freezes/{date}", h.RemoveFreeze)
+		r.Get("/{id}", h.Get)
+		r.Put("/{id}", h.Update)
+		r.Patch("/{id}", h.Patch)
+		r.Delete("/{id}", h.Delete)
 	})
 }
 
-func CreateMood(w http.ResponseWriter, r *http.Request) {
-	var in struct {
-		Icon    string `json:"icon"`
-		Comment string `json:"comment"`
-		Date    string `json:"date"` // формат "YYYY-MM-DD"
-	}
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+func (h *MoodHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var in entries.Input
+	if !decodeJSON(w, r, &in, maxMoodBody) {
 		return
 	}
-	if in.Icon == "" {
-		http.Error(w, "icon is required", http.StatusBadRequest)
+
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	m, err := h.entries().Create(r.Context(), userID, in)
+	var invalid validate.Errors
+	switch {
+	case errors.As(err, &invalid):
+		failErr(w, r, http.StatusBadRequest, err)
 		return
-	}
-	if in.Comment == "" {
-		http.Error(w, "comment is required", http.StatusBadRequest)
+	case errors.Is(err, entries.ErrExists):
+		failErr(w, r, http.StatusConflict, err)
+		return
+	case err != nil:
+		failInternal(w, r, err)
 		return
 	}
 
-	// Розбір дати
-	var dt time.Time
-	if in.Date != "" {
-		var err error
-		dt, err = time.Parse("2006-01-02", in.Date)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", moodETag(m))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(m)
+}
+
+// entries – сервіс внесення поверх поточних репозиторіїв обробника
+func (h *MoodHandler) entries() *entries.Service {
+	return &entries.Service{Moods: h.Moods, Users: h.Users, Icons: h.Icons, BackfillDays: h.BackfillDays}
+}
+
+// List повертає сторінку записів у порядку (date, id). Параметри (усі необов'язкові):
+//
+//	from, to  – межі дат включно, кожна окремо
+//	sort      – asc (за замовчуванням) або desc
+//	icon      – лише записи з цією іконкою
+//	score     – лише записи з цим місцем на шкалі
+//	q         – слова з коментаря (пошук за початком слова)
+//	limit     – розмір сторінки, 1..200 (за замовчуванням 50)
+//	cursor    – next_cursor попередньої сторінки
+func (h *MoodHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	params := r.URL.Query()
+
+	var q repository.MoodQuery
+	if v := params.Get("from"); v != "" {
+		from, err := time.Parse("2006-01-02", v)
 		if err != nil {
-			http.Error(w, "invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			fail(w, r, http.StatusBadRequest, "invalid_from_date")
 			return
 		}
-	} else {
-		dt = time.Now()
+		q.From = &from
 	}
-
-	userID := r.Context().Value(middleware.UserIDKey).(string)
-	now := time.Now()
-
-	m := models.Mood{
-		ID:        uuid.NewString(),
-		UserID:    userID,
-		Date:      dt,
-		Icon:      in.Icon,
-		Comment:   in.Comment,
-		CreatedAt: now,
-		UpdatedAt: now,
+	if v := params.Get("to"); v != "" {
+		to, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			fail(w, r, http.StatusBadRequest, "invalid_to_date")
+			return
+		}
+		q.To = &to
 	}
-
-	query := `
-        INSERT INTO mood (id, user_id, date, icon, comment, created_at, updated_at)
-        VALUES (:id, :user_id, :date, :icon, :comment, :created_at, :updated_at)`
-	if _, err := db.DB.NamedExec(query, &m); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if q.From != nil && q.To != nil && q.From.After(*q.To) {
+		fail(w, r, http.StatusBadRequest, "from_after_to")
+		return
+	}
+	switch params.Get("sort") {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		fail(w, r, http.StatusBadRequest, "invalid_sort")
+		return
+	}
+	q.Icon = params.Get("icon")
+	if v := params.Get("score"); v != "" {
+		score, err := strconv.Atoi(v)
+		if err != nil || score < models.MinScore || score > models.MaxScore {
+			fail(w, r, http.StatusBadRequest, "invalid_score", models.MinScore, models.MaxScore)
+			return
+		}
+		q.Score = &score
+	}
+	q.Search = params.Get("q")
+	limit, ok := pageSize(params.Get("limit"))
+	if !ok {
+		fail(w, r, http.StatusBadRequest, "invalid_limit", maxPageSize)
 		return
 	}
+	if v := params.Get("cursor"); v != "" {
+		after, err := decodeCursor(v)
+		if err != nil {
+			fail(w, r, http.StatusBadRequest, "invalid_cursor")
+			return
+		}
+		q.After = after
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(m)
+	// запитуємо на один запис більше, щоб знати, чи є наступна сторінка
+	q.Limit = limit + 1
+	moods, err := h.Moods.Page(r.Context(), userID, q)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	resp := page[models.Mood]{Data: moods}
+	if len(moods) > limit {
+		last := moods[limit-1]
+		resp.Data = moods[:limit]
+		resp.NextCursor = encodeCursor(repository.MoodKey{Date: last.Date, ID: last.ID})
+		setNextLink(w, r, resp.NextCursor)
+	}
+	writeCached(w, r, "", resp)
 }
 
-func ListMood(w http.ResponseWriter, r *http.Request) {
+// Stats повертає статистику за період from..to (за замовчуванням – останні 30 днів
+// до сьогодні в поясі користувача)
+// з групуванням group_by=day|week|month|weekday
+func (h *MoodHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(middleware.UserIDKey).(string)
-	from := r.URL.Query().Get("from")
-	to := r.URL.Query().Get("to")
-
-	baseQuery := `SELECT * FROM mood WHERE user_id=$1`
-	args := []interface{}{userID}
+	q := r.URL.Query()
 
-	if from != "" && to != "" {
-		baseQuery += " AND date BETWEEN $2 AND $3"
-		args = append(args, from, to)
+	groupBy, err := stats.ParseGroupBy(q.Get("group_by"))
+	if err != nil {
+		failErr(w, r, http.StatusBadRequest, err)
+		return
+	}
+	settings, err := h.Users.GetSettings(r.Context(), userID)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	to := settings.Today(time.Now())
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			fail(w, r, http.StatusBadRequest, "invalid_to_date")
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -29)
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			fail(w, r, http.StatusBadRequest, "invalid_from_date")
+			return
+		}
+	}
+	if from.After(to) {
+		fail(w, r, http.StatusBadRequest, "from_after_to")
+		return
+	}
+	if to.Sub(from) >= maxStatsDays*24*time.Hour {
+		failErr(w, r, http.StatusBadRequest, i18n.NewPluralError("period_too_long", maxStatsDays))
+		return
 	}
 
-	var moods []models.Mood
-	if err := db.DB.Select(&moods, baseQuery, args...); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	moods, err := h.Moods.List(r.Context(), userID, repository.MoodFilter{From: &from, To: &to})
+	if err != nil {
+		failInternal(w, r, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(moods)
+	writeCached(w, r, "", stats.Compute(moods, from, to, groupBy))
 }
 
-func getMoodByID(w http.ResponseWriter, r *http.Request) {
+func (h *MoodHandler) Get(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(middleware.UserIDKey).(string)
 	id := chi.URLParam(r, "id")
 
-	var m models.Mood
-	err := db.DB.Get(&m,
-		`SELECT * FROM mood WHERE id=$1 AND user_id=$2`, id, userID)
+	m, err := h.Moods.Get(r.Context(), userID, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "not found", http.StatusNotFound)
+		if errors.Is(err, repository.ErrNotFound) {
+			fail(w, r, http.StatusNotFound, "not_found")
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		failInternal(w, r, err)
 		return
 	}
+	writeCached(w, r, moodETag(m), m)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(m)
+// moodUpdate – тіло PUT /mood/{id}: icon і comment замінюються повністю, порожні не приймаються;
+// необов'язкова date переносить запис на інший день
+type moodUpdate struct {
+	Icon    string `json:"icon" validate:"required,max=50"`
+	Comment string `json:"comment" validate:"required,max=1000"`
+	Date    string `json:"date" validate:"date"`
 }
 
-func UpdateMood(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	var in struct {
-		Icon    string `json:"icon"`
-		Comment string `json:"comment"`
+// Update замінює запис і повертає його
+func (h *MoodHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var in moodUpdate
+	if !decodeJSON(w, r, &in, maxMoodBody) {
+		return
+	}
+	if err := validate.Struct(in); err != nil {
+		failErr(w, r, http.StatusBadRequest, err)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	p := entries.Patch{Icon: validate.Some(in.Icon), Comment: validate.Some(in.Comment)}
+	if in.Date != "" {
+		p.Date = validate.Some(in.Date)
+	}
+	h.update(w, r, p)
+}
+
+// mergePatchType – тип тіла PATCH за RFC 7386; звичайний application/json теж приймається
+const mergePatchType = "application/merge-patch+json"
+
+// Patch частково змінює запис за JSON Merge Patch (див. entries.Patch) і повертає його
+func (h *MoodHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != mergePatchType && ct != "application/json" {
+		fail(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type", mergePatchType)
+		return
+	}
+	var p entries.Patch
+	if !decodeJSON(w, r, &p, maxMoodBody) {
 		return
 	}
+	h.update(w, r, p)
+}
 
+// update застосовує p до запису {id} і відповідає оновленим записом
+func (h *MoodHandler) update(w http.ResponseWriter, r *http.Request, p entries.Patch) {
 	userID := r.Context().Value(middleware.UserIDKey).(string)
-	res, err := db.DB.Exec(
-		`UPDATE mood SET icon=$1, comment=$2, updated_at=$3 WHERE id=$4 AND user_id=$5`,
-		in.Icon, in.Comment, time.Now(), id, userID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	id := chi.URLParam(r, "id")
+	version, ok := h.ifMatch(w, r, userID, id)
+	if !ok {
 		return
 	}
-	if rows, _ := res.RowsAffected(); rows == 0 {
-		http.Error(w, "not found", http.StatusNotFound)
+	p.Version = version
+	m, err := h.entries().Update(r.Context(), userID, id, p)
+	var invalid validate.Errors
+	switch {
+	case errors.As(err, &invalid):
+		failErr(w, r, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		fail(w, r, http.StatusNotFound, "not_found")
+		return
+	case errors.Is(err, entries.ErrExists):
+		failErr(w, r, http.StatusConflict, err)
+		return
+	case errors.Is(err, repository.ErrStale):
+		fail(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	case err != nil:
+		failInternal(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", moodETag(m))
+	json.NewEncoder(w).Encode(m)
 }
 
-func DeleteMood(w http.ResponseWriter, r *http.Request) {
+func (h *MoodHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	userID := r.Context().Value(middleware.UserIDKey).(string)
-	res, err := db.DB.Exec(
-		`DELETE FROM mood WHERE id=$1 AND user_id=$2`,
-		id, userID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	version, ok := h.ifMatch(w, r, userID, id)
+	if !ok {
 		return
 	}
-	if rows, _ := res.RowsAffected(); rows == 0 {
-		http.Error(w, "not found", http.StatusNotFound)
+	err := h.Moods.Delete(r.Context(), userID, id, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusNotFound, "not_found")
+		return
+	} else if errors.Is(err, repository.ErrStale) {
+		fail(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ifMatch перевіряє If-Match перед зміною запису id і повертає версію, яку бачив клієнт
+// (0 – заголовка немає, зміна безумовна). Якщо запису немає (404) або клієнт бачив
+// іншу версію (412), відповідь уже надіслано і ok=false.
+func (h *MoodHandler) ifMatch(w http.ResponseWriter, r *http.Request, userID, id string) (version int, ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	m, err := h.Moods.Get(r.Context(), userID, id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		fail(w, r, http.StatusNotFound, "not_found")
+		return 0, false
+	case err != nil:
+		failInternal(w, r, err)
+		return 0, false
+	case !etagMatch(header, moodETag(m), false):
+		fail(w, r, http.StatusPreconditionFailed, "precondition_failed")
+		return 0, false
+	}
+	return m.Version, true
+}
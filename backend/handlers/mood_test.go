@@ -3,30 +3,57 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
-	"regexp"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
-	"moodtracker/db"
 	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/problem"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-chi/chi/v5"
-	"github.com/jmoiron/sqlx"
 )
 
-// setupMoodTest створює sqlmock і підставляє його в db.DB
-func setupMoodTest(t *testing.T) (mock sqlmock.Sqlmock, teardown func()) {
-	sqlDB, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+// brokenMoods імітує недоступну БД
+type brokenMoods struct{ repository.MoodRepository }
+
+func (brokenMoods) Create(context.Context, *models.Mood) error { return errDB }
+func (brokenMoods) List(context.Context, string, repository.MoodFilter) ([]models.Mood, error) {
+	return nil, errDB
+}
+func (brokenMoods) Page(context.Context, string, repository.MoodQuery) ([]models.Mood, error) {
+	return nil, errDB
+}
+func (brokenMoods) Update(context.Context, *models.Mood) error                { return errDB }
+func (brokenMoods) Delete(context.Context, string, string, int) error         { return errDB }
+func (brokenMoods) Get(context.Context, string, string) (*models.Mood, error) { return nil, errDB }
+
+// setupMoodTest створює MoodHandler поверх репозиторію в пам'яті
+func setupMoodTest(t *testing.T) (*MoodHandler, repository.MoodRepository) {
+	repos := memory.New()
+	if err := repos.Users.Create(context.Background(), &models.User{ID: "user-1", Email: "u1@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	return NewMoodHandler(repos, nil), repos.Moods
+}
+
+// seedMood зберігає запис користувача на дату date
+func seedMood(t *testing.T, moods repository.MoodRepository, id, userID, date, icon string) {
+	d, _ := time.Parse("2006-01-02", date)
+	now := time.Now()
+	err := moods.Create(context.Background(), &models.Mood{
+		ID: id, UserID: userID, Date: d, Icon: icon, Comment: "c-" + id, CreatedAt: now, UpdatedAt: now,
+	})
 	if err != nil {
-		t.Fatalf("не вдалося створити sqlmock: %v", err)
+		t.Fatal(err)
 	}
-	db.DB.DB = sqlx.NewDb(sqlDB, "postgres")
-	return m, func() { sqlDB.Close() }
 }
 
 // newRequest формує http.Request з контекстом userID і, за потреби, chi URLParam "id"
@@ -48,52 +75,59 @@ func newRequest(method, url string, body []byte, id string) *http.Request {
 	return req.WithContext(ctx)
 }
 
+// decodeProblem розбирає відповідь з помилкою і перевіряє статус та код
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) problem.Problem {
+	t.Helper()
+	var p problem.Problem
+	if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("очікував %s, отримав %q", problem.ContentType, ct)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("не вдалося розпарсити problem+json: %v", err)
+	}
+	if w.Code != status || p.Status != status || p.Code != code {
+		t.Errorf("очікував %d %s, отримав %d %+v", status, code, w.Code, p)
+	}
+	return p
+}
+
 func TestCreateMood_BadJSON(t *testing.T) {
+	h, _ := setupMoodTest(t)
 	req := newRequest(http.MethodPost, "/mood", []byte("not-json"), "")
 	w := httptest.NewRecorder()
 
-	CreateMood(w, req)
+	h.Create(w, req)
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("TestCreateMood_BadJSON: очікував 400, отримав %d", w.Code)
 	}
 }
 
 func TestCreateMood_DBError(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
+	h, _ := setupMoodTest(t)
+	h.Moods = brokenMoods{}
 
-	// будь-який INSERT повертає помилку
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO mood")).
-		WillReturnError(errors.New("insert fail"))
-
-	payload := map[string]string{"icon": "😀", "comment": "oops"}
+	payload := map[string]string{"icon": "😃", "comment": "oops"}
 	body, _ := json.Marshal(payload)
 	req := newRequest(http.MethodPost, "/mood", body, "")
 	w := httptest.NewRecorder()
 
-	CreateMood(w, req)
-	if w.Code != http.StatusInternalServerError {
-		t.Errorf("TestCreateMood_DBError: очікував 500, отримав %d", w.Code)
-	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestCreateMood_DBError: невиконані очікування: %v", err)
+	h.Create(w, req)
+	decodeProblem(t, w, http.StatusInternalServerError, "internal_error")
+	// подробиці помилки БД лишаються в лозі
+	if strings.Contains(w.Body.String(), errDB.Error()) {
+		t.Errorf("TestCreateMood_DBError: відповідь розкриває помилку БД: %s", w.Body.String())
 	}
 }
 
 func TestCreateMood_Success(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
-
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO mood")).
-		WithArgs(sqlmock.AnyArg(), "user-1", sqlmock.AnyArg(), "😃", "ok", sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	h, moods := setupMoodTest(t)
 
-	payload := map[string]string{"icon": "😃", "comment": "ok"}
+	payload := map[string]string{"icon": "😃", "comment": "ok", "date": "2025-01-15"}
 	body, _ := json.Marshal(payload)
 	req := newRequest(http.MethodPost, "/mood", body, "")
 	w := httptest.NewRecorder()
 
-	CreateMood(w, req)
+	h.Create(w, req)
 	if w.Code != http.StatusCreated {
 		t.Fatalf("TestCreateMood_Success: очікував 201, отримав %d", w.Code)
 	}
@@ -105,227 +139,776 @@ func TestCreateMood_Success(t *testing.T) {
 	if resp["user_id"] != "user-1" || resp["icon"] != "😃" || resp["comment"] != "ok" {
 		t.Errorf("TestCreateMood_Success: невірні дані у відповіді: %+v", resp)
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestCreateMood_Success: невиконані очікування: %v", err)
+	stored, err := moods.Get(context.Background(), "user-1", resp["id"].(string))
+	if err != nil || stored.Date.Format("2006-01-02") != "2025-01-15" {
+		t.Errorf("TestCreateMood_Success: запис не збережено: %+v, %v", stored, err)
 	}
 }
 
-func TestListMood_DBError(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
+// conflictMoods імітує запис, що паралельно з'явився на ту саму дату
+type conflictMoods struct{ repository.MoodRepository }
+
+func (conflictMoods) Create(context.Context, *models.Mood) error { return repository.ErrConflict }
+
+func postMood(h *MoodHandler, payload map[string]string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+	w := httptest.NewRecorder()
+	h.Create(w, newRequest(http.MethodPost, "/mood", body, ""))
+	return w
+}
+
+func TestCreateMood_SingleModeConflict(t *testing.T) {
+	h, _ := setupMoodTest(t)
+
+	if w := postMood(h, map[string]string{"icon": "😃", "comment": "ранок", "date": "2025-01-15"}); w.Code != http.StatusCreated {
+		t.Fatalf("TestCreateMood_SingleModeConflict: очікував 201, отримав %d", w.Code)
+	}
+	w := postMood(h, map[string]string{"icon": "😞", "comment": "вечір", "date": "2025-01-15"})
+	p := decodeProblem(t, w, http.StatusConflict, "mood_already_exists")
+	if p.Instance != "/mood" || p.Title != "Conflict" || p.Detail == "" {
+		t.Errorf("TestCreateMood_SingleModeConflict: неповна відповідь %+v", p)
+	}
+}
+
+func TestCreateMood_RepositoryConflict(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	h.Moods = conflictMoods{moods}
+
+	w := postMood(h, map[string]string{"icon": "😃", "comment": "ok", "date": "2025-01-15"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("TestCreateMood_RepositoryConflict: очікував 409, отримав %d", w.Code)
+	}
+}
+
+func TestCreateMood_MultipleModeOrderedByTime(t *testing.T) {
+	h, _ := setupMoodTest(t)
+	err := h.Users.UpdateSettings(context.Background(), "user-1", models.UserSettings{EntryMode: models.EntryModeMultiple})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// вечірній запис вносимо першим: список однаково має йти за часом
+	for _, p := range []map[string]string{
+		{"icon": "😞", "comment": "вечір", "logged_at": "2025-01-15T21:00:00Z"},
+		{"icon": "😃", "comment": "ранок", "logged_at": "2025-01-15T08:30:00Z"},
+	} {
+		if w := postMood(h, p); w.Code != http.StatusCreated {
+			t.Fatalf("TestCreateMood_MultipleModeOrderedByTime: очікував 201, отримав %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// сторінки API йдуть за (date, id), а внутрішній список дня – за часом запису
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	list, err := h.Moods.List(context.Background(), "user-1", repository.MoodFilter{From: &day, To: &day})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Comment != "ранок" || list[1].Comment != "вечір" {
+		t.Errorf("TestCreateMood_MultipleModeOrderedByTime: неправильний порядок: %+v", list)
+	}
+	if list[0].Date.Format("2006-01-02") != "2025-01-15" || list[0].LoggedAt == nil {
+		t.Errorf("TestCreateMood_MultipleModeOrderedByTime: дату не взято з logged_at: %+v", list[0])
+	}
+}
+
+func TestCreateMood_LoggedAtOutsideDate(t *testing.T) {
+	h, _ := setupMoodTest(t)
+
+	w := postMood(h, map[string]string{"icon": "😃", "comment": "ok", "date": "2025-01-15", "logged_at": "2025-01-16T08:00:00Z"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("TestCreateMood_LoggedAtOutsideDate: очікував 400, отримав %d", w.Code)
+	}
+}
+
+func TestCreateMood_UnknownIcon(t *testing.T) {
+	h, _ := setupMoodTest(t)
+
+	w := postMood(h, map[string]string{"icon": "🦄", "comment": "ok", "date": "2025-01-15"})
+	p := decodeProblem(t, w, http.StatusBadRequest, "validation_failed")
+	if len(p.Errors) != 1 || p.Errors[0].Field != "icon" || p.Errors[0].Code != "unknown_icon" {
+		t.Errorf("TestCreateMood_UnknownIcon: неправильні помилки полів %+v", p.Errors)
+	}
+}
+
+func TestCreateMood_FieldErrors(t *testing.T) {
+	h, _ := setupMoodTest(t)
+	tomorrow := time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+
+	for _, tc := range []struct {
+		payload map[string]string
+		want    string // "поле:код" через пробіл
+	}{
+		{map[string]string{"icon": "", "comment": " "}, "icon:required comment:required"},
+		{map[string]string{"icon": strings.Repeat("😃", 51), "comment": strings.Repeat("я", 1001)}, "icon:too_long comment:too_long"},
+		{map[string]string{"icon": "😃", "comment": "ok", "date": "15.01.2025"}, "date:invalid_date"},
+		{map[string]string{"icon": "😃", "comment": "ok", "date": tomorrow}, "date:future_date"},
+		{map[string]string{"icon": "😃", "comment": "ok", "logged_at": time.Now().Add(time.Hour).Format(time.RFC3339)}, "logged_at:future_date"},
+		{map[string]string{"icon": "😃", "comment": "ok", "mood": "5"}, "mood:unknown_field"},
+	} {
+		p := decodeProblem(t, postMood(h, tc.payload), http.StatusBadRequest, "validation_failed")
+		var got []string
+		for _, fe := range p.Errors {
+			got = append(got, fe.Field+":"+fe.Code)
+		}
+		if strings.Join(got, " ") != tc.want {
+			t.Errorf("%v: очікував %q, отримав %q", tc.payload, tc.want, got)
+		}
+	}
+}
+
+func TestCreateMood_BackfillWindow(t *testing.T) {
+	h, _ := setupMoodTest(t)
+	h.BackfillDays = 7
+
+	old := time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+	p := decodeProblem(t, postMood(h, map[string]string{"icon": "😃", "comment": "ok", "date": old}), http.StatusBadRequest, "validation_failed")
+	if len(p.Errors) != 1 || p.Errors[0].Code != "date_too_old" || p.Errors[0].Detail != "настрій можна внести лише за останні 7 днів" {
+		t.Errorf("TestCreateMood_BackfillWindow: неправильні помилки полів %+v", p.Errors)
+	}
+	recent := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
+	if w := postMood(h, map[string]string{"icon": "😃", "comment": "ok", "date": recent}); w.Code != http.StatusCreated {
+		t.Errorf("TestCreateMood_BackfillWindow: очікував 201, отримав %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateMood_BodyTooLarge(t *testing.T) {
+	h, _ := setupMoodTest(t)
+
+	w := postMood(h, map[string]string{"icon": "😃", "comment": strings.Repeat("a", maxMoodBody)})
+	decodeProblem(t, w, http.StatusRequestEntityTooLarge, "request_too_large")
+}
+
+func TestCreateMood_StoresScore(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	err := h.Icons.Create(context.Background(), "user-1", &models.MoodIcon{Icon: "🦄", Label: "Казково", Score: 5, Color: "#8e24aa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for icon, want := range map[string]int{"😞": 2, "🦄": 5} {
+		date := map[string]string{"😞": "2025-01-15", "🦄": "2025-01-16"}[icon]
+		w := postMood(h, map[string]string{"icon": icon, "comment": "ok", "date": date})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("TestCreateMood_StoresScore: %s: очікував 201, отримав %d", icon, w.Code)
+		}
+		var resp models.Mood
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		stored, err := moods.Get(context.Background(), "user-1", resp.ID)
+		if err != nil || stored.Score == nil || *stored.Score != want {
+			t.Errorf("TestCreateMood_StoresScore: %s: очікував бал %d, отримав %+v, %v", icon, want, stored, err)
+		}
+	}
+}
+
+func TestCreateMood_DefaultDateInUserTimezone(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	settings := models.UserSettings{EntryMode: models.EntryModeSingle, Timezone: "Pacific/Kiritimati"}
+	if err := h.Users.UpdateSettings(context.Background(), "user-1", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	w := postMood(h, map[string]string{"icon": "😃", "comment": "ok"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("TestCreateMood_DefaultDateInUserTimezone: очікував 201, отримав %d", w.Code)
+	}
+	var resp models.Mood
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	stored, _ := moods.Get(context.Background(), "user-1", resp.ID)
+	if want := settings.Today(time.Now()); !stored.Date.Equal(want) {
+		t.Errorf("TestCreateMood_DefaultDateInUserTimezone: очікував %s, отримав %s", want.Format("2006-01-02"), stored.Date.Format("2006-01-02"))
+	}
+}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM mood WHERE user_id=$1")).
-		WithArgs("user-1").
-		WillReturnError(errors.New("select fail"))
+func TestListMood_DBError(t *testing.T) {
+	h, _ := setupMoodTest(t)
+	h.Moods = brokenMoods{}
 
 	req := newRequest(http.MethodGet, "/mood", nil, "")
 	w := httptest.NewRecorder()
 
-	ListMood(w, req)
+	h.List(w, req)
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("TestListMood_DBError: очікував 500, отримав %d", w.Code)
 	}
 }
 
 func TestListMood_NoFilter_Success(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
-
-	now := time.Now().Truncate(time.Second)
-	// повертаємо time.Time для date
-	rows := sqlmock.NewRows([]string{"id", "user_id", "date", "icon", "comment", "created_at", "updated_at"}).
-		AddRow("m1", "user-1", now, "🙂", "fine", now, now)
-
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM mood WHERE user_id=$1")).
-		WithArgs("user-1").
-		WillReturnRows(rows)
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
+	seedMood(t, moods, "m2", "user-2", "2025-01-10", "😡")
 
 	req := newRequest(http.MethodGet, "/mood", nil, "")
 	w := httptest.NewRecorder()
 
-	ListMood(w, req)
+	h.List(w, req)
 	if w.Code != http.StatusOK {
 		t.Fatalf("TestListMood_NoFilter_Success: очікував 200, отримав %d", w.Code)
 	}
 
-	var list []map[string]interface{}
-	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+	var resp page[map[string]interface{}]
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("TestListMood_NoFilter_Success: не вдалося розпарсити JSON: %v", err)
 	}
-	if len(list) != 1 || list[0]["icon"] != "🙂" {
-		t.Errorf("TestListMood_NoFilter_Success: неправильні записи: %+v", list)
-	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestListMood_NoFilter_Success: невиконані очікування: %v", err)
+	if list := resp.Data; len(list) != 1 || list[0]["icon"] != "🙂" {
+		t.Errorf("TestListMood_NoFilter_Success: неправильні записи: %+v", resp)
 	}
 }
 
 func TestListMood_WithFilter_Success(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
-
-	now := time.Now().Truncate(time.Second)
-	rows := sqlmock.NewRows([]string{"id", "user_id", "date", "icon", "comment", "created_at", "updated_at"}).
-		AddRow("m2", "user-1", now, "🙁", "sad", now, now)
-
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM mood WHERE user_id=$1 AND date BETWEEN $2 AND $3")).
-		WithArgs("user-1", "2025-01-01", "2025-01-31").
-		WillReturnRows(rows)
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2024-12-31", "🙂")
+	seedMood(t, moods, "m2", "user-1", "2025-01-31", "🙁")
+	seedMood(t, moods, "m3", "user-1", "2025-02-01", "😃")
 
 	req := newRequest(http.MethodGet, "/mood?from=2025-01-01&to=2025-01-31", nil, "")
 	w := httptest.NewRecorder()
 
-	ListMood(w, req)
+	h.List(w, req)
 	if w.Code != http.StatusOK {
 		t.Fatalf("TestListMood_WithFilter_Success: очікував 200, отримав %d", w.Code)
 	}
 
-	var list []map[string]interface{}
-	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+	var resp page[map[string]interface{}]
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("TestListMood_WithFilter_Success: не вдалося розпарсити JSON: %v", err)
 	}
-	if len(list) != 1 || list[0]["icon"] != "🙁" {
-		t.Errorf("TestListMood_WithFilter_Success: неправильні записи: %+v", list)
+	if list := resp.Data; len(list) != 1 || list[0]["icon"] != "🙁" {
+		t.Errorf("TestListMood_WithFilter_Success: неправильні записи: %+v", resp)
+	}
+}
+
+func TestListMood_BadFilter(t *testing.T) {
+	h, _ := setupMoodTest(t)
+
+	req := newRequest(http.MethodGet, "/mood?from=01.01.2025&to=2025-01-31", nil, "")
+	w := httptest.NewRecorder()
+
+	h.List(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("TestListMood_BadFilter: очікував 400, отримав %d", w.Code)
+	}
+}
+
+// listMoods виконує GET url і повертає сторінку записів
+func listMoods(t *testing.T, h *MoodHandler, url string) (page[models.Mood], *httptest.ResponseRecorder) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.List(w, newRequest(http.MethodGet, url, nil, ""))
+	var resp page[models.Mood]
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: очікував 200, отримав %d: %s", url, w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: не вдалося розпарсити JSON: %v", url, err)
+	}
+	return resp, w
+}
+
+func ids(list []models.Mood) string {
+	out := make([]string, len(list))
+	for i, m := range list {
+		out[i] = m.ID
+	}
+	return strings.Join(out, ",")
+}
+
+func TestListMood_Pagination(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "a", "user-1", "2025-01-10", "🙂")
+	seedMood(t, moods, "c", "user-1", "2025-01-10", "🙂")
+	seedMood(t, moods, "b", "user-1", "2025-01-11", "🙂")
+	seedMood(t, moods, "d", "user-1", "2025-01-12", "🙂")
+
+	// сторінки по 2: (date, id) за зростанням, курсор веде на наступну
+	first, w := listMoods(t, h, "/api/mood?limit=2")
+	if ids(first.Data) != "a,c" || first.NextCursor == "" {
+		t.Fatalf("перша сторінка: %s, cursor %q", ids(first.Data), first.NextCursor)
+	}
+	want := `</api/mood?cursor=` + first.NextCursor + `&limit=2>; rel="next"`
+	if got := w.Header().Get("Link"); got != want {
+		t.Errorf("Link: очікував %s, отримав %s", want, got)
+	}
+	second, w := listMoods(t, h, "/api/mood?limit=2&cursor="+first.NextCursor)
+	if ids(second.Data) != "b,d" || second.NextCursor != "" || w.Header().Get("Link") != "" {
+		t.Errorf("остання сторінка: %s, cursor %q, Link %q", ids(second.Data), second.NextCursor, w.Header().Get("Link"))
+	}
+
+	// від нових до старих
+	desc, _ := listMoods(t, h, "/api/mood?sort=desc&limit=3")
+	if ids(desc.Data) != "d,b,c" {
+		t.Fatalf("desc: %s", ids(desc.Data))
+	}
+	if rest, _ := listMoods(t, h, "/api/mood?sort=desc&limit=3&cursor="+desc.NextCursor); ids(rest.Data) != "a" {
+		t.Errorf("desc, друга сторінка: %s", ids(rest.Data))
+	}
+
+	// межі дат можна задавати окремо
+	if from, _ := listMoods(t, h, "/api/mood?from=2025-01-11"); ids(from.Data) != "b,d" {
+		t.Errorf("лише from: %s", ids(from.Data))
+	}
+	if to, _ := listMoods(t, h, "/api/mood?to=2025-01-10"); ids(to.Data) != "a,c" {
+		t.Errorf("лише to: %s", ids(to.Data))
+	}
+}
+
+func TestListMood_Filters(t *testing.T) {
+	h, _ := setupMoodTest(t)
+	for _, p := range []map[string]string{
+		{"icon": "😃", "comment": "Чудова прогулянка в парку", "date": "2025-01-10"},
+		{"icon": "😞", "comment": "погано спав, прогулянки не було", "date": "2025-01-11"},
+		{"icon": "😃", "comment": "робота", "date": "2025-01-12"},
+	} {
+		if w := postMood(h, p); w.Code != http.StatusCreated {
+			t.Fatalf("очікував 201, отримав %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	comments := func(url string) string {
+		resp, _ := listMoods(t, h, url)
+		out := make([]string, len(resp.Data))
+		for i, m := range resp.Data {
+			out[i] = m.Comment
+		}
+		return strings.Join(out, "|")
+	}
+	if got := comments("/mood?icon=" + url.QueryEscape("😃")); got != "Чудова прогулянка в парку|робота" {
+		t.Errorf("icon: %s", got)
+	}
+	if got := comments("/mood?score=2"); got != "погано спав, прогулянки не було" {
+		t.Errorf("score: %s", got)
+	}
+	// пошук без урахування регістру і за початком слова
+	if got := comments("/mood?q=" + url.QueryEscape("ПРОГУЛЯНК")); got != "Чудова прогулянка в парку|погано спав, прогулянки не було" {
+		t.Errorf("q: %s", got)
+	}
+	if got := comments("/mood?q=" + url.QueryEscape("прогулянка парк") + "&score=5"); got != "Чудова прогулянка в парку" {
+		t.Errorf("q з кількох слів і score: %s", got)
+	}
+}
+
+func TestListMood_BadParams(t *testing.T) {
+	h, _ := setupMoodTest(t)
+	for url, code := range map[string]string{
+		"/mood?to=2025-31-01":                   "invalid_to_date",
+		"/mood?from=2025-02-01&to=2025-01-01":   "from_after_to",
+		"/mood?sort=random":                     "invalid_sort",
+		"/mood?score=9":                         "invalid_score",
+		"/mood?limit=0":                         "invalid_limit",
+		"/mood?limit=1000":                      "invalid_limit",
+		"/mood?cursor=not-a-cursor":             "invalid_cursor",
+		"/mood?cursor=" + encodeCursorRaw("{}"): "invalid_cursor",
+	} {
+		w := httptest.NewRecorder()
+		h.List(w, newRequest(http.MethodGet, url, nil, ""))
+		decodeProblem(t, w, http.StatusBadRequest, code)
+	}
+}
+
+func encodeCursorRaw(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+func TestMoodStats_Success(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-01", "😃")
+	seedMood(t, moods, "m2", "user-1", "2025-01-03", "😃")
+	seedMood(t, moods, "m3", "user-2", "2025-01-02", "😡")
+
+	req := newRequest(http.MethodGet, "/mood/stats?from=2025-01-01&to=2025-01-03&group_by=week", nil, "")
+	w := httptest.NewRecorder()
+
+	h.Stats(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("TestMoodStats_Success: очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Entries     int      `json:"entries"`
+		MostCommon  string   `json:"most_common"`
+		MissingDays []string `json:"missing_days"`
+		Groups      []struct {
+			Key string `json:"key"`
+		} `json:"groups"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("TestMoodStats_Success: не вдалося розпарсити JSON: %v", err)
+	}
+	if resp.Entries != 2 || resp.MostCommon != "😃" || len(resp.MissingDays) != 1 || resp.MissingDays[0] != "2025-01-02" {
+		t.Errorf("TestMoodStats_Success: неправильна статистика: %s", w.Body.String())
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestListMood_WithFilter_Success: невиконані очікування: %v", err)
+	if len(resp.Groups) != 1 || resp.Groups[0].Key != "2025-W01" {
+		t.Errorf("TestMoodStats_Success: неправильні групи: %+v", resp.Groups)
+	}
+}
+
+func TestMoodStats_BadParams(t *testing.T) {
+	h, _ := setupMoodTest(t)
+
+	for _, url := range []string{
+		"/mood/stats?group_by=year",
+		"/mood/stats?from=01.01.2025",
+		"/mood/stats?from=2025-02-01&to=2025-01-01",
+		"/mood/stats?from=2023-01-01&to=2025-01-01",
+	} {
+		w := httptest.NewRecorder()
+		h.Stats(w, newRequest(http.MethodGet, url, nil, ""))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("TestMoodStats_BadParams: %s: очікував 400, отримав %d", url, w.Code)
+		}
+	}
+}
+
+func TestMoodStats_DBError(t *testing.T) {
+	h, _ := setupMoodTest(t)
+	h.Moods = brokenMoods{}
+
+	w := httptest.NewRecorder()
+	h.Stats(w, newRequest(http.MethodGet, "/mood/stats", nil, ""))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("TestMoodStats_DBError: очікував 500, отримав %d", w.Code)
+	}
+}
+
+func TestGetMood_NotFound(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	// запис іншого користувача не видно
+	seedMood(t, moods, "m1", "user-2", "2025-01-10", "🙂")
+
+	req := newRequest(http.MethodGet, "/mood/m1", nil, "m1")
+	w := httptest.NewRecorder()
+
+	h.Get(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("TestGetMood_NotFound: очікував 404, отримав %d", w.Code)
+	}
+}
+
+func TestGetMood_Success(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
+
+	req := newRequest(http.MethodGet, "/mood/m1", nil, "m1")
+	w := httptest.NewRecorder()
+
+	h.Get(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("TestGetMood_Success: очікував 200, отримав %d", w.Code)
+	}
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["id"] != "m1" || resp["icon"] != "🙂" {
+		t.Errorf("TestGetMood_Success: неправильний запис: %+v", resp)
+	}
+}
+
+func TestGetMood_NotModified(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
+
+	w := httptest.NewRecorder()
+	h.Get(w, newRequest(http.MethodGet, "/mood/m1", nil, "m1"))
+	etag := w.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("очікував ETag \"1\", отримав %q", etag)
+	}
+
+	req := newRequest(http.MethodGet, "/mood/m1", nil, "m1")
+	req.Header.Set("If-None-Match", "W/"+etag)
+	w = httptest.NewRecorder()
+	h.Get(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+		t.Errorf("очікував 304 без тіла, отримав %d: %s", w.Code, w.Body.String())
+	}
+
+	// після зміни запису стара версія вже не актуальна
+	if w := patchMood(h, "m1", `{"comment":"нове"}`); w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("очікував ETag \"2\" після зміни, отримав %q", w.Header().Get("ETag"))
+	}
+	w = httptest.NewRecorder()
+	h.Get(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("очікував 200 для нової версії, отримав %d", w.Code)
+	}
+}
+
+func TestListMood_NotModified(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
+
+	w := httptest.NewRecorder()
+	h.List(w, newRequest(http.MethodGet, "/mood", nil, ""))
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("список без ETag")
+	}
+
+	req := newRequest(http.MethodGet, "/mood", nil, "")
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.List(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("очікував 304, отримав %d", w.Code)
+	}
+
+	// новий запис змінює список
+	seedMood(t, moods, "m2", "user-1", "2025-01-11", "😞")
+	w = httptest.NewRecorder()
+	h.List(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("очікував 200 з новим ETag, отримав %d %q", w.Code, w.Header().Get("ETag"))
 	}
 }
 
 func TestUpdateMood_BadJSON(t *testing.T) {
+	h, _ := setupMoodTest(t)
 	req := newRequest(http.MethodPut, "/mood/m1", []byte("bad"), "m1")
 	w := httptest.NewRecorder()
 
-	UpdateMood(w, req)
+	h.Update(w, req)
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("TestUpdateMood_BadJSON: очікував 400, отримав %d", w.Code)
 	}
 }
 
 func TestUpdateMood_DBError(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
+	h, _ := setupMoodTest(t)
+	h.Moods = brokenMoods{}
 
-	mock.ExpectExec(regexp.QuoteMeta(
-		"UPDATE mood SET icon=$1, comment=$2, updated_at=$3 WHERE id=$4 AND user_id=$5")).
-		WithArgs("ico2", "comm2", sqlmock.AnyArg(), "m1", "user-1").
-		WillReturnError(errors.New("update fail"))
-
-	payload := map[string]string{"icon": "ico2", "comment": "comm2"}
+	payload := map[string]string{"icon": "😐", "comment": "comm2"}
 	body, _ := json.Marshal(payload)
 	req := newRequest(http.MethodPut, "/mood/m1", body, "m1")
 	w := httptest.NewRecorder()
 
-	UpdateMood(w, req)
+	h.Update(w, req)
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("TestUpdateMood_DBError: очікував 500, отримав %d", w.Code)
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestUpdateMood_DBError: невиконані очікування: %v", err)
+}
+
+func TestUpdateMood_UnknownIcon(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "😊")
+
+	body, _ := json.Marshal(map[string]string{"icon": "ico", "comment": "comm"})
+	w := httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/mood/m1", body, "m1"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("TestUpdateMood_UnknownIcon: очікував 400, отримав %d", w.Code)
+	}
+}
+
+func TestUpdateMood_EmptyFields(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "😊")
+
+	w := httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/mood/m1", []byte(`{"icon":"😊","comment":""}`), "m1"))
+	p := decodeProblem(t, w, http.StatusBadRequest, "validation_failed")
+	if len(p.Errors) != 1 || p.Errors[0].Field != "comment" {
+		t.Errorf("TestUpdateMood_EmptyFields: неправильні помилки полів %+v", p.Errors)
+	}
+	if m, _ := moods.Get(context.Background(), "user-1", "m1"); m.Comment != "c-m1" {
+		t.Errorf("TestUpdateMood_EmptyFields: запис змінено: %+v", m)
 	}
 }
 
 func TestUpdateMood_NotFound(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
-
-	mock.ExpectExec(regexp.QuoteMeta(
-		"UPDATE mood SET icon=$1, comment=$2, updated_at=$3 WHERE id=$4 AND user_id=$5")).
-		WillReturnResult(sqlmock.NewResult(0, 0))
+	h, _ := setupMoodTest(t)
 
-	payload := map[string]string{"icon": "ico3", "comment": "comm3"}
+	payload := map[string]string{"icon": "😢", "comment": "comm3"}
 	body, _ := json.Marshal(payload)
 	req := newRequest(http.MethodPut, "/mood/m1", body, "m1")
 	w := httptest.NewRecorder()
 
-	UpdateMood(w, req)
+	h.Update(w, req)
 	if w.Code != http.StatusNotFound {
 		t.Errorf("TestUpdateMood_NotFound: очікував 404, отримав %d", w.Code)
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestUpdateMood_NotFound: невиконані очікування: %v", err)
-	}
 }
 
 func TestUpdateMood_Success(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
-
-	mock.ExpectExec(regexp.QuoteMeta(
-		"UPDATE mood SET icon=$1, comment=$2, updated_at=$3 WHERE id=$4 AND user_id=$5")).
-		WithArgs("ico4", "comm4", sqlmock.AnyArg(), "m1", "user-1").
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
 
-	payload := map[string]string{"icon": "ico4", "comment": "comm4"}
+	payload := map[string]string{"icon": "😊", "comment": "comm4"}
 	body, _ := json.Marshal(payload)
 	req := newRequest(http.MethodPut, "/mood/m1", body, "m1")
 	w := httptest.NewRecorder()
 
-	UpdateMood(w, req)
-	if w.Code != http.StatusNoContent {
-		t.Errorf("TestUpdateMood_Success: очікував 204, отримав %d", w.Code)
+	h.Update(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("TestUpdateMood_Success: очікував 200, отримав %d", w.Code)
+	}
+	var resp models.Mood
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.ID != "m1" || resp.Icon != "😊" {
+		t.Errorf("TestUpdateMood_Success: у відповіді очікував оновлений запис, отримав %s", w.Body.String())
+	}
+	stored, _ := moods.Get(context.Background(), "user-1", "m1")
+	if stored.Icon != "😊" || stored.Comment != "comm4" || stored.Score == nil || *stored.Score != 4 {
+		t.Errorf("TestUpdateMood_Success: запис не оновлено: %+v", stored)
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestUpdateMood_Success: невиконані очікування: %v", err)
+	if stored.Date.Format("2006-01-02") != "2025-01-10" {
+		t.Errorf("TestUpdateMood_Success: без date день не мав змінитися: %v", stored.Date)
 	}
 }
 
-func TestDeleteMood_DBError(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
+func patchMood(h *MoodHandler, id, body string) *httptest.ResponseRecorder {
+	req := newRequest(http.MethodPatch, "/mood/"+id, []byte(body), id)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	h.Patch(w, req)
+	return w
+}
+
+func TestPatchMood_Partial(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
 
-	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM mood WHERE id=$1 AND user_id=$2")).
-		WithArgs("m1", "user-1").
-		WillReturnError(errors.New("delete fail"))
+	w := patchMood(h, "m1", `{"comment":"лише коментар"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	stored, _ := moods.Get(context.Background(), "user-1", "m1")
+	if stored.Icon != "🙂" || stored.Comment != "лише коментар" {
+		t.Errorf("мав змінитися лише коментар: %+v", stored)
+	}
+
+	// null для обов'язкового поля – помилка, а не очищення
+	p := decodeProblem(t, patchMood(h, "m1", `{"icon":null,"comment":""}`), http.StatusBadRequest, "validation_failed")
+	if len(p.Errors) != 2 || p.Errors[0].Field != "icon" || p.Errors[1].Field != "comment" {
+		t.Errorf("неправильні помилки полів: %+v", p.Errors)
+	}
+
+	req := newRequest(http.MethodPatch, "/mood/m1", []byte(`{"comment":"x"}`), "m1")
+	req.Header.Set("Content-Type", "text/plain")
+	w = httptest.NewRecorder()
+	h.Patch(w, req)
+	decodeProblem(t, w, http.StatusUnsupportedMediaType, "unsupported_media_type")
+
+	decodeProblem(t, patchMood(h, "m2", `{"comment":"x"}`), http.StatusNotFound, "not_found")
+}
+
+func TestPatchMood_MoveDate(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
+	seedMood(t, moods, "m2", "user-1", "2025-01-12", "😞")
+	at := time.Date(2025, 1, 10, 21, 30, 0, 0, time.UTC)
+	m1, _ := moods.Get(context.Background(), "user-1", "m1")
+	m1.LoggedAt = &at
+	moods.Update(context.Background(), m1)
+
+	// день зайнятий іншим записом
+	decodeProblem(t, patchMood(h, "m1", `{"date":"2025-01-12"}`), http.StatusConflict, "mood_already_exists")
+
+	w := patchMood(h, "m1", `{"date":"2025-01-11"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	stored, _ := moods.Get(context.Background(), "user-1", "m1")
+	if stored.Date.Format("2006-01-02") != "2025-01-11" || stored.LoggedAt == nil || !stored.LoggedAt.Equal(at.AddDate(0, 0, 1)) {
+		t.Errorf("запис мав переїхати на 11.01 разом із часом: %+v", stored)
+	}
+
+	// logged_at має припадати на новий день; null прибирає час
+	decodeProblem(t, patchMood(h, "m1", `{"date":"2025-01-09","logged_at":"2025-01-11T08:00:00Z"}`), http.StatusBadRequest, "validation_failed")
+	if w := patchMood(h, "m1", `{"date":"2025-01-09","logged_at":null}`); w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	if stored, _ := moods.Get(context.Background(), "user-1", "m1"); stored.LoggedAt != nil || stored.Date.Format("2006-01-02") != "2025-01-09" {
+		t.Errorf("очікував запис на 09.01 без часу: %+v", stored)
+	}
+
+	// у режимі "кілька на день" день може бути зайнятим
+	h.Users.UpdateSettings(context.Background(), "user-1", models.UserSettings{EntryMode: models.EntryModeMultiple})
+	if w := patchMood(h, "m1", `{"date":"2025-01-12"}`); w.Code != http.StatusOK {
+		t.Errorf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPatchMood_IfMatch(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
+
+	patch := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := newRequest(http.MethodPatch, "/mood/m1", []byte(body), "m1")
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		h.Patch(w, req)
+		return w
+	}
+
+	// перший пристрій зберігає зміну, другий зі старою версією отримує 412
+	if w := patch(`"1"`, `{"comment":"з телефона"}`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("очікував 200 з ETag \"2\", отримав %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	decodeProblem(t, patch(`"1"`, `{"comment":"з ноутбука"}`), http.StatusPreconditionFailed, "precondition_failed")
+	if stored, _ := moods.Get(context.Background(), "user-1", "m1"); stored.Comment != "з телефона" {
+		t.Errorf("застаріла зміна затерла запис: %+v", stored)
+	}
+
+	// слабкий ETag для If-Match не підходить, "*" – будь-яка версія
+	decodeProblem(t, patch(`W/"2"`, `{"comment":"x"}`), http.StatusPreconditionFailed, "precondition_failed")
+	if w := patch(`"7", "2"`, `{"comment":"x"}`); w.Code != http.StatusOK {
+		t.Errorf("очікував 200 для списку ETag, отримав %d", w.Code)
+	}
+	if w := patch("*", `{"comment":"y"}`); w.Code != http.StatusOK {
+		t.Errorf("очікував 200 для *, отримав %d", w.Code)
+	}
+}
+
+func TestDeleteMood_IfMatch(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
+
+	del := func(ifMatch string) *httptest.ResponseRecorder {
+		req := newRequest(http.MethodDelete, "/mood/m1", nil, "m1")
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		h.Delete(w, req)
+		return w
+	}
+	decodeProblem(t, del(`"2"`), http.StatusPreconditionFailed, "precondition_failed")
+	if w := del(`"1"`); w.Code != http.StatusNoContent {
+		t.Fatalf("очікував 204, отримав %d", w.Code)
+	}
+	decodeProblem(t, del(`"1"`), http.StatusNotFound, "not_found")
+}
+
+func TestDeleteMood_DBError(t *testing.T) {
+	h, _ := setupMoodTest(t)
+	h.Moods = brokenMoods{}
 
 	req := newRequest(http.MethodDelete, "/mood/m1", nil, "m1")
 	w := httptest.NewRecorder()
 
-	DeleteMood(w, req)
+	h.Delete(w, req)
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("TestDeleteMood_DBError: очікував 500, отримав %d", w.Code)
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestDeleteMood_DBError: невиконані очікування: %v", err)
-	}
 }
 
 func TestDeleteMood_NotFound(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
-
-	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM mood WHERE id=$1 AND user_id=$2")).
-		WithArgs("m1", "user-1").
-		WillReturnResult(sqlmock.NewResult(0, 0))
+	h, _ := setupMoodTest(t)
 
 	req := newRequest(http.MethodDelete, "/mood/m1", nil, "m1")
 	w := httptest.NewRecorder()
 
-	DeleteMood(w, req)
+	h.Delete(w, req)
 	if w.Code != http.StatusNotFound {
 		t.Errorf("TestDeleteMood_NotFound: очікував 404, отримав %d", w.Code)
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestDeleteMood_NotFound: невиконані очікування: %v", err)
-	}
 }
 
 func TestDeleteMood_Success(t *testing.T) {
-	mock, teardown := setupMoodTest(t)
-	defer teardown()
-
-	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM mood WHERE id=$1 AND user_id=$2")).
-		WithArgs("m1", "user-1").
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	h, moods := setupMoodTest(t)
+	seedMood(t, moods, "m1", "user-1", "2025-01-10", "🙂")
 
 	req := newRequest(http.MethodDelete, "/mood/m1", nil, "m1")
 	w := httptest.NewRecorder()
 
-	DeleteMood(w, req)
+	h.Delete(w, req)
 	if w.Code != http.StatusNoContent {
 		t.Errorf("TestDeleteMood_Success: очікував 204, отримав %d", w.Code)
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("TestDeleteMood_Success: невиконані очікування: %v", err)
+	if _, err := moods.Get(context.Background(), "user-1", "m1"); err != repository.ErrNotFound {
+		t.Errorf("TestDeleteMood_Success: запис не видалено: %v", err)
 	}
 }
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/notify"
+	"moodtracker/repository"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// NotificationHandler обслуговує /user/notifications: канали сповіщень і підписки Web Push
+type NotificationHandler struct {
+	Settings repository.NotificationRepository
+	PushSubs repository.PushSubscriptionRepository
+	Channels []string // канали, увімкнені на сервері
+	VAPIDKey string   // відкритий ключ Web Push; порожній – Web Push вимкнено
+	Auth     func(http.Handler) http.Handler
+}
+
+func NewNotificationHandler(repos repository.Set, channels []string, vapidKey string, authMW func(http.Handler) http.Handler) *NotificationHandler {
+	return &NotificationHandler{
+		Settings: repos.Notifications,
+		PushSubs: repos.PushSubs,
+		Channels: channels,
+		VAPIDKey: vapidKey,
+		Auth:     authMW,
+	}
+}
+
+func (h *NotificationHandler) Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.Auth)
+		r.Get("/", h.Get)
+		r.Put("/", h.Update)
+		r.Delete("/", h.Reset)
+		r.Get("/push/key", h.PushKey)
+		r.Post("/push", h.Subscribe)
+		r.Delete("/push/{id}", h.Unsubscribe)
+	})
+}
+
+// notificationsResponse – налаштування разом зі списком каналів, які підтримує сервер
+type notificationsResponse struct {
+	models.NotificationSettings
+	Available []string `json:"available"`
+}
+
+func (h *NotificationHandler) settings(r *http.Request, userID string) (*models.NotificationSettings, error) {
+	s, err := h.Settings.Get(r.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		def := models.DefaultNotificationSettings(userID)
+		return &def, nil
+	}
+	return s, err
+}
+
+func (h *NotificationHandler) write(w http.ResponseWriter, s *models.NotificationSettings) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notificationsResponse{NotificationSettings: *s, Available: h.Channels})
+}
+
+// Get повертає канали користувача, а якщо їх не змінювали – типові
+func (h *NotificationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	s, err := h.settings(r, userID)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	h.write(w, s)
+}
+
+// Update замінює налаштування; не вказані поля беруться з типових. Ключ підпису
+// webhook генерує сервер: він зберігається, доки не зміниться webhook_url.
+func (h *NotificationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	s := models.DefaultNotificationSettings(userID)
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	if err := s.Validate(); err != nil {
+		failErr(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	old, err := h.settings(r, userID)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	switch {
+	case s.WebhookURL == "":
+		s.WebhookSecret = ""
+	case s.WebhookURL == old.WebhookURL && old.WebhookSecret != "":
+		s.WebhookSecret = old.WebhookSecret
+	default:
+		if s.WebhookSecret, err = randomToken(32); err != nil {
+			failInternal(w, r, fmt.Errorf("generate webhook secret: %w", err))
+			return
+		}
+	}
+
+	if err := h.Settings.Save(r.Context(), &s); err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	h.write(w, &s)
+}
+
+// Reset повертає типові канали
+func (h *NotificationHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.Settings.Delete(r.Context(), userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		failInternal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PushKey повертає applicationServerKey для pushManager.subscribe
+func (h *NotificationHandler) PushKey(w http.ResponseWriter, r *http.Request) {
+	if h.VAPIDKey == "" {
+		fail(w, r, http.StatusServiceUnavailable, "web_push_not_configured")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"public_key": h.VAPIDKey})
+}
+
+// Subscribe зберігає підписку браузера у форматі PushSubscription.toJSON()
+func (h *NotificationHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	if h.VAPIDKey == "" {
+		fail(w, r, http.StatusServiceUnavailable, "web_push_not_configured")
+		return
+	}
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	var req struct {
+		Endpoint string `json:"endpoint"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	sub := models.PushSubscription{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		CreatedAt: time.Now(),
+	}
+	if err := notify.ValidateSubscription(sub); err != nil {
+		failErr(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.PushSubs.Save(r.Context(), &sub); err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// Unsubscribe видаляє підписку користувача
+func (h *NotificationHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.PushSubs.Delete(r.Context(), userID, chi.URLParam(r, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusNotFound, "subscription_not_found")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
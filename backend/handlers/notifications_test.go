@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"moodtracker/models"
+	"moodtracker/repository/memory"
+)
+
+func setupNotificationTest(vapidKey string) *NotificationHandler {
+	channels := []string{models.JobEmail, models.JobTelegram, models.JobWebhook, models.JobWebPush}
+	return NewNotificationHandler(memory.New(), channels, vapidKey, nil)
+}
+
+func getNotifications(t *testing.T, h *NotificationHandler) notificationsResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.Get(w, newRequest(http.MethodGet, "/user/notifications", nil, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	var resp notificationsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestNotifications_DefaultUpdateReset(t *testing.T) {
+	h := setupNotificationTest("")
+
+	resp := getNotifications(t, h)
+	if len(resp.Reminders) != 1 || resp.Reminders[0] != models.JobTelegram || len(resp.Available) != 4 {
+		t.Errorf("очікував типові канали, отримав %+v", resp)
+	}
+
+	body := `{"reminders":["email","webpush"],"reports":["webhook"],"webhook_url":"https://example.com/hook"}`
+	w := httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/user/notifications", []byte(body), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	resp = getNotifications(t, h)
+	secret := resp.WebhookSecret
+	if len(resp.Reminders) != 2 || resp.Reports[0] != models.JobWebhook || secret == "" {
+		t.Fatalf("налаштування не збережено: %+v", resp)
+	}
+
+	// ключ підпису генерує сервер і не змінює, поки не змінився URL
+	body = `{"reminders":["email"],"reports":["webhook"],"webhook_url":"https://example.com/hook","webhook_secret":"mine"}`
+	h.Update(httptest.NewRecorder(), newRequest(http.MethodPut, "/user/notifications", []byte(body), ""))
+	if got := getNotifications(t, h).WebhookSecret; got != secret {
+		t.Errorf("ключ мав лишитися %q, отримав %q", secret, got)
+	}
+	body = `{"reminders":["email"],"reports":["webhook"],"webhook_url":"https://example.com/other"}`
+	h.Update(httptest.NewRecorder(), newRequest(http.MethodPut, "/user/notifications", []byte(body), ""))
+	if got := getNotifications(t, h).WebhookSecret; got == secret || got == "" {
+		t.Errorf("для нового URL очікував новий ключ, отримав %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	h.Reset(w, newRequest(http.MethodDelete, "/user/notifications", nil, ""))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("очікував 204, отримав %d", w.Code)
+	}
+	if resp := getNotifications(t, h); resp.Reminders[0] != models.JobTelegram || resp.WebhookURL != "" {
+		t.Errorf("після скидання очікував типові канали, отримав %+v", resp)
+	}
+}
+
+func TestNotifications_UpdateInvalid(t *testing.T) {
+	h := setupNotificationTest("")
+
+	for _, body := range []string{
+		`{"reminders":["sms"]}`,
+		`{"reminders":["email","email"]}`,
+		`{"reports":["webhook"]}`,
+		`{"reports":["webhook"],"webhook_url":"http://example.com/hook"}`,
+		`not-json`,
+	} {
+		w := httptest.NewRecorder()
+		h.Update(w, newRequest(http.MethodPut, "/user/notifications", []byte(body), ""))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: очікував 400, отримав %d", body, w.Code)
+		}
+	}
+}
+
+func TestNotifications_Push(t *testing.T) {
+	h := setupNotificationTest("")
+	w := httptest.NewRecorder()
+	h.PushKey(w, newRequest(http.MethodGet, "/user/notifications/push/key", nil, ""))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("без VAPID очікував 503, отримав %d", w.Code)
+	}
+
+	h = setupNotificationTest("public-key")
+	w = httptest.NewRecorder()
+	h.PushKey(w, newRequest(http.MethodGet, "/user/notifications/push/key", nil, ""))
+	if w.Code != http.StatusOK || w.Body.String() != "{\"public_key\":\"public-key\"}\n" {
+		t.Errorf("неправильна відповідь: %d %s", w.Code, w.Body.String())
+	}
+
+	key, _ := ecdh.P256().GenerateKey(rand.Reader)
+	p256dh := base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes())
+	auth := base64.RawURLEncoding.EncodeToString([]byte("0123456789abcdef"))
+	body := fmt.Sprintf(`{"endpoint":"https://push.example.com/abc","keys":{"p256dh":%q,"auth":%q}}`, p256dh, auth)
+	w = httptest.NewRecorder()
+	h.Subscribe(w, newRequest(http.MethodPost, "/user/notifications/push", []byte(body), ""))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("очікував 201, отримав %d: %s", w.Code, w.Body.String())
+	}
+	var sub models.PushSubscription
+	json.Unmarshal(w.Body.Bytes(), &sub)
+
+	bad := fmt.Sprintf(`{"endpoint":"https://push.example.com/abc","keys":{"p256dh":"AAAA","auth":%q}}`, auth)
+	w = httptest.NewRecorder()
+	h.Subscribe(w, newRequest(http.MethodPost, "/user/notifications/push", []byte(bad), ""))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("очікував 400, отримав %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.Unsubscribe(w, newRequest(http.MethodDelete, "/user/notifications/push/"+sub.ID, nil, sub.ID))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("очікував 204, отримав %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.Unsubscribe(w, newRequest(http.MethodDelete, "/user/notifications/push/"+sub.ID, nil, sub.ID))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("очікував 404, отримав %d", w.Code)
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"moodtracker/auth"
+	"moodtracker/i18n"
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+// oidcStateCookie прив'язує state до браузера, який почав вхід: без нього зловмисник
+// міг би надіслати жертві посилання на callback зі своїм code і state (login CSRF)
+const oidcStateCookie = "oidc_state"
+
+var errUnverifiedEmail = i18n.NewError("unverified_email")
+
+// OIDCLogin зберігає state, nonce і PKCE verifier та перенаправляє на провайдера
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.OIDC == nil {
+		fail(w, r, http.StatusNotFound, "sso_not_configured")
+		return
+	}
+
+	state, err := randomToken(24)
+	if err != nil {
+		failInternal(w, r, fmt.Errorf("generate state: %w", err))
+		return
+	}
+	nonce, err := randomToken(24)
+	if err != nil {
+		failInternal(w, r, fmt.Errorf("generate nonce: %w", err))
+		return
+	}
+	verifier := auth.NewPKCEVerifier()
+
+	err = h.Identities.SaveState(r.Context(), &models.OIDCState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	// У cookie лише хеш state; Lax дозволяє надіслати його при поверненні від провайдера
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    hashSecret(state),
+		Path:     "/",
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, h.OIDC.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+// OIDCCallback обмінює code на ID-токен, прив'язує sub/email до users
+// і видає власні токени застосунку
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.OIDC == nil {
+		fail(w, r, http.StatusNotFound, "sso_not_configured")
+		return
+	}
+	q := r.URL.Query()
+	// Текст помилки від IdP приходить у URL, який може підробити будь-хто, тож
+	// клієнту – фіксоване повідомлення, а подробиці – лише в лог
+	if e := q.Get("error"); e != "" {
+		log.Printf("oidc provider error: %q: %q", e, q.Get("error_description"))
+		fail(w, r, http.StatusUnauthorized, "identity_provider_error")
+		return
+	}
+	state, code := q.Get("state"), q.Get("code")
+	if state == "" || code == "" {
+		fail(w, r, http.StatusBadRequest, "state_and_code_required")
+		return
+	}
+
+	// state має належати цьому браузеру; чужий state не споживаємо
+	c, err := r.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(c.Value), []byte(hashSecret(state))) != 1 {
+		fail(w, r, http.StatusUnauthorized, "invalid_state")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/", MaxAge: -1, HttpOnly: true, Secure: true, SameSite: http.SameSiteLaxMode})
+
+	// state одноразовий: ConsumeState видаляє його
+	st, err := h.Identities.ConsumeState(r.Context(), state, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusUnauthorized, "invalid_state")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	ident, err := h.OIDC.Exchange(r.Context(), code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		log.Printf("oidc exchange failed: %v", err)
+		fail(w, r, http.StatusUnauthorized, "sso_login_failed")
+		return
+	}
+
+	userID, err := h.linkIdentity(r.Context(), ident)
+	if errors.Is(err, errUnverifiedEmail) {
+		failErr(w, r, http.StatusForbidden, err)
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	pair, err := h.startSession(r.Context(), userID)
+	if err != nil {
+		failInternal(w, r, fmt.Errorf("issue tokens: %w", err))
+		return
+	}
+
+	// Для SPA повертаємо токени у fragment, який не потрапляє в логи серверів
+	if dest := os.Getenv("OIDC_POST_LOGIN_URL"); dest != "" {
+		frag := url.Values{}
+		frag.Set("token", pair.Token)
+		frag.Set("refresh_token", pair.RefreshToken)
+		frag.Set("expires_in", strconv.Itoa(pair.ExpiresIn))
+		http.Redirect(w, r, dest+"#"+frag.Encode(), http.StatusFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pair)
+}
+
+// linkIdentity знаходить користувача за (issuer, sub). Перший вхід прив'язується
+// до облікового запису з тим самим email лише якщо провайдер його підтвердив.
+func (h *AuthHandler) linkIdentity(ctx context.Context, id *auth.OIDCIdentity) (string, error) {
+	userID, err := h.Identities.FindUserID(ctx, id.Issuer, id.Subject)
+	if err == nil {
+		return userID, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return "", err
+	}
+
+	email := normalizeEmail(id.Email)
+	if email == "" || !id.EmailVerified {
+		return "", errUnverifiedEmail
+	}
+	userID, err = h.findOrCreateUser(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	err = h.Identities.Link(ctx, &models.Identity{
+		Issuer:  id.Issuer,
+		Subject: id.Subject,
+		UserID:  userID,
+		Email:   email,
+	})
+	if err != nil {
+		return "", fmt.Errorf("link identity: %w", err)
+	}
+	return userID, nil
+}
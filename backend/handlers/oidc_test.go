@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"moodtracker/auth"
+	"moodtracker/models"
+	"moodtracker/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP – мінімальний OpenID-провайдер: discovery, JWKS і token endpoint
+type mockIdP struct {
+	srv  *httptest.Server
+	keys *auth.KeyManager
+
+	challenge     string // code_challenge з запиту авторизації
+	nonce         string // nonce, який провайдер покладе в ID-токен
+	subject       string
+	email         string
+	emailVerified bool
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("не вдалося згенерувати ключ IdP: %v", err)
+	}
+	keys, err := auth.NewKeyManager([]*auth.Key{auth.NewRSAKey("idp-1", priv)}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{keys: keys, subject: "sub-42", email: "sso@example.com", emailVerified: true}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                idp.srv.URL,
+			"authorization_endpoint":                idp.srv.URL + "/authorize",
+			"token_endpoint":                        idp.srv.URL + "/token",
+			"jwks_uri":                              idp.srv.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(idp.keys.JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		idToken, _ := idp.keys.Sign(jwt.MapClaims{
+			"iss":            idp.srv.URL,
+			"sub":            idp.subject,
+			"aud":            "moodtracker",
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          idp.nonce,
+			"email":          idp.email,
+			"email_verified": idp.emailVerified,
+		})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "idp-access",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     idToken,
+		})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func setupOIDCTest(t *testing.T) (*AuthHandler, repository.Set, *mockIdP) {
+	h, repos, _ := setupAuthTest(t)
+	idp := newMockIdP(t)
+
+	provider, err := auth.NewOIDCProvider(context.Background(), auth.OIDCConfig{
+		Issuer:      idp.srv.URL,
+		ClientID:    "moodtracker",
+		RedirectURL: "http://localhost:8080/api/auth/oidc/callback",
+	})
+	if err != nil {
+		t.Fatalf("не вдалося налаштувати OIDC: %v", err)
+	}
+	h.OIDC = provider
+	return h, repos, idp
+}
+
+// startOIDCLogin виконує /oidc/login і повертає state з адреси перенаправлення
+func startOIDCLogin(t *testing.T, h *AuthHandler, idp *mockIdP) string {
+	w := httptest.NewRecorder()
+	h.OIDCLogin(w, httptest.NewRequest(http.MethodGet, "/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("очікував 302, отримав %d", w.Code)
+	}
+
+	loc, _ := url.Parse(w.Header().Get("Location"))
+	q := loc.Query()
+	if q.Get("state") == "" || q.Get("nonce") == "" || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("неправильний запит авторизації: %s", loc)
+	}
+	idp.challenge = q.Get("code_challenge")
+	idp.nonce = q.Get("nonce")
+	return q.Get("state")
+}
+
+// callback повертається на /oidc/callback з того самого браузера, що почав вхід
+func callback(h *AuthHandler, state, code string) *httptest.ResponseRecorder {
+	return callbackWithCookie(h, state, code, hashSecret(state))
+}
+
+func callbackWithCookie(h *AuthHandler, state, code, cookie string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/oidc/callback?state="+state+"&code="+code, nil)
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: cookie})
+	}
+	h.OIDCCallback(w, req)
+	return w
+}
+
+func TestOIDC_StateCookie(t *testing.T) {
+	h, repos, idp := setupOIDCTest(t)
+
+	w := httptest.NewRecorder()
+	h.OIDCLogin(w, httptest.NewRequest(http.MethodGet, "/oidc/login", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != oidcStateCookie || !cookies[0].HttpOnly || !cookies[0].Secure ||
+		cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("неправильна cookie state: %+v", cookies)
+	}
+
+	// жертва відкриває callback зі state зловмисника: у її браузері немає cookie або вона від іншого входу
+	state := startOIDCLogin(t, h, idp)
+	for _, cookie := range []string{"", cookies[0].Value} {
+		decodeProblem(t, callbackWithCookie(h, state, "good-code", cookie), http.StatusUnauthorized, "invalid_state")
+	}
+	// чужий state не споживається
+	if _, err := repos.Identities.ConsumeState(context.Background(), state, time.Now()); err != nil {
+		t.Errorf("state мав лишитися невикористаним: %v", err)
+	}
+}
+
+func TestOIDC_PruneStates(t *testing.T) {
+	h, repos, idp := setupOIDCTest(t)
+	state := startOIDCLogin(t, h, idp)
+
+	if err := h.Prune(context.Background(), time.Now().Add(oidcStateTTL+time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Identities.ConsumeState(context.Background(), state, time.Now()); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("прострочений state мав бути видалений: %v", err)
+	}
+}
+
+func TestOIDC_NotConfigured(t *testing.T) {
+	h, _, _ := setupAuthTest(t)
+
+	w := httptest.NewRecorder()
+	h.OIDCLogin(w, httptest.NewRequest(http.MethodGet, "/oidc/login", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("очікував 404, отримав %d", w.Code)
+	}
+}
+
+func TestOIDC_LinksVerifiedEmailToExistingUser(t *testing.T) {
+	h, repos, idp := setupOIDCTest(t)
+	ctx := context.Background()
+	if err := repos.Users.Create(ctx, &models.User{ID: "user-1", Email: "sso@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	state := startOIDCLogin(t, h, idp)
+	w := callback(h, state, "good-code")
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	var resp tokenPair
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("очікував пару токенів застосунку, отримав %s", w.Body.String())
+	}
+	if got := userFromToken(t, h, resp.Token); got != "user-1" {
+		t.Errorf("очікував токен для user-1, отримав %q", got)
+	}
+	if userID, err := repos.Identities.FindUserID(ctx, idp.srv.URL, "sub-42"); err != nil || userID != "user-1" {
+		t.Errorf("обліковий запис провайдера не прив'язано: %q, %v", userID, err)
+	}
+}
+
+func TestOIDC_KnownIdentity(t *testing.T) {
+	h, repos, idp := setupOIDCTest(t)
+	// email у провайдера змінився, але прив'язка йде за sub
+	idp.email = "renamed@example.com"
+	err := repos.Identities.Link(context.Background(), &models.Identity{
+		Issuer: idp.srv.URL, Subject: "sub-42", UserID: "user-7", Email: "old@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := startOIDCLogin(t, h, idp)
+	w := callback(h, state, "good-code")
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	var resp tokenPair
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if got := userFromToken(t, h, resp.Token); got != "user-7" {
+		t.Errorf("очікував токен для user-7, отримав %q", got)
+	}
+}
+
+func TestOIDC_RedirectsWithTokensInFragment(t *testing.T) {
+	h, _, idp := setupOIDCTest(t)
+	t.Setenv("OIDC_POST_LOGIN_URL", "http://localhost:5173/login/callback")
+
+	state := startOIDCLogin(t, h, idp)
+	w := callback(h, state, "good-code")
+	if w.Code != http.StatusFound {
+		t.Fatalf("очікував 302, отримав %d", w.Code)
+	}
+	loc, _ := url.Parse(w.Header().Get("Location"))
+	frag, _ := url.ParseQuery(loc.Fragment)
+	if loc.Path != "/login/callback" || frag.Get("token") == "" || frag.Get("refresh_token") == "" || loc.RawQuery != "" {
+		t.Errorf("токени мають бути лише у fragment: %s", loc)
+	}
+}
+
+func TestOIDC_StateIsSingleUse(t *testing.T) {
+	h, _, idp := setupOIDCTest(t)
+
+	state := startOIDCLogin(t, h, idp)
+	if w := callback(h, state, "good-code"); w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	if w := callback(h, state, "good-code"); w.Code != http.StatusUnauthorized {
+		t.Errorf("повторний state: очікував 401, отримав %d", w.Code)
+	}
+}
+
+func TestOIDC_RejectsNonceMismatch(t *testing.T) {
+	h, _, idp := setupOIDCTest(t)
+
+	state := startOIDCLogin(t, h, idp)
+	idp.nonce = "replayed-nonce"
+
+	w := callback(h, state, "good-code")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+}
+
+func TestOIDC_RejectsWrongPKCEVerifier(t *testing.T) {
+	h, repos, idp := setupOIDCTest(t)
+	ctx := context.Background()
+
+	state := startOIDCLogin(t, h, idp)
+	// підміняємо verifier, збережений разом зі state
+	st, err := repos.Identities.ConsumeState(ctx, state, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.CodeVerifier = "someone-elses-verifier-000000000000000000000"
+	if err := repos.Identities.SaveState(ctx, st); err != nil {
+		t.Fatal(err)
+	}
+
+	w := callback(h, state, "good-code")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+}
+
+func TestOIDC_UnverifiedEmail(t *testing.T) {
+	h, repos, idp := setupOIDCTest(t)
+	idp.emailVerified = false
+
+	state := startOIDCLogin(t, h, idp)
+	w := callback(h, state, "good-code")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("очікував 403, отримав %d", w.Code)
+	}
+	if _, err := repos.Users.GetByEmail(context.Background(), "sso@example.com"); err == nil {
+		t.Error("користувача з непідтвердженим email не мали створювати")
+	}
+}
+
+func TestOIDC_UnknownState(t *testing.T) {
+	h, _, _ := setupOIDCTest(t)
+
+	w := callback(h, "forged", "good-code")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+}
+
+func TestOIDC_ProviderErrorNotEchoed(t *testing.T) {
+	h, _, _ := setupOIDCTest(t)
+
+	// error з URL може підробити будь-хто – у відповіді лише фіксований текст
+	w := httptest.NewRecorder()
+	q := url.Values{"error": {"Зайдіть на evil.example і введіть пароль"}, "error_description": {"phishing"}}
+	h.OIDCCallback(w, httptest.NewRequest(http.MethodGet, "/oidc/callback?"+q.Encode(), nil))
+	p := decodeProblem(t, w, http.StatusUnauthorized, "identity_provider_error")
+	if strings.Contains(w.Body.String(), "evil.example") || strings.Contains(w.Body.String(), "phishing") || p.Detail == "" {
+		t.Errorf("detail не має містити текст від IdP: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"moodtracker/repository"
+)
+
+// Розмір сторінки списків: за замовчуванням і найбільший, який можна запросити через limit
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// page – конверт відповіді зі списком: елементи сторінки і курсор наступної.
+// Той самий курсор іде в заголовку Link з rel="next".
+type page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"` // порожній на останній сторінці
+}
+
+var errBadCursor = errors.New("bad cursor")
+
+// cursor – вміст курсора до кодування; клієнт бачить лише непрозорий рядок
+type cursor struct {
+	Date string `json:"d"`
+	ID   string `json:"i"`
+}
+
+// encodeCursor пакує ключ останнього запису сторінки в рядок для параметра cursor
+func encodeCursor(k repository.MoodKey) string {
+	b, _ := json.Marshal(cursor{Date: k.Date.Format("2006-01-02"), ID: k.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor – обернене до encodeCursor; errBadCursor, якщо рядок не від нас
+func decodeCursor(s string) (*repository.MoodKey, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return nil, errBadCursor
+	}
+	d, err := time.Parse("2006-01-02", c.Date)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	return &repository.MoodKey{Date: d, ID: c.ID}, nil
+}
+
+// pageSize розбирає параметр limit: 1..maxPageSize, за замовчуванням defaultPageSize
+func pageSize(v string) (int, bool) {
+	if v == "" {
+		return defaultPageSize, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxPageSize {
+		return 0, false
+	}
+	return n, true
+}
+
+// setNextLink додає заголовок Link на наступну сторінку: той самий запит з параметром cursor
+func setNextLink(w http.ResponseWriter, r *http.Request, next string) {
+	u := *r.URL
+	q := u.Query()
+	q.Set("cursor", next)
+	u.RawQuery = q.Encode()
+	u.Scheme, u.Host = "", ""
+	w.Header().Add("Link", "<"+u.String()+`>; rel="next"`)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ReminderHandler обслуговує /user/reminders
+type ReminderHandler struct {
+	Reminders repository.ReminderRepository
+	Auth      func(http.Handler) http.Handler
+}
+
+func NewReminderHandler(reminders repository.ReminderRepository, authMW func(http.Handler) http.Handler) *ReminderHandler {
+	return &ReminderHandler{Reminders: reminders, Auth: authMW}
+}
+
+func (h *ReminderHandler) Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.Auth)
+		r.Get("/", h.Get)
+		r.Put("/", h.Update)
+		r.Delete("/", h.Reset)
+	})
+}
+
+// Get повертає розклад користувача, а якщо його не змінювали – типовий
+func (h *ReminderHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	rs, err := h.Reminders.Get(r.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		def := models.DefaultReminderSettings(userID)
+		rs = &def
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rs)
+}
+
+// Update замінює розклад повністю; не вказані поля беруться з типового
+func (h *ReminderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	rs := models.DefaultReminderSettings(userID)
+	if err := json.NewDecoder(r.Body).Decode(&rs); err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	if err := rs.Validate(); err != nil {
+		failErr(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.Reminders.Save(r.Context(), &rs); err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rs)
+}
+
+// Reset повертає типовий розклад
+func (h *ReminderHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.Reminders.Delete(r.Context(), userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		failInternal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"moodtracker/models"
+	"moodtracker/repository/memory"
+)
+
+func setupReminderTest() *ReminderHandler {
+	return NewReminderHandler(memory.New().Reminders, nil)
+}
+
+func TestReminders_Default(t *testing.T) {
+	h := setupReminderTest()
+
+	w := httptest.NewRecorder()
+	h.Get(w, newRequest(http.MethodGet, "/user/reminders", nil, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	var rs models.ReminderSettings
+	json.Unmarshal(w.Body.Bytes(), &rs)
+	if len(rs.Times) != 1 || rs.Times[0] != "20:00" || len(rs.Weekdays) != 7 || rs.ReportFrequency != models.ReportWeekly {
+		t.Errorf("очікував типовий розклад, отримав %+v", rs)
+	}
+}
+
+func TestReminders_UpdateAndReset(t *testing.T) {
+	h := setupReminderTest()
+
+	body := `{"times":["21:00","08:30"],"weekdays":[5,1],"quiet_start":"23:00","quiet_end":"07:00","report_frequency":"monthly"}`
+	w := httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/user/reminders", []byte(body), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.Get(w, newRequest(http.MethodGet, "/user/reminders", nil, ""))
+	var rs models.ReminderSettings
+	json.Unmarshal(w.Body.Bytes(), &rs)
+	// час і дні зберігаються впорядкованими
+	if len(rs.Times) != 2 || rs.Times[0] != "08:30" || rs.Weekdays[0] != 1 || rs.QuietStart != "23:00" || rs.ReportFrequency != models.ReportMonthly {
+		t.Errorf("налаштування не збережено: %+v", rs)
+	}
+
+	w = httptest.NewRecorder()
+	h.Reset(w, newRequest(http.MethodDelete, "/user/reminders", nil, ""))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("очікував 204, отримав %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.Get(w, newRequest(http.MethodGet, "/user/reminders", nil, ""))
+	rs = models.ReminderSettings{}
+	json.Unmarshal(w.Body.Bytes(), &rs)
+	if rs.ReportFrequency != models.ReportWeekly || rs.QuietStart != "" {
+		t.Errorf("після скидання очікував типовий розклад, отримав %+v", rs)
+	}
+}
+
+func TestReminders_UpdateNormalizes(t *testing.T) {
+	h := setupReminderTest()
+
+	body := `{"times":["21:00","9:05"],"weekdays":[],"quiet_start":"7:00","quiet_end":"22:00"}`
+	w := httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/user/reminders", []byte(body), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	var rs models.ReminderSettings
+	json.Unmarshal(w.Body.Bytes(), &rs)
+	// час зберігається у формі HH:MM, а порожній список днів означає "щодня"
+	if len(rs.Times) != 2 || rs.Times[0] != "09:05" || rs.QuietStart != "07:00" || len(rs.Weekdays) != 7 {
+		t.Errorf("налаштування не нормалізовано: %+v", rs)
+	}
+
+	// "9:00" і "09:00" – той самий час
+	w = httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/user/reminders", []byte(`{"times":["9:00","09:00"]}`), ""))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "duplicate_reminder_time") {
+		t.Errorf("очікував 400 duplicate_reminder_time, отримав %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReminders_UpdateInvalid(t *testing.T) {
+	h := setupReminderTest()
+
+	for _, body := range []string{
+		`{"times":["25:00"]}`,
+		`{"times":["08:00","08:00"]}`,
+		`{"times":["01:00","02:00","03:00","04:00","05:00","06:00","07:00"]}`,
+		`{"weekdays":[7]}`,
+		`{"weekdays":[1,1]}`,
+		`{"quiet_start":"22:00"}`,
+		`{"quiet_start":"22:00","quiet_end":"22:00"}`,
+		`{"report_frequency":"daily"}`,
+		`not-json`,
+	} {
+		w := httptest.NewRecorder()
+		h.Update(w, newRequest(http.MethodPut, "/user/reminders", []byte(body), ""))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: очікував 400, отримав %d", body, w.Code)
+		}
+	}
+}
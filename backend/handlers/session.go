@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// sessionRetention – скільки ще зберігаються прострочені й відкликані токени; ротовані
+	// лишаються до кінця свого строку, щоб їх повторне пред'явлення виявлялося
+	sessionRetention = 24 * time.Hour
+)
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // секунд до закінчення access-токена
+}
+
+// startSession створює нову сім'ю refresh-токенів (новий пристрій) і видає пару токенів
+func (h *AuthHandler) startSession(ctx context.Context, userID string) (tokenPair, error) {
+	return h.issueTokenPair(ctx, userID, uuid.NewString())
+}
+
+// issueTokenPair зберігає хеш нового refresh-токена в сім'ї familyID і підписує access-токен
+func (h *AuthHandler) issueTokenPair(ctx context.Context, userID, familyID string) (tokenPair, error) {
+	refresh, err := randomToken(32)
+	if err != nil {
+		return tokenPair{}, err
+	}
+	err = h.Sessions.Create(ctx, &models.Session{
+		ID:        uuid.NewString(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: hashSecret(refresh),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return tokenPair{}, err
+	}
+	access, err := h.issueToken(userID, familyID)
+	if err != nil {
+		return tokenPair{}, err
+	}
+	return tokenPair{Token: access, RefreshToken: refresh, ExpiresIn: int(accessTokenTTL.Seconds())}, nil
+}
+
+// issueToken підписує короткоживучий access-токен, прив'язаний до сесії sid
+func (h *AuthHandler) issueToken(userID, sessionID string) (string, error) {
+	return h.Keys.Sign(jwt.MapClaims{
+		"user_id": userID,
+		"sid":     sessionID,
+		"exp":     time.Now().Add(accessTokenTTL).Unix(),
+	})
+}
+
+// randomToken повертає n випадкових байтів у base64url – для refresh-токенів, state і nonce
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Refresh – ротує refresh-токен. Повторне пред'явлення вже ротованого токена
+// означає, що його вкрали: відкликаємо всю сім'ю.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	if req.RefreshToken == "" {
+		fail(w, r, http.StatusBadRequest, "refresh_token_required")
+		return
+	}
+
+	s, err := h.Sessions.GetByTokenHash(r.Context(), hashSecret(req.RefreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusUnauthorized, "invalid_refresh_token")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	if s.RevokedAt != nil || now.After(s.ExpiresAt) {
+		fail(w, r, http.StatusUnauthorized, "invalid_refresh_token")
+		return
+	}
+	if s.RotatedAt != nil {
+		h.revokeFamily(w, r, s.FamilyID)
+		return
+	}
+
+	// Умовне оновлення: з двох паралельних ротацій виграє лише одна
+	rotated, err := h.Sessions.MarkRotated(r.Context(), s.ID, now)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	if !rotated {
+		h.revokeFamily(w, r, s.FamilyID)
+		return
+	}
+
+	pair, err := h.issueTokenPair(r.Context(), s.UserID, s.FamilyID)
+	if err != nil {
+		failInternal(w, r, fmt.Errorf("issue tokens: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pair)
+}
+
+// revokeFamily відкликає всі токени сесії після виявлення повторного використання
+func (h *AuthHandler) revokeFamily(w http.ResponseWriter, r *http.Request, familyID string) {
+	if err := h.Sessions.RevokeFamily(r.Context(), familyID, time.Now()); err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	fail(w, r, http.StatusUnauthorized, "refresh_token_reused")
+}
+
+// Logout відкликає поточну сесію (пристрій)
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Context().Value(middleware.SessionIDKey).(string)
+	if err := h.Sessions.RevokeFamily(r.Context(), sessionID, time.Now()); err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll відкликає всі сесії користувача – "вийти на всіх пристроях"
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	if err := h.Sessions.RevokeUser(r.Context(), userID, time.Now()); err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
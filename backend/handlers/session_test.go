@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+func doRefresh(h *AuthHandler, token string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"refresh_token": token})
+	req := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	h.Refresh(w, req)
+	return w
+}
+
+// seedSession зберігає refresh-токен token у сім'ї familyID користувача user-1
+func seedSession(t *testing.T, repos repository.Set, id, familyID, token string, edit func(*models.Session)) {
+	s := &models.Session{
+		ID:        id,
+		FamilyID:  familyID,
+		UserID:    "user-1",
+		TokenHash: hashSecret(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if edit != nil {
+		edit(s)
+	}
+	if err := repos.Sessions.Create(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func isActive(t *testing.T, repos repository.Set, familyID, userID string) bool {
+	active, err := repos.Sessions.IsActive(context.Background(), familyID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return active
+}
+
+// lateRotation імітує паралельний запит, що ротував токен між читанням і оновленням
+type lateRotation struct{ repository.SessionRepository }
+
+func (lateRotation) MarkRotated(context.Context, string, time.Time) (bool, error) { return false, nil }
+
+func TestRefreshHandler_MissingToken(t *testing.T) {
+	h, _, _ := setupAuthTest(t)
+
+	w := doRefresh(h, "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("очікував 400, отримав %d", w.Code)
+	}
+}
+
+func TestRefreshHandler_UnknownToken(t *testing.T) {
+	h, _, _ := setupAuthTest(t)
+
+	w := doRefresh(h, "nope")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+}
+
+func TestRefreshHandler_Rotates(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedSession(t, repos, "s-1", "fam-1", "rt-1", nil)
+
+	w := doRefresh(h, "rt-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	var resp tokenPair
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("не вдалося розпарсити JSON: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.RefreshToken == "rt-1" {
+		t.Fatalf("очікував нову пару токенів, отримав %+v", resp)
+	}
+
+	ctx := context.Background()
+	old, _ := repos.Sessions.GetByTokenHash(ctx, hashSecret("rt-1"))
+	if old.RotatedAt == nil {
+		t.Error("старий токен мав бути позначений ротованим")
+	}
+	// новий токен потрапляє в ту саму сім'ю
+	next, err := repos.Sessions.GetByTokenHash(ctx, hashSecret(resp.RefreshToken))
+	if err != nil || next.FamilyID != "fam-1" || next.UserID != "user-1" {
+		t.Errorf("новий токен не в сім'ї fam-1: %+v, %v", next, err)
+	}
+}
+
+func TestRefreshHandler_ReuseRevokesFamily(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	// токен уже ротовано – хтось пред'являє його вдруге
+	rotated := time.Now().Add(-time.Minute)
+	seedSession(t, repos, "s-1", "fam-1", "rt-old", func(s *models.Session) { s.RotatedAt = &rotated })
+	seedSession(t, repos, "s-2", "fam-1", "rt-new", nil)
+
+	w := doRefresh(h, "rt-old")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+	if isActive(t, repos, "fam-1", "user-1") {
+		t.Error("сім'я токенів мала бути відкликана")
+	}
+	if w := doRefresh(h, "rt-new"); w.Code != http.StatusUnauthorized {
+		t.Errorf("актуальний токен сім'ї теж має бути відкликаний, отримав %d", w.Code)
+	}
+}
+
+func TestRefreshHandler_ConcurrentRotation(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedSession(t, repos, "s-1", "fam-1", "rt-1", nil)
+	h.Sessions = lateRotation{h.Sessions}
+
+	w := doRefresh(h, "rt-1")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+	if isActive(t, repos, "fam-1", "user-1") {
+		t.Error("сім'я токенів мала бути відкликана")
+	}
+}
+
+func TestRefreshHandler_Expired(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedSession(t, repos, "s-1", "fam-1", "rt-1", func(s *models.Session) {
+		s.ExpiresAt = time.Now().Add(-time.Hour)
+	})
+
+	w := doRefresh(h, "rt-1")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+}
+
+func TestRefreshHandler_Revoked(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	revoked := time.Now().Add(-time.Minute)
+	seedSession(t, repos, "s-1", "fam-1", "rt-1", func(s *models.Session) { s.RevokedAt = &revoked })
+
+	w := doRefresh(h, "rt-1")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("очікував 401, отримав %d", w.Code)
+	}
+}
+
+func TestSessions_Prune(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	now := time.Now()
+	old := now.Add(-sessionRetention - time.Hour)
+	seedSession(t, repos, "s-expired", "fam-1", "rt-expired", func(s *models.Session) { s.ExpiresAt = old })
+	seedSession(t, repos, "s-revoked", "fam-2", "rt-revoked", func(s *models.Session) { s.RevokedAt = &old })
+	// ротований, але ще чинний токен і актуальний токен тієї самої сім'ї
+	seedSession(t, repos, "s-rotated", "fam-3", "rt-rotated", func(s *models.Session) { s.RotatedAt = &old })
+	seedSession(t, repos, "s-current", "fam-3", "rt-current", nil)
+
+	if err := h.Prune(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{"rt-expired", "rt-revoked"} {
+		if _, err := repos.Sessions.GetByTokenHash(context.Background(), hashSecret(token)); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("%s мав бути видалений: %v", token, err)
+		}
+	}
+	if !isActive(t, repos, "fam-3", "user-1") {
+		t.Fatal("чинна сесія мала лишитися")
+	}
+	// повторне пред'явлення ротованого токена досі відкликає сім'ю
+	if w := doRefresh(h, "rt-rotated"); w.Code != http.StatusUnauthorized || isActive(t, repos, "fam-3", "user-1") {
+		t.Errorf("після Prune повтор має відкликати сім'ю: %d", w.Code)
+	}
+}
+
+func authedRequest(method, url, userID, sessionID string) *http.Request {
+	req := httptest.NewRequest(method, url, nil)
+	ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
+	ctx = context.WithValue(ctx, middleware.SessionIDKey, sessionID)
+	return req.WithContext(ctx)
+}
+
+func TestLogoutHandler_RevokesCurrentSession(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedSession(t, repos, "s-1", "fam-1", "rt-1", nil)
+	seedSession(t, repos, "s-2", "fam-2", "rt-2", nil)
+
+	w := httptest.NewRecorder()
+	h.Logout(w, authedRequest(http.MethodPost, "/logout", "user-1", "fam-1"))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("очікував 204, отримав %d", w.Code)
+	}
+	if isActive(t, repos, "fam-1", "user-1") {
+		t.Error("поточна сесія мала бути відкликана")
+	}
+	if !isActive(t, repos, "fam-2", "user-1") {
+		t.Error("інші пристрої не мали постраждати")
+	}
+}
+
+func TestLogoutAllHandler_RevokesAllSessions(t *testing.T) {
+	h, repos, _ := setupAuthTest(t)
+	seedSession(t, repos, "s-1", "fam-1", "rt-1", nil)
+	seedSession(t, repos, "s-2", "fam-2", "rt-2", nil)
+	seedSession(t, repos, "s-3", "fam-3", "rt-3", func(s *models.Session) { s.UserID = "user-2" })
+
+	w := httptest.NewRecorder()
+	h.LogoutAll(w, authedRequest(http.MethodPost, "/logout-all", "user-1", "fam-1"))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("очікував 204, отримав %d", w.Code)
+	}
+	if isActive(t, repos, "fam-1", "user-1") || isActive(t, repos, "fam-2", "user-1") {
+		t.Error("усі сесії user-1 мали бути відкликані")
+	}
+	if !isActive(t, repos, "fam-3", "user-2") {
+		t.Error("сесії інших користувачів не мали постраждати")
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"moodtracker/i18n"
+	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SettingsHandler обслуговує /user/settings
+type SettingsHandler struct {
+	Users repository.UserRepository
+	Auth  func(http.Handler) http.Handler
+}
+
+func NewSettingsHandler(users repository.UserRepository, authMW func(http.Handler) http.Handler) *SettingsHandler {
+	return &SettingsHandler{Users: users, Auth: authMW}
+}
+
+func (h *SettingsHandler) Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.Auth)
+		r.Get("/", h.Get)
+		r.Put("/", h.Update)
+	})
+}
+
+func (h *SettingsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	s, err := h.Users.GetSettings(r.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusNotFound, "not_found")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// validTimezone перевіряє IANA-пояс. "" і "Local" time.LoadLocation приймає,
+// але це пояс сервера, а не користувача
+func validTimezone(tz string) bool {
+	if tz == "" || tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+// Update замінює налаштування; не вказані поля лишаються без змін
+func (h *SettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	s, err := h.Users.GetSettings(r.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusNotFound, "not_found")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(s); err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	if s.EntryMode != models.EntryModeSingle && s.EntryMode != models.EntryModeMultiple {
+		fail(w, r, http.StatusBadRequest, "invalid_entry_mode")
+		return
+	}
+	if !validTimezone(s.Timezone) {
+		fail(w, r, http.StatusBadRequest, "invalid_timezone")
+		return
+	}
+	lang, ok := i18n.Parse(string(s.Locale))
+	if !ok {
+		fail(w, r, http.StatusBadRequest, "invalid_locale", i18n.Supported())
+		return
+	}
+	s.Locale = lang
+
+	if err := h.Users.UpdateSettings(r.Context(), userID, *s); err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
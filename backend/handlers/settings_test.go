@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"moodtracker/i18n"
+	"moodtracker/models"
+	"moodtracker/repository/memory"
+)
+
+func setupSettingsTest(t *testing.T) *SettingsHandler {
+	repos := memory.New()
+	if err := repos.Users.Create(context.Background(), &models.User{ID: "user-1", Email: "u1@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	return NewSettingsHandler(repos.Users, nil)
+}
+
+func TestSettings_DefaultSingle(t *testing.T) {
+	h := setupSettingsTest(t)
+
+	w := httptest.NewRecorder()
+	h.Get(w, newRequest(http.MethodGet, "/user/settings", nil, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	var s models.UserSettings
+	json.Unmarshal(w.Body.Bytes(), &s)
+	if s.EntryMode != models.EntryModeSingle || s.Timezone != "UTC" || s.Locale != i18n.UK {
+		t.Errorf("очікував single, UTC і uk за замовчуванням, отримав %+v", s)
+	}
+}
+
+func TestSettings_Update(t *testing.T) {
+	h := setupSettingsTest(t)
+
+	w := httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/user/settings", []byte(`{"entry_mode":"multiple"}`), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	// не вказані поля лишаються без змін
+	w = httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/user/settings", []byte(`{"timezone":"Europe/Kyiv"}`), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", w.Code)
+	}
+	s, err := h.Users.GetSettings(context.Background(), "user-1")
+	if err != nil || s.EntryMode != models.EntryModeMultiple || s.Timezone != "Europe/Kyiv" {
+		t.Errorf("налаштування не збережено: %+v, %v", s, err)
+	}
+
+	// мовний тег зводиться до підтримуваної мови
+	w = httptest.NewRecorder()
+	h.Update(w, newRequest(http.MethodPut, "/user/settings", []byte(`{"locale":"en-US"}`), ""))
+	if s, _ := h.Users.GetSettings(context.Background(), "user-1"); w.Code != http.StatusOK || s.Locale != i18n.EN {
+		t.Errorf("очікував locale en, отримав %d %+v", w.Code, s)
+	}
+}
+
+func TestSettings_LocalizedErrors(t *testing.T) {
+	h := setupSettingsTest(t)
+
+	for lang, want := range map[string]string{
+		"":               "entry_mode має бути \"single\" або \"multiple\"",
+		"en-US,en;q=0.9": "entry_mode must be \"single\" or \"multiple\"",
+	} {
+		req := newRequest(http.MethodPut, "/user/settings", []byte(`{"entry_mode":"sometimes"}`), "")
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		h.Update(w, req)
+		if p := decodeProblem(t, w, http.StatusBadRequest, "invalid_entry_mode"); p.Detail != want {
+			t.Errorf("%q: очікував %q, отримав %q", lang, want, p.Detail)
+		}
+	}
+}
+
+func TestSettings_UpdateInvalid(t *testing.T) {
+	h := setupSettingsTest(t)
+
+	for _, body := range []string{`{"entry_mode":"sometimes"}`, `{"timezone":"Mars/Olympus"}`, `{"timezone":"Local"}`, `{"timezone":""}`, `{"locale":"de"}`, `not-json`} {
+		w := httptest.NewRecorder()
+		h.Update(w, newRequest(http.MethodPut, "/user/settings", []byte(body), ""))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: очікував 400, отримав %d", body, w.Code)
+		}
+	}
+	s, _ := h.Users.GetSettings(context.Background(), "user-1")
+	if s.EntryMode != models.EntryModeSingle || s.Timezone != "UTC" {
+		t.Errorf("некоректний запит змінив налаштування: %+v", s)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"moodtracker/middleware"
+	"moodtracker/repository"
+	"moodtracker/streaks"
+)
+
+type streaksResp struct {
+	streaks.Streaks
+	Freezes []string `json:"freezes"`
+}
+
+// GetStreaks повертає поточну й найдовшу серії. "Сьогодні" визначає пояс користувача,
+// а параметр tz (IANA) дозволяє його перевизначити.
+func (h *MoodHandler) GetStreaks(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	settings, err := h.Users.GetSettings(r.Context(), userID)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	if q := r.URL.Query(); q.Has("tz") {
+		tz := q.Get("tz")
+		if !validTimezone(tz) {
+			fail(w, r, http.StatusBadRequest, "invalid_timezone")
+			return
+		}
+		settings.Timezone = tz
+	}
+	today := settings.Today(time.Now())
+
+	st, err := h.Streaks.Get(r.Context(), userID, today)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	frozen, err := h.Freezes.List(r.Context(), userID)
+	if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	resp := streaksResp{Streaks: st, Freezes: []string{}}
+	for _, d := range frozen {
+		resp.Freezes = append(resp.Freezes, d.Format("2006-01-02"))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AddFreeze заморожує день: його пропуск не обірве серію
+func (h *MoodHandler) AddFreeze(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Date string `json:"date"` // формат "YYYY-MM-DD"
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	d, err := time.Parse("2006-01-02", in.Date)
+	if err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_date")
+		return
+	}
+
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	if err := h.Freezes.Add(r.Context(), userID, d); errors.Is(err, repository.ErrConflict) {
+		fail(w, r, http.StatusConflict, "day_already_frozen")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MoodHandler) RemoveFreeze(w http.ResponseWriter, r *http.Request) {
+	d, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		fail(w, r, http.StatusBadRequest, "invalid_date")
+		return
+	}
+
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	err = h.Freezes.Remove(r.Context(), userID, d)
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusNotFound, "not_found")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
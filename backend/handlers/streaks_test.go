@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"moodtracker/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestGetStreaks(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	// UTC+14: там уже "завтра" для більшості серверів
+	today := models.UserSettings{Timezone: "Pacific/Kiritimati"}.Today(time.Now())
+	for i := 1; i <= 3; i++ {
+		d := today.AddDate(0, 0, -i).Format("2006-01-02")
+		seedMood(t, moods, "m"+d, "user-1", d, "😊")
+	}
+	if err := h.Freezes.Add(context.Background(), "user-1", today.AddDate(0, 0, -4)); err != nil {
+		t.Fatal(err)
+	}
+	seedMood(t, moods, "old", "user-1", today.AddDate(0, 0, -5).Format("2006-01-02"), "😊")
+
+	w := httptest.NewRecorder()
+	h.GetStreaks(w, newRequest(http.MethodGet, "/mood/streaks?tz=Pacific/Kiritimati", nil, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Current     int      `json:"current"`
+		Longest     int      `json:"longest"`
+		TodayLogged bool     `json:"today_logged"`
+		Freezes     []string `json:"freezes"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Current != 4 || resp.Longest != 4 || resp.TodayLogged || len(resp.Freezes) != 1 {
+		t.Errorf("неправильні серії: %s", w.Body.String())
+	}
+}
+
+func TestGetStreaks_UserTimezone(t *testing.T) {
+	h, moods := setupMoodTest(t)
+	settings := models.UserSettings{EntryMode: models.EntryModeSingle, Timezone: "Pacific/Kiritimati"}
+	if err := h.Users.UpdateSettings(context.Background(), "user-1", settings); err != nil {
+		t.Fatal(err)
+	}
+	// запис на "сьогодні" за Кірітіматі, навіть якщо на сервері ще вчора
+	today := settings.Today(time.Now())
+	seedMood(t, moods, "m1", "user-1", today.Format("2006-01-02"), "😊")
+
+	w := httptest.NewRecorder()
+	h.GetStreaks(w, newRequest(http.MethodGet, "/mood/streaks", nil, ""))
+	var resp struct {
+		Current     int  `json:"current"`
+		TodayLogged bool `json:"today_logged"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Current != 1 || !resp.TodayLogged {
+		t.Errorf("очікував сьогоднішній запис у поясі користувача: %s", w.Body.String())
+	}
+}
+
+func TestGetStreaks_BadTZ(t *testing.T) {
+	h, _ := setupMoodTest(t)
+
+	// "Local" і порожній tz – пояс сервера, їх відхиляють так само, як у налаштуваннях
+	for _, tz := range []string{"Mars/Olympus", "Local", ""} {
+		w := httptest.NewRecorder()
+		h.GetStreaks(w, newRequest(http.MethodGet, "/mood/streaks?tz="+tz, nil, ""))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"code":"invalid_timezone"`) {
+			t.Errorf("tz=%q: очікував 400 invalid_timezone, отримав %d: %s", tz, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestFreezes(t *testing.T) {
+	h, _ := setupMoodTest(t)
+
+	add := func(body string) int {
+		w := httptest.NewRecorder()
+		h.AddFreeze(w, newRequest(http.MethodPost, "/mood/streaks/freezes", []byte(body), ""))
+		return w.Code
+	}
+	if code := add(`{"date":"2025-01-08"}`); code != http.StatusNoContent {
+		t.Fatalf("очікував 204, отримав %d", code)
+	}
+	if code := add(`{"date":"2025-01-08"}`); code != http.StatusConflict {
+		t.Errorf("повторне заморожування: очікував 409, отримав %d", code)
+	}
+	if code := add(`{"date":"08.01.2025"}`); code != http.StatusBadRequest {
+		t.Errorf("неправильна дата: очікував 400, отримав %d", code)
+	}
+
+	remove := func(date string) int {
+		req := newRequest(http.MethodDelete, "/mood/streaks/freezes/"+date, nil, "")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("date", date)
+		w := httptest.NewRecorder()
+		h.RemoveFreeze(w, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+		return w.Code
+	}
+	if code := remove("2025-01-08"); code != http.StatusNoContent {
+		t.Errorf("очікував 204, отримав %d", code)
+	}
+	if code := remove("2025-01-08"); code != http.StatusNotFound {
+		t.Errorf("повторне видалення: очікував 404, отримав %d", code)
+	}
+	frozen, _ := h.Freezes.List(context.Background(), "user-1")
+	if len(frozen) != 0 {
+		t.Errorf("день лишився замороженим: %v", frozen)
+	}
+}
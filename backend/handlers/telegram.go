@@ -2,40 +2,98 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"time"
 
-	"moodtracker/db"
 	"moodtracker/middleware"
+	"moodtracker/models"
+	"moodtracker/repository"
 
 	"github.com/go-chi/chi/v5"
 )
 
-type chatReq struct {
-	ChatID int64 `json:"chat_id"`
+// telegramLinkTTL – скільки живе посилання для прив'язки чату
+const telegramLinkTTL = 15 * time.Minute
+
+// TelegramHandler обслуговує /user/telegram. Чат прив'язує сам бот: клієнт отримує
+// одноразове посилання t.me/<BotName>?start=<token>, а chat_id бот бере з оновлення Telegram.
+type TelegramHandler struct {
+	Users   repository.UserRepository
+	Links   repository.TelegramLinkRepository
+	BotName string // username бота без "@" (TELEGRAM_BOT_USERNAME)
+	Auth    func(http.Handler) http.Handler
+}
+
+func NewTelegramHandler(users repository.UserRepository, links repository.TelegramLinkRepository, botName string, authMW func(http.Handler) http.Handler) *TelegramHandler {
+	return &TelegramHandler{Users: users, Links: links, BotName: botName, Auth: authMW}
 }
 
-// RegisterTelegramRoutes реєструє POST /user/telegram
-func RegisterTelegramRoutes(r chi.Router) {
+// Routes реєструє GET і DELETE /user/telegram та POST /user/telegram/link
+func (h *TelegramHandler) Routes(r chi.Router) {
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.JWTAuth)
-		r.Post("/register", RegisterTelegram)
+		r.Use(h.Auth)
+		r.Get("/", h.Status)
+		r.Post("/link", h.Link)
+		r.Delete("/", h.Unlink)
 	})
 }
 
-func RegisterTelegram(w http.ResponseWriter, r *http.Request) {
-	var req chatReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+// Status повідомляє, чи прив'язано Telegram-чат
+func (h *TelegramHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	u, err := h.Users.GetByID(r.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusNotFound, "not_found")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
 		return
 	}
-	userID := r.Context().Value(middleware.UserIDKey).(string)
-	// Оновлюємо користувача
-	_, err := db.DB.Exec(
-		`UPDATE users SET telegram_chat_id=$1 WHERE id=$2`,
-		req.ChatID, userID,
-	)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"linked": u.TelegramChatID != nil})
+}
+
+// Link видає одноразове посилання на бота для прив'язки чату
+func (h *TelegramHandler) Link(w http.ResponseWriter, r *http.Request) {
+	if h.BotName == "" {
+		fail(w, r, http.StatusServiceUnavailable, "telegram_not_configured")
+		return
+	}
+	// 24 байти в base64url – 32 символи, Telegram приймає до 64 символів [A-Za-z0-9_-]
+	token, err := randomToken(24)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		failInternal(w, r, err)
+		return
+	}
+	l := models.TelegramLink{
+		Token:     token,
+		UserID:    r.Context().Value(middleware.UserIDKey).(string),
+		ExpiresAt: time.Now().Add(telegramLinkTTL),
+	}
+	if err := h.Links.Create(r.Context(), &l); err != nil {
+		failInternal(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"url":        "https://t.me/" + url.PathEscape(h.BotName) + "?start=" + token,
+		"expires_at": l.ExpiresAt,
+	})
+}
+
+// Unlink від'єднує Telegram-чат; нагадування більше не надсилаються
+func (h *TelegramHandler) Unlink(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.Users.UnlinkTelegram(r.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		fail(w, r, http.StatusNotFound, "telegram_not_linked")
+		return
+	} else if err != nil {
+		failInternal(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -1,93 +1,114 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
-	"regexp"
+	"strings"
 	"testing"
+	"time"
 
-	"moodtracker/db"
-	"moodtracker/middleware"
-
-	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/jmoiron/sqlx"
+	"moodtracker/models"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
 )
 
-func setupTelegramTest(t *testing.T) (mock sqlmock.Sqlmock, teardown func()) {
-	sqlDB, m, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
-	if err != nil {
-		t.Fatalf("не вдалося створити sqlmock: %v", err)
+// brokenLinks імітує недоступну БД
+type brokenLinks struct {
+	repository.TelegramLinkRepository
+}
+
+func (brokenLinks) Create(context.Context, *models.TelegramLink) error { return errDB }
+
+func setupTelegramTest(t *testing.T) (*TelegramHandler, repository.Set) {
+	repos := memory.New()
+	if err := repos.Users.Create(context.Background(), &models.User{ID: "user-1", Email: "test@example.com"}); err != nil {
+		t.Fatal(err)
 	}
-	db.DB.DB = sqlx.NewDb(sqlDB, "postgres")
-	return m, func() { sqlDB.Close() }
+	return NewTelegramHandler(repos.Users, repos.TelegramLinks, "mood_bot", nil), repos
 }
 
-func TestRegisterTelegram_BadJSON(t *testing.T) {
-	// некоректний JSON
-	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString("not-json"))
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
-	w := httptest.NewRecorder()
+func TestTelegramLink_Success(t *testing.T) {
+	h, repos := setupTelegramTest(t)
 
-	RegisterTelegram(w, req)
+	w := httptest.NewRecorder()
+	h.Link(w, newRequest(http.MethodPost, "/user/telegram/link", nil, ""))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("очікував 201, отримав %d", w.Code)
+	}
+	var out struct {
+		URL       string    `json:"url"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &out)
+	token, ok := strings.CutPrefix(out.URL, "https://t.me/mood_bot?start=")
+	if !ok || len(token) != 32 || out.ExpiresAt.Before(time.Now()) {
+		t.Fatalf("неправильне посилання: %+v", out)
+	}
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("очікував 400 Bad Request, отримав %d", w.Code)
+	// токен одноразовий і належить користувачу
+	l, err := repos.TelegramLinks.Consume(context.Background(), token, time.Now())
+	if err != nil || l.UserID != "user-1" {
+		t.Errorf("токен не збережено: %+v, %v", l, err)
+	}
+	if _, err := repos.TelegramLinks.Consume(context.Background(), token, time.Now()); err == nil {
+		t.Error("токен можна використати лише раз")
 	}
 }
 
-func TestRegisterTelegram_DBError(t *testing.T) {
-	mock, teardown := setupTelegramTest(t)
-	defer teardown()
-
-	// імітуємо помилку оновлення в БД
-	mock.
-		ExpectExec(regexp.QuoteMeta("UPDATE users SET telegram_chat_id=$1 WHERE id=$2")).
-		WithArgs(int64(1234), "user-1").
-		WillReturnError(errors.New("update fail"))
+func TestTelegramLink_NotConfigured(t *testing.T) {
+	h, _ := setupTelegramTest(t)
+	h.BotName = ""
 
-	// формуємо валідний запит
-	payload := chatReq{ChatID: 1234}
-	body, _ := json.Marshal(payload)
-	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(body))
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
 	w := httptest.NewRecorder()
+	h.Link(w, newRequest(http.MethodPost, "/user/telegram/link", nil, ""))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("очікував 503, отримав %d", w.Code)
+	}
+}
 
-	RegisterTelegram(w, req)
+func TestTelegramLink_DBError(t *testing.T) {
+	h, _ := setupTelegramTest(t)
+	h.Links = brokenLinks{}
 
+	w := httptest.NewRecorder()
+	h.Link(w, newRequest(http.MethodPost, "/user/telegram/link", nil, ""))
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("очікував 500 Internal Server Error, отримав %d", w.Code)
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("невиконані очікування sqlmock: %v", err)
-	}
 }
 
-func TestRegisterTelegram_Success(t *testing.T) {
-	mock, teardown := setupTelegramTest(t)
-	defer teardown()
-
-	// імітуємо успішне оновлення
-	mock.
-		ExpectExec(regexp.QuoteMeta("UPDATE users SET telegram_chat_id=$1 WHERE id=$2")).
-		WithArgs(int64(5678), "user-1").
-		WillReturnResult(sqlmock.NewResult(1, 1))
+func TestTelegramStatusAndUnlink(t *testing.T) {
+	h, repos := setupTelegramTest(t)
+	linked := func() bool {
+		w := httptest.NewRecorder()
+		h.Status(w, newRequest(http.MethodGet, "/user/telegram", nil, ""))
+		var out map[string]bool
+		json.Unmarshal(w.Body.Bytes(), &out)
+		return out["linked"]
+	}
+	if linked() {
+		t.Fatal("новий користувач не має прив'язаного чату")
+	}
+	if err := repos.Users.SetTelegramChatID(context.Background(), "user-1", 5678); err != nil {
+		t.Fatal(err)
+	}
+	if !linked() {
+		t.Fatal("очікував прив'язаний чат")
+	}
 
-	payload := chatReq{ChatID: 5678}
-	body, _ := json.Marshal(payload)
-	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(body))
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
 	w := httptest.NewRecorder()
-
-	RegisterTelegram(w, req)
-
+	h.Unlink(w, newRequest(http.MethodDelete, "/user/telegram", nil, ""))
 	if w.Code != http.StatusNoContent {
-		t.Errorf("очікував 204 No Content, отримав %d", w.Code)
+		t.Fatalf("очікував 204 No Content, отримав %d", w.Code)
+	}
+	if linked() {
+		t.Error("чат має бути від'єднано")
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("невиконані очікування sqlmock: %v", err)
+	w = httptest.NewRecorder()
+	h.Unlink(w, newRequest(http.MethodDelete, "/user/telegram", nil, ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("повторне від'єднання: очікував 404, отримав %d", w.Code)
 	}
 }
@@ -0,0 +1,195 @@
+// Package i18n – каталог повідомлень бота, нагадувань, звітів і помилок API.
+// Тексти вибираються за мовою користувача (users.locale) або заголовком
+// Accept-Language; множина рахується за правилами CLDR для кожної мови.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale – мова повідомлень
+type Locale string
+
+const (
+	UK Locale = "uk"
+	EN Locale = "en"
+)
+
+// Default – мова користувачів, які її не обирали, і запитів без Accept-Language
+const Default = UK
+
+// Locales – підтримувані мови
+var Locales = []Locale{UK, EN}
+
+// Parse розбирає мовний тег ("en", "en-US", "uk_UA"); ok=false – мова не підтримується
+func Parse(tag string) (l Locale, ok bool) {
+	base := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	for _, l := range Locales {
+		if string(l) == base {
+			return l, true
+		}
+	}
+	return Default, false
+}
+
+// Supported – список підтримуваних мов для повідомлень про помилки: "uk, en"
+func Supported() string {
+	names := make([]string, len(Locales))
+	for i, l := range Locales {
+		names[i] = string(l)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Match – підтримувана мова для tag або Default
+func Match(tag string) Locale {
+	l, _ := Parse(tag)
+	return l
+}
+
+// Negotiate вибирає мову за заголовком Accept-Language з урахуванням q-ваг
+func Negotiate(header string) Locale {
+	type candidate struct {
+		l Locale
+		q float64
+	}
+	var found []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		l, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			found = append(found, candidate{l, q})
+		}
+	}
+	if len(found) == 0 {
+		return Default
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].q > found[j].q })
+	return found[0].l
+}
+
+// known повертає l, якщо мова підтримується, інакше Default (наприклад, для порожньої)
+func (l Locale) known() Locale {
+	if _, ok := catalog[l]; ok {
+		return l
+	}
+	return Default
+}
+
+// Plural повертає категорію CLDR для цілого n: "one", "few", "many" або "other"
+func Plural(l Locale, n int) string {
+	l = l.known()
+	if n < 0 {
+		n = -n
+	}
+	switch l {
+	case UK:
+		switch mod10, mod100 := n%10, n%100; {
+		case mod10 == 1 && mod100 != 11:
+			return "one"
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+// lookup шукає ключ мовою l, а якщо перекладу немає – мовою Default
+func lookup(l Locale, key string) (string, bool) {
+	if s, ok := catalog[l.known()][key]; ok {
+		return s, true
+	}
+	s, ok := catalog[Default][key]
+	return s, ok
+}
+
+// T повертає повідомлення key мовою l, підставляючи args як у fmt.Sprintf.
+// Невідомий ключ повертається як є.
+func T(l Locale, key string, args ...any) string {
+	format, ok := lookup(l, key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// N – T для повідомлення з кількістю: форма обирається за n ("key.one", "key.few"...),
+// n підставляється першим аргументом, далі args
+func N(l Locale, key string, n int, args ...any) string {
+	format, ok := lookup(l, key+"."+Plural(l, n))
+	if !ok {
+		if format, ok = lookup(l, key+".other"); !ok {
+			return key
+		}
+	}
+	return fmt.Sprintf(format, append([]any{n}, args...)...)
+}
+
+// Localizer – помилка, текст якої можна показати клієнту його мовою
+type Localizer interface {
+	Localize(l Locale) string
+}
+
+// Error – помилка з ключем каталогу. Error() повертає англійський текст для логів,
+// а клієнт отримує Localize мовою запиту.
+type Error struct {
+	Key  string
+	Args []any
+	n    *int // кількість для N
+}
+
+// NewError створює помилку з повідомленням key
+func NewError(key string, args ...any) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+// NewPluralError – NewError для повідомлення з кількістю n (див. N)
+func NewPluralError(key string, n int, args ...any) *Error {
+	return &Error{Key: key, Args: args, n: &n}
+}
+
+func (e *Error) Error() string { return e.Localize(EN) }
+
+// Code – ключ повідомлення, він же стабільний код помилки для відповіді API
+func (e *Error) Code() string { return e.Key }
+
+func (e *Error) Localize(l Locale) string {
+	if e.n != nil {
+		return N(l, e.Key, *e.n, e.Args...)
+	}
+	return T(l, e.Key, e.Args...)
+}
+
+// Message – текст err для клієнта мовою l; помилки поза каталогом повертаються як є
+func Message(l Locale, err error) string {
+	var loc Localizer
+	if errors.As(err, &loc) {
+		return loc.Localize(l)
+	}
+	return err.Error()
+}
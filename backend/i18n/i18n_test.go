@@ -0,0 +1,130 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlural(t *testing.T) {
+	for _, tc := range []struct {
+		l    Locale
+		n    int
+		want string
+	}{
+		{UK, 1, "one"}, {UK, 21, "one"}, {UK, 11, "many"},
+		{UK, 2, "few"}, {UK, 4, "few"}, {UK, 34, "few"}, {UK, 12, "many"}, {UK, 14, "many"},
+		{UK, 0, "many"}, {UK, 5, "many"}, {UK, 111, "many"},
+		{EN, 1, "one"}, {EN, 0, "other"}, {EN, 21, "other"},
+	} {
+		if got := Plural(tc.l, tc.n); got != tc.want {
+			t.Errorf("%s %d: очікував %s, отримав %s", tc.l, tc.n, tc.want, got)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]Locale{
+		"":                          Default,
+		"en-US,en;q=0.9":            EN,
+		"uk-UA,uk;q=0.9,en;q=0.8":   UK,
+		"de-DE,en;q=0.5,uk;q=0.7":   UK,
+		"fr, en;q=0.1":              EN,
+		"en;q=0, de":                Default,
+		"ru-RU,ru;q=0.9,en-GB;q=.8": EN,
+	} {
+		if got := Negotiate(header); got != want {
+			t.Errorf("%q: очікував %s, отримав %s", header, want, got)
+		}
+	}
+	if l, ok := Parse("en_GB"); !ok || l != EN {
+		t.Errorf("en_GB: отримав %s, %v", l, ok)
+	}
+	if _, ok := Parse("de"); ok {
+		t.Error("de не підтримується")
+	}
+}
+
+func TestTAndN(t *testing.T) {
+	if got := N(UK, "days", 3); got != "3 дні" {
+		t.Errorf("отримав %q", got)
+	}
+	if got := N(EN, "days", 1); got != "1 day" {
+		t.Errorf("отримав %q", got)
+	}
+	if got := T(EN, "report.streaks", N(EN, "days", 2), N(EN, "days", 5)); got != "Current streak: 2 days, longest: 5 days" {
+		t.Errorf("отримав %q", got)
+	}
+	if got := T(EN, "no.such.key"); got != "no.such.key" {
+		t.Errorf("невідомий ключ: %q", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	sentinel := NewError("not_found")
+	err := fmt.Errorf("load: %w", sentinel)
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is має знаходити помилку каталогу")
+	}
+	if got := Message(UK, err); got != "не знайдено" {
+		t.Errorf("отримав %q", got)
+	}
+	if got := NewPluralError("too_many_times", 6).Error(); got != "at most 6 reminder times are allowed" {
+		t.Errorf("отримав %q", got)
+	}
+	if got := Message(EN, errors.New("raw")); got != "raw" {
+		t.Errorf("помилка поза каталогом: %q", got)
+	}
+}
+
+var verbRe = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+// forms – форми множини, які Plural може повернути для мови
+var forms = map[Locale][]string{UK: {"one", "few", "many"}, EN: {"one", "other"}}
+
+// TestCatalog перевіряє, що кожне повідомлення перекладено всіма мовами,
+// має всі форми множини і ті самі дієслова fmt
+func TestCatalog(t *testing.T) {
+	verbs := map[string]string{} // базовий ключ -> дієслова fmt
+	for _, l := range Locales {
+		bases := map[string][]string{}
+		for key, msg := range catalog[l] {
+			base, form := key, ""
+			if i := strings.LastIndex(key, "."); i >= 0 && slices.Contains([]string{"one", "few", "many", "other"}, key[i+1:]) {
+				base, form = key[:i], key[i+1:]
+			}
+			bases[base] = append(bases[base], form)
+
+			got := strings.Join(verbRe.FindAllString(msg, -1), " ")
+			if want, ok := verbs[base]; !ok {
+				verbs[base] = got
+			} else if got != want {
+				t.Errorf("%s %s: дієслова %q, а в іншій мові %q", l, key, got, want)
+			}
+		}
+		for base, have := range bases {
+			if len(have) == 1 && have[0] == "" {
+				continue
+			}
+			for _, f := range forms[l] {
+				if !slices.Contains(have, f) {
+					t.Errorf("%s %s: немає форми %s", l, base, f)
+				}
+			}
+		}
+		for _, other := range Locales {
+			for key := range catalog[other] {
+				base := key
+				if i := strings.LastIndex(key, "."); i >= 0 && slices.Contains([]string{"one", "few", "many", "other"}, key[i+1:]) {
+					base = key[:i]
+				}
+				if _, ok := bases[base]; !ok {
+					t.Errorf("%s: немає перекладу %s", l, base)
+				}
+			}
+		}
+	}
+}
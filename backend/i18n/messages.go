@@ -0,0 +1,337 @@
+package i18n
+
+// catalog – повідомлення за мовою і ключем. Ключі помилок API – snake_case,
+// решта згруповані префіксами (bot., reminder., report., icon., email.). Повідомлення
+// з кількістю мають форми з суфіксами .one/.few/.many (uk) і .one/.other (en).
+var catalog = map[Locale]map[string]string{
+	UK: {
+		// Помилки API
+		"not_found":                "не знайдено",
+		"unauthorized":             "потрібна авторизація",
+		"conflict":                 "запис суперечить наявним даним",
+		"precondition_failed":      "запис змінено з іншого пристрою; оновіть його і спробуйте ще раз",
+		"internal_error":           "внутрішня помилка сервера, спробуйте пізніше",
+		"method_not_allowed":       "метод не підтримується для цього ресурсу",
+		"invalid_json":             "некоректний JSON у тілі запиту",
+		"email_required":           "вкажіть email",
+		"too_many_login_requests":  "забагато запитів на вхід, спробуйте пізніше",
+		"login_email_failed":       "не вдалося надіслати лист із кодом входу",
+		"email_and_code_required":  "вкажіть email і код",
+		"invalid_code":             "код недійсний або прострочений",
+		"too_many_attempts":        "забагато спроб, запросіть новий код або спробуйте пізніше",
+		"refresh_token_required":   "вкажіть refresh_token",
+		"invalid_refresh_token":    "refresh-токен недійсний",
+		"refresh_token_reused":     "виявлено повторне використання refresh-токена",
+		"sso_not_configured":       "вхід через SSO не налаштовано",
+		"identity_provider_error":  "провайдер входу відхилив запит, спробуйте ще раз",
+		"state_and_code_required":  "вкажіть state і code",
+		"invalid_state":            "state недійсний або прострочений",
+		"sso_login_failed":         "не вдалося увійти через SSO",
+		"unverified_email":         "провайдер входу не повернув підтверджений email",
+		"icon_length":              "іконка має містити від 1 до %d символів",
+		"label_length":             "назва має містити від 1 до %d символів",
+		"score_range":              "бал має бути від %d до %d",
+		"invalid_color":            "колір має бути у форматі #RRGGBB",
+		"icon_in_catalog":          "ця іконка вже є у вбудованому каталозі",
+		"icon_exists":              "така іконка вже існує",
+		"invalid_icon":             "некоректна іконка",
+		"unknown_icon":             "невідома іконка, див. /api/moods/catalog",
+		"invalid_date":             "некоректна дата, очікується YYYY-MM-DD",
+		"invalid_from_date":        "некоректна дата from, очікується YYYY-MM-DD",
+		"invalid_to_date":          "некоректна дата to, очікується YYYY-MM-DD",
+		"logged_at_date":           "logged_at має припадати на date",
+		"mood_already_exists":      "настрій за цю дату вже записано",
+		"request_too_large":        "тіло запиту завелике",
+		"unsupported_media_type":   "тіло запиту має бути %s",
+		"from_after_to":            "from не може бути пізніше за to",
+		"invalid_sort":             "sort має бути asc або desc",
+		"invalid_score":            "score має бути цілим числом від %d до %d",
+		"invalid_limit":            "limit має бути цілим числом від 1 до %d",
+		"invalid_cursor":           "некоректний cursor; візьміть next_cursor з попередньої сторінки",
+		"period_too_long.one":      "період не може перевищувати %d день",
+		"period_too_long.few":      "період не може перевищувати %d дні",
+		"period_too_long.many":     "період не може перевищувати %d днів",
+		"invalid_group_by":         "group_by має бути одним із: day, week, month, weekday",
+		"invalid_entry_mode":       "entry_mode має бути \"single\" або \"multiple\"",
+		"invalid_timezone":         "часовий пояс має бути IANA, наприклад Europe/Kyiv",
+		"invalid_locale":           "locale має бути одним із: %s",
+		"day_already_frozen":       "цей день уже заморожено",
+		"telegram_not_configured":  "Telegram-бот не налаштовано",
+		"telegram_not_linked":      "Telegram не підключено",
+		"web_push_not_configured":  "Web Push не налаштовано",
+		"subscription_not_found":   "підписку не знайдено",
+		"invalid_push_endpoint":    "endpoint має бути абсолютною https-адресою",
+		"invalid_push_p256dh":      "keys.p256dh має бути відкритим ключем P-256 у base64url",
+		"invalid_push_auth":        "keys.auth має бути 16 байтами в base64url",
+		"unknown_channel":          "невідомий канал %q",
+		"duplicate_channel":        "канал %q вказано двічі",
+		"webhook_url_required":     "для каналу webhook потрібен webhook_url",
+		"invalid_webhook_url":      "webhook_url має бути абсолютною https-адресою",
+		"invalid_time":             "некоректний час %q, очікується HH:MM",
+		"too_many_times.one":       "можна задати щонайбільше %d час нагадування",
+		"too_many_times.few":       "можна задати щонайбільше %d часи нагадувань",
+		"too_many_times.many":      "можна задати щонайбільше %d часів нагадувань",
+		"duplicate_reminder_time":  "час нагадування %q вказано двічі",
+		"invalid_weekday":          "дні тижня мають бути від 0 (неділя) до 6 (субота)",
+		"duplicate_weekday":        "день тижня %d вказано двічі",
+		"quiet_hours_pair":         "quiet_start і quiet_end задаються разом",
+		"quiet_hours_empty":        "тихі години не можуть бути порожнім проміжком",
+		"invalid_report_frequency": "report_frequency має бути weekly, monthly або off",
+		"invalid_webhook_secret":   "неправильний секретний токен webhook",
+		"invalid_update":           "некоректне оновлення Telegram",
+
+		// Помилки полів (validate)
+		"validation_failed": "деякі поля запиту некоректні",
+		"required":          "обов'язкове поле",
+		"too_long.one":      "щонайбільше %d символ",
+		"too_long.few":      "щонайбільше %d символи",
+		"too_long.many":     "щонайбільше %d символів",
+		"too_small":         "щонайменше %d",
+		"too_large":         "щонайбільше %d",
+		"unknown_field":     "невідоме поле",
+		"invalid_type":      "неправильний тип значення",
+		"future_date":       "дата не може бути в майбутньому",
+		"date_too_old.one":  "настрій можна внести лише за останній %d день",
+		"date_too_old.few":  "настрій можна внести лише за останні %d дні",
+		"date_too_old.many": "настрій можна внести лише за останні %d днів",
+
+		// Telegram-бот
+		"bot.command.mood":        "Записати настрій",
+		"bot.command.today":       "Записи за сьогодні",
+		"bot.command.week":        "Звіт за останні 7 днів",
+		"bot.command.stats":       "Статистика за 30 днів і серії",
+		"bot.command.undo":        "Видалити останній сьогоднішній запис",
+		"bot.command.stop":        "Відключити нагадування",
+		"bot.help":                "Команди:",
+		"bot.error":               "Щось пішло не так. Спробуй пізніше.",
+		"bot.not_linked":          "Чат не підключено. Натисни «Підключити Telegram» у застосунку і відкрий посилання.",
+		"bot.start.private":       "Підключити нагадування можна лише в особистому чаті з ботом.",
+		"bot.start.invalid":       "Посилання недійсне або застаріло. Отримай нове в застосунку.",
+		"bot.start.failed":        "Не вдалося підключити Telegram. Спробуй пізніше.",
+		"bot.start.taken":         "Цей чат уже підключено до іншого акаунта. Спершу відключи його там.",
+		"bot.start.done":          "Готово! Тепер нагадування і звіти надходитимуть сюди.",
+		"bot.keyboard":            "Який у тебе настрій?",
+		"bot.prompt.prefix":       "Настрій: ",
+		"bot.prompt":              "Напиши коментар у відповідь на це повідомлення.",
+		"bot.prompt.placeholder":  "Коментар",
+		"bot.create.exists":       "За сьогодні настрій уже записано. Щоб додавати кілька записів на день, зміни режим у налаштуваннях.",
+		"bot.create.unknown_icon": "Такої іконки немає в каталозі. Обери її через /mood.",
+		"bot.create.no_comment":   "Коментар не може бути порожнім.",
+		"bot.create.invalid":      "Не вдалося зберегти запис: %s",
+		"bot.create.failed":       "Не вдалося зберегти запис. Спробуй пізніше.",
+		"bot.created":             "Записано: %s — %s",
+		"bot.streak.one":          "Серія: %d день поспіль",
+		"bot.streak.few":          "Серія: %d дні поспіль",
+		"bot.streak.many":         "Серія: %d днів поспіль",
+		"bot.today.title":         "Сьогодні:",
+		"bot.today.empty":         "Сьогодні ще немає записів. Додай настрій: /mood",
+		"bot.today.failed":        "Не вдалося отримати записи. Спробуй пізніше.",
+		"bot.week.title":          "Твій звіт за останні 7 днів:",
+		"bot.stats.title":         "Твоя статистика за 30 днів:",
+		"bot.stats.failed":        "Не вдалося порахувати статистику. Спробуй пізніше.",
+		"bot.undo.done":           "Видалено: %s — %s",
+		"bot.undo.empty":          "Сьогодні немає записів, які можна скасувати.",
+		"bot.undo.failed":         "Не вдалося скасувати запис. Спробуй пізніше.",
+		"bot.stop.done":           "Чат від'єднано, нагадування більше не надходитимуть. Підключити знову можна в застосунку.",
+		"bot.stop.failed":         "Не вдалося відключити нагадування. Спробуй пізніше.",
+
+		// Нагадування і звіти
+		"reminder.title":          "Нагадування про настрій",
+		"reminder.text":           "Не забудь внести сьогоднішній настрій",
+		"reminder.streak.one":     "Твоя серія: %d день поспіль — не переривай її!",
+		"reminder.streak.few":     "Твоя серія: %d дні поспіль — не переривай її!",
+		"reminder.streak.many":    "Твоя серія: %d днів поспіль — не переривай її!",
+		"reminder.milestone.one":  "Ще один запис — і буде %d день поспіль 🎉",
+		"reminder.milestone.few":  "Ще один запис — і буде %d дні поспіль 🎉",
+		"reminder.milestone.many": "Ще один запис — і буде %d днів поспіль 🎉",
+		"report.weekly.subject":   "Звіт за тиждень",
+		"report.weekly.title":     "Твій звіт за останній тиждень:",
+		"report.monthly.subject":  "Звіт за місяць",
+		"report.monthly.title":    "Твій звіт за минулий місяць:",
+		"report.average":          "Середній бал: %.1f з %d",
+		"report.most_common":      "Найчастіший настрій: %s",
+		"report.days_logged":      "Днів із записами: %d з %d",
+		"report.streaks":          "Поточна серія: %s, найдовша: %s",
+		"report.milestone.one":    "🎉 Віха: %d день поспіль!",
+		"report.milestone.few":    "🎉 Віха: %d дні поспіль!",
+		"report.milestone.many":   "🎉 Віха: %d днів поспіль!",
+		"days.one":                "%d день",
+		"days.few":                "%d дні",
+		"days.many":               "%d днів",
+
+		// Назви іконок вбудованого каталогу (models.Catalog)
+		"icon.great":   "Чудово",
+		"icon.good":    "Добре",
+		"icon.neutral": "Нейтрально",
+		"icon.sad":     "Сумно",
+		"icon.bad":     "Погано",
+		"icon.angry":   "Злість",
+
+		// Листи
+		"email.login.subject":  "Код входу до Mood Tracker",
+		"email.login.body":     "Ваш код входу: %s\n\nАбо просто перейдіть за посиланням:\n%s",
+		"email.login.ttl.one":  "Код діє %d хвилину і може бути використаний лише один раз.",
+		"email.login.ttl.few":  "Код діє %d хвилини і може бути використаний лише один раз.",
+		"email.login.ttl.many": "Код діє %d хвилин і може бути використаний лише один раз.",
+	},
+	EN: {
+		// API errors
+		"not_found":                "not found",
+		"unauthorized":             "unauthorized",
+		"conflict":                 "the record conflicts with existing data",
+		"precondition_failed":      "the entry was changed on another device; reload it and try again",
+		"internal_error":           "internal server error, try again later",
+		"method_not_allowed":       "method not allowed for this resource",
+		"invalid_json":             "invalid JSON body",
+		"email_required":           "email is required",
+		"too_many_login_requests":  "too many login requests, try again later",
+		"login_email_failed":       "failed to send login email",
+		"email_and_code_required":  "email and code are required",
+		"invalid_code":             "invalid or expired code",
+		"too_many_attempts":        "too many attempts, request a new code or try again later",
+		"refresh_token_required":   "refresh_token is required",
+		"invalid_refresh_token":    "invalid refresh token",
+		"refresh_token_reused":     "refresh token reuse detected",
+		"sso_not_configured":       "sso is not configured",
+		"identity_provider_error":  "the identity provider rejected the sign-in, please try again",
+		"state_and_code_required":  "state and code are required",
+		"invalid_state":            "invalid or expired state",
+		"sso_login_failed":         "sso login failed",
+		"unverified_email":         "identity provider did not return a verified email",
+		"icon_length":              "icon must be 1-%d characters",
+		"label_length":             "label must be 1-%d characters",
+		"score_range":              "score must be between %d and %d",
+		"invalid_color":            "color must be #RRGGBB",
+		"icon_in_catalog":          "icon is already in the built-in catalogue",
+		"icon_exists":              "icon already exists",
+		"invalid_icon":             "invalid icon",
+		"unknown_icon":             "unknown icon, see /api/moods/catalog",
+		"invalid_date":             "invalid date format, expected YYYY-MM-DD",
+		"invalid_from_date":        "invalid from date, expected YYYY-MM-DD",
+		"invalid_to_date":          "invalid to date, expected YYYY-MM-DD",
+		"logged_at_date":           "logged_at must fall on date",
+		"mood_already_exists":      "mood for this date already exists",
+		"request_too_large":        "request body is too large",
+		"unsupported_media_type":   "request body must be %s",
+		"from_after_to":            "from must not be after to",
+		"invalid_sort":             "sort must be asc or desc",
+		"invalid_score":            "score must be an integer from %d to %d",
+		"invalid_limit":            "limit must be an integer from 1 to %d",
+		"invalid_cursor":           "invalid cursor; use next_cursor from the previous page",
+		"period_too_long.one":      "period must not exceed %d day",
+		"period_too_long.other":    "period must not exceed %d days",
+		"invalid_group_by":         "group_by must be one of day, week, month, weekday",
+		"invalid_entry_mode":       "entry_mode must be \"single\" or \"multiple\"",
+		"invalid_timezone":         "timezone must be an IANA time zone such as Europe/Kyiv",
+		"invalid_locale":           "locale must be one of %s",
+		"day_already_frozen":       "day is already frozen",
+		"telegram_not_configured":  "telegram bot is not configured",
+		"telegram_not_linked":      "telegram is not linked",
+		"web_push_not_configured":  "web push is not configured",
+		"subscription_not_found":   "subscription not found",
+		"invalid_push_endpoint":    "endpoint must be an absolute https URL",
+		"invalid_push_p256dh":      "keys.p256dh must be a base64url P-256 public key",
+		"invalid_push_auth":        "keys.auth must be 16 bytes of base64url",
+		"unknown_channel":          "unknown channel %q",
+		"duplicate_channel":        "duplicate channel %q",
+		"webhook_url_required":     "webhook_url is required for the webhook channel",
+		"invalid_webhook_url":      "webhook_url must be an absolute https URL",
+		"invalid_time":             "invalid time %q, expected HH:MM",
+		"too_many_times.one":       "at most %d reminder time is allowed",
+		"too_many_times.other":     "at most %d reminder times are allowed",
+		"duplicate_reminder_time":  "duplicate reminder time %q",
+		"invalid_weekday":          "weekdays must be between 0 (Sunday) and 6 (Saturday)",
+		"duplicate_weekday":        "duplicate weekday %d",
+		"quiet_hours_pair":         "quiet_start and quiet_end must be set together",
+		"quiet_hours_empty":        "quiet hours must not be empty",
+		"invalid_report_frequency": "report_frequency must be weekly, monthly or off",
+		"invalid_webhook_secret":   "invalid webhook secret token",
+		"invalid_update":           "invalid Telegram update",
+
+		// Field errors (validate)
+		"validation_failed":  "some request fields are invalid",
+		"required":           "field is required",
+		"too_long.one":       "must be at most %d character",
+		"too_long.other":     "must be at most %d characters",
+		"too_small":          "must be at least %d",
+		"too_large":          "must be at most %d",
+		"unknown_field":      "unknown field",
+		"invalid_type":       "wrong value type",
+		"future_date":        "date cannot be in the future",
+		"date_too_old.one":   "must be within the last %d day",
+		"date_too_old.other": "must be within the last %d days",
+
+		// Telegram bot
+		"bot.command.mood":        "Log your mood",
+		"bot.command.today":       "Today's entries",
+		"bot.command.week":        "Report for the last 7 days",
+		"bot.command.stats":       "30-day stats and streaks",
+		"bot.command.undo":        "Delete today's latest entry",
+		"bot.command.stop":        "Turn off reminders",
+		"bot.help":                "Commands:",
+		"bot.error":               "Something went wrong. Please try again later.",
+		"bot.not_linked":          "This chat is not connected. Tap “Connect Telegram” in the app and open the link.",
+		"bot.start.private":       "Reminders can only be connected in a private chat with the bot.",
+		"bot.start.invalid":       "The link is invalid or has expired. Get a new one in the app.",
+		"bot.start.failed":        "Could not connect Telegram. Please try again later.",
+		"bot.start.taken":         "This chat is already connected to another account. Disconnect it there first.",
+		"bot.start.done":          "Done! Reminders and reports will now arrive here.",
+		"bot.keyboard":            "How are you feeling?",
+		"bot.prompt.prefix":       "Mood: ",
+		"bot.prompt":              "Reply to this message with a comment.",
+		"bot.prompt.placeholder":  "Comment",
+		"bot.create.exists":       "Today's mood is already logged. To add several entries a day, change the mode in settings.",
+		"bot.create.unknown_icon": "This icon is not in the catalogue. Pick one with /mood.",
+		"bot.create.no_comment":   "The comment must not be empty.",
+		"bot.create.invalid":      "Could not save the entry: %s",
+		"bot.create.failed":       "Could not save the entry. Please try again later.",
+		"bot.created":             "Logged: %s — %s",
+		"bot.streak.one":          "Streak: %d day in a row",
+		"bot.streak.other":        "Streak: %d days in a row",
+		"bot.today.title":         "Today:",
+		"bot.today.empty":         "No entries yet today. Log your mood: /mood",
+		"bot.today.failed":        "Could not load entries. Please try again later.",
+		"bot.week.title":          "Your report for the last 7 days:",
+		"bot.stats.title":         "Your stats for the last 30 days:",
+		"bot.stats.failed":        "Could not calculate stats. Please try again later.",
+		"bot.undo.done":           "Deleted: %s — %s",
+		"bot.undo.empty":          "There are no entries today to undo.",
+		"bot.undo.failed":         "Could not undo the entry. Please try again later.",
+		"bot.stop.done":           "Chat disconnected, reminders will no longer arrive. You can reconnect in the app.",
+		"bot.stop.failed":         "Could not turn off reminders. Please try again later.",
+
+		// Reminders and reports
+		"reminder.title":           "Mood reminder",
+		"reminder.text":            "Don't forget to log today's mood",
+		"reminder.streak.one":      "Your streak: %d day in a row — keep it going!",
+		"reminder.streak.other":    "Your streak: %d days in a row — keep it going!",
+		"reminder.milestone.one":   "One more entry and it's %d day in a row 🎉",
+		"reminder.milestone.other": "One more entry and it's %d days in a row 🎉",
+		"report.weekly.subject":    "Weekly report",
+		"report.weekly.title":      "Your report for the last week:",
+		"report.monthly.subject":   "Monthly report",
+		"report.monthly.title":     "Your report for last month:",
+		"report.average":           "Average score: %.1f of %d",
+		"report.most_common":       "Most common mood: %s",
+		"report.days_logged":       "Days logged: %d of %d",
+		"report.streaks":           "Current streak: %s, longest: %s",
+		"report.milestone.one":     "🎉 Milestone: %d day in a row!",
+		"report.milestone.other":   "🎉 Milestone: %d days in a row!",
+		"days.one":                 "%d day",
+		"days.other":               "%d days",
+
+		// Built-in catalog icon labels (models.Catalog)
+		"icon.great":   "Great",
+		"icon.good":    "Good",
+		"icon.neutral": "Neutral",
+		"icon.sad":     "Sad",
+		"icon.bad":     "Bad",
+		"icon.angry":   "Angry",
+
+		// Emails
+		"email.login.subject":   "Your Mood Tracker sign-in code",
+		"email.login.body":      "Your sign-in code: %s\n\nOr just follow the link:\n%s",
+		"email.login.ttl.one":   "The code is valid for %d minute and can be used only once.",
+		"email.login.ttl.other": "The code is valid for %d minutes and can be used only once.",
+	},
+}
@@ -0,0 +1,93 @@
+// Package leader обирає серед інстансів бекенду одного, що виконує фонові
+// завдання за розкладом. Лідер тримає оренду в таблиці leases і регулярно її
+// продовжує; якщо він зникне, після TTL оренду забере інший інстанс.
+package leader
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"moodtracker/repository"
+)
+
+// Elector бере і продовжує оренду Name від імені інстансу ID
+type Elector struct {
+	Leases repository.LeaseRepository
+	Name   string
+	ID     string
+	TTL    time.Duration // скільки оренда живе без продовження
+	Renew  time.Duration // як часто продовжувати; має бути помітно менше за TTL
+
+	mu    sync.Mutex
+	until time.Time // до якого моменту цей інстанс – лідер
+}
+
+// New повертає Elector з ідентифікатором інстансу "hostname/uuid"
+func New(leases repository.LeaseRepository, name string) *Elector {
+	host, _ := os.Hostname()
+	return &Elector{
+		Leases: leases,
+		Name:   name,
+		ID:     host + "/" + uuid.NewString(),
+		TTL:    30 * time.Second,
+		Renew:  10 * time.Second,
+	}
+}
+
+// IsLeader повідомляє, чи тримає інстанс оренду зараз. Якщо продовжити її не
+// вдалося, лідерство закінчується разом з орендою, навіть без відповіді від БД.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return time.Now().Before(e.until)
+}
+
+// Step один раз бере або продовжує оренду і повертає, чи інстанс – лідер
+func (e *Elector) Step(ctx context.Context) bool {
+	was := e.IsLeader()
+	now := time.Now()
+	ok, err := e.Leases.Acquire(ctx, e.Name, e.ID, now, e.TTL)
+	if err != nil {
+		log.Printf("lease %s renew err: %v", e.Name, err)
+		return e.IsLeader()
+	}
+
+	e.mu.Lock()
+	if ok {
+		e.until = now.Add(e.TTL)
+	} else {
+		e.until = time.Time{}
+	}
+	e.mu.Unlock()
+
+	if ok != was {
+		log.Printf("lease %s: instance %s leader=%v", e.Name, e.ID, ok)
+	}
+	return ok
+}
+
+// Run тримає оренду, доки не скасовано ctx, а потім віддає її, щоб інший
+// інстанс став лідером одразу, не чекаючи TTL
+func (e *Elector) Run(ctx context.Context) {
+	t := time.NewTicker(e.Renew)
+	defer t.Stop()
+	for {
+		e.Step(ctx)
+		select {
+		case <-ctx.Done():
+			e.mu.Lock()
+			e.until = time.Time{}
+			e.mu.Unlock()
+			if err := e.Leases.Release(context.WithoutCancel(ctx), e.Name, e.ID); err != nil {
+				log.Printf("lease %s release err: %v", e.Name, err)
+			}
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
+)
+
+func TestElector_SingleLeader(t *testing.T) {
+	leases := memory.New().Leases
+	a, b := New(leases, "scheduler"), New(leases, "scheduler")
+	ctx := context.Background()
+
+	if !a.Step(ctx) || b.Step(ctx) {
+		t.Fatal("лідером має бути лише перший інстанс")
+	}
+	if !a.IsLeader() || b.IsLeader() {
+		t.Errorf("IsLeader: a=%v, b=%v", a.IsLeader(), b.IsLeader())
+	}
+	// продовження оренди тим самим інстансом
+	if !a.Step(ctx) {
+		t.Error("лідер має продовжити власну оренду")
+	}
+}
+
+func TestElector_Failover(t *testing.T) {
+	leases := memory.New().Leases
+	a, b := New(leases, "scheduler"), New(leases, "scheduler")
+	a.TTL = 20 * time.Millisecond
+	ctx := context.Background()
+
+	a.Step(ctx)
+	// лідер "помер" і не продовжує оренду
+	time.Sleep(30 * time.Millisecond)
+	if a.IsLeader() {
+		t.Error("без продовження оренди лідерство має закінчитися")
+	}
+	if !b.Step(ctx) {
+		t.Error("після закінчення оренди лідером має стати інший інстанс")
+	}
+	if a.Step(ctx) {
+		t.Error("старий лідер не має повернути оренду")
+	}
+}
+
+func TestElector_RunReleasesOnStop(t *testing.T) {
+	leases := memory.New().Leases
+	a, b := New(leases, "scheduler"), New(leases, "scheduler")
+	a.Renew = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { a.Run(ctx); close(done) }()
+	for !a.IsLeader() {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if a.IsLeader() || !b.Step(context.Background()) {
+		t.Error("після зупинки оренда має одразу перейти до іншого інстансу")
+	}
+}
+
+// failing імітує недоступну БД
+type failing struct{ repository.LeaseRepository }
+
+func (failing) Acquire(context.Context, string, string, time.Time, time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestElector_KeepsLeaseUntilTTLOnError(t *testing.T) {
+	e := New(memory.New().Leases, "scheduler")
+	e.TTL = 50 * time.Millisecond
+	e.Step(context.Background())
+
+	e.Leases = failing{}
+	if !e.Step(context.Background()) {
+		t.Error("збій БД не скасовує ще чинну оренду")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if e.Step(context.Background()) || e.IsLeader() {
+		t.Error("без продовження лідерство має закінчитися разом з орендою")
+	}
+}
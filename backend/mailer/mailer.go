@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message – лист, який треба доставити одному отримувачу
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer відправляє листи. Реалізації: SMTPMailer для продакшену,
+// LogMailer для локальної розробки.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv обирає реалізацію за змінною MAILER (smtp|log, за замовчуванням log)
+func FromEnv() Mailer {
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     envOr("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     envOr("MAIL_FROM", "no-reply@moodtracker.local"),
+		}
+	default:
+		return &LogMailer{Path: os.Getenv("MAIL_LOG_FILE")}
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// SMTPMailer відправляє листи через SMTP-сервер з PLAIN-автентифікацією
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if m.Host == "" {
+		return fmt.Errorf("smtp mailer: SMTP_HOST is not set")
+	}
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{msg.To}, m.build(msg))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("smtp send: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *SMTPMailer) build(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// LogMailer нічого не відправляє: пише лист у лог або дописує у файл Path
+type LogMailer struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	text := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	if m.Path == "" {
+		log.Printf("mail (not sent):\n%s", text)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open mail log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text + "----\n"); err != nil {
+		return fmt.Errorf("write mail log: %w", err)
+	}
+	return nil
+}
@@ -1,17 +1,33 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	_ "time/tzdata" // часові пояси користувачів не залежать від zoneinfo в образі
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
 
+	"moodtracker/auth"
 	"moodtracker/db"
+	"moodtracker/entries"
 	"moodtracker/handlers"
+	"moodtracker/leader"
+	"moodtracker/mailer"
+	appmw "moodtracker/middleware"
+	"moodtracker/notify"
+	"moodtracker/outbox"
+	"moodtracker/problem"
+	"moodtracker/reminders"
+	"moodtracker/repository/sqlstore"
 	"moodtracker/telegram"
 )
 
@@ -20,14 +36,104 @@ func main() {
 		log.Println("No .env file found")
 	}
 
-	err := db.NewDB()
+	database, err := db.Open(os.Getenv("DATABASE_URL"))
 	if err != nil {
 		log.Fatalf("DB connection failed: %v", err)
 		return
 	}
-	err = db.DB.MigrateUp()
+
+	// moodtracker migrate up|down|goto|version|force
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		m, err := database.Migrator()
+		if err != nil {
+			log.Fatalf("DB migration failed: %v", err)
+		}
+		if err := runMigrate(m, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	// Сервер мігрує схему сам лише на явний запит: -migrate або AUTO_MIGRATE=true
+	autoMigrate := flag.Bool("migrate", false, "apply pending DB migrations before starting the server")
+	flag.Parse()
+	if env, _ := strconv.ParseBool(os.Getenv("AUTO_MIGRATE")); env || *autoMigrate {
+		err = database.MigrateUp()
+		if err != nil {
+			log.Fatalf("DB migration failed: %v", err)
+			return
+		}
+	}
+
+	keys, err := auth.LoadFromEnv()
 	if err != nil {
-		log.Fatalf("DB migration failed: %v", err)
+		log.Fatalf("JWT keys: %v", err)
+		return
+	}
+	// Ротація ключів: підкладаємо новий ключ у JWT_KEYS_DIR і надсилаємо SIGHUP
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			if err := keys.ReloadFromEnv(); err != nil {
+				log.Printf("JWT keys reload failed: %v", err)
+				continue
+			}
+			log.Println("JWT keys reloaded")
+		}
+	}()
+
+	repos := sqlstore.New(database.DB)
+	mail := mailer.FromEnv()
+	authMW := appmw.JWTAuth(keys, repos.Sessions)
+	authHandler := handlers.NewAuthHandler(repos, keys, mail)
+
+	if cfg, ok := auth.OIDCConfigFromEnv(); ok {
+		provider, err := auth.NewOIDCProvider(context.Background(), cfg)
+		if err != nil {
+			log.Fatalf("OIDC provider: %v", err)
+			return
+		}
+		authHandler.OIDC = provider
+		log.Printf("SSO login enabled for %s", cfg.Issuer)
+	}
+
+	// Розклад і отримання оновлень Telegram виконує лише лідер; решта реплік обслуговують HTTP і чергу
+	elector := leader.New(repos.Leases, "scheduler")
+	go elector.Run(context.Background())
+
+	notifiers := []notify.Notifier{&notify.Email{Mailer: mail}, notify.NewWebhook(repos.Notifications)}
+	var vapidKey string
+	if cfg, ok := notify.VAPIDConfigFromEnv(); ok {
+		push, err := notify.NewWebPush(repos.PushSubs, cfg)
+		if err != nil {
+			log.Fatalf("Web Push: %v", err)
+			return
+		}
+		notifiers = append(notifiers, push)
+		vapidKey = push.PublicKey()
+	}
+	bot := telegram.Start(repos, telegram.ConfigFromEnv(), elector)
+	if bot != nil {
+		notifiers = append(notifiers, bot.Notifier)
+	}
+	dispatcher := notify.NewDispatcher(repos.Jobs, repos.Notifications, notifiers...)
+
+	// Надсилає воркер черги: повтори, 429 і ліміти каналів обробляються там.
+	// Він працює на кожній репліці – одне завдання двічі не візьмуть завдяки SKIP LOCKED,
+	// а ліміти частоти діляться між OUTBOX_REPLICAS репліками
+	replicas, err := outbox.ReplicasFromEnv()
+	if err != nil {
+		log.Fatalf("Outbox: %v", err)
+		return
+	}
+	go outbox.New(repos.Jobs, dispatcher.Deliver()).PerReplica(replicas).Run(context.Background())
+	reminders.NewScheduler(repos, dispatcher).Start(elector)
+	go pruneLoop(context.Background(), elector, authHandler, repos.Jobs)
+
+	moodHandler := handlers.NewMoodHandler(repos, authMW)
+	if moodHandler.BackfillDays, err = entries.BackfillDaysFromEnv(); err != nil {
+		log.Fatalf("Mood entries: %v", err)
 		return
 	}
 
@@ -36,23 +142,31 @@ func main() {
 	r.Use(cors.Handler(cors.Options{
 		// Дозволяємо доступ тільки з фронтенд-адреси (якщо потрібно, можна замінити на * для всіх)
 		AllowedOrigins:   []string{"http://localhost:5173"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", "If-None-Match"},
+		ExposedHeaders:   []string{"Link", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           300, // 5 хв
 	}))
 
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.NotFound(problem.NotFound)
+	r.MethodNotAllowed(problem.MethodNotAllowed)
+	r.Get("/.well-known/jwks.json", keys.JWKSHandler)
 	r.Route("/api", func(r chi.Router) {
-		r.Route("/auth", handlers.RegisterAuthRoutes)
-		r.Route("/mood", handlers.RegisterMoodRoutes)
-		r.Route("/user/telegram", handlers.RegisterTelegramRoutes)
+		r.Route("/auth", authHandler.Routes)
+		r.Route("/mood", moodHandler.Routes)
+		r.Route("/moods/catalog", handlers.NewCatalogHandler(repos.Icons, authMW).Routes)
+		r.Route("/user/telegram", handlers.NewTelegramHandler(repos.Users, repos.TelegramLinks, os.Getenv("TELEGRAM_BOT_USERNAME"), authMW).Routes)
+		r.Route("/user/settings", handlers.NewSettingsHandler(repos.Users, authMW).Routes)
+		r.Route("/user/reminders", handlers.NewReminderHandler(repos.Reminders, authMW).Routes)
+		r.Route("/user/notifications", handlers.NewNotificationHandler(repos, dispatcher.Channels(), vapidKey, authMW).Routes)
+		if bot != nil && bot.Webhook != nil {
+			r.Method(http.MethodPost, "/telegram/webhook", bot.Webhook)
+		}
 	})
 
-	telegram.Start(db.DB.DB)
-
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
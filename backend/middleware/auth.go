@@ -2,47 +2,71 @@ package middleware
 
 import (
 	"context"
+	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
+	"moodtracker/auth"
+	"moodtracker/problem"
+	"moodtracker/repository"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type ctxKey string
 
-const UserIDKey ctxKey = "userID"
-
-// Викликається на login, щоб створити токен:
-// token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": id})
-// signed, _ := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-
-func JWTAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		hdr := r.Header.Get("Authorization")
-		if hdr == "" || !strings.HasPrefix(hdr, "Bearer ") {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		tokenStr := strings.TrimPrefix(hdr, "Bearer ")
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
+const (
+	UserIDKey    ctxKey = "userID"
+	SessionIDKey ctxKey = "sessionID"
+)
+
+// JWTAuth перевіряє access-токен (claims user_id, sid, exp) ключами keys
+// і відхиляє токени відкликаних сесій.
+func JWTAuth(keys *auth.KeyManager, sessions repository.SessionRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hdr := r.Header.Get("Authorization")
+			if hdr == "" || !strings.HasPrefix(hdr, "Bearer ") {
+				unauthorized(w, r)
+				return
+			}
+			tokenStr := strings.TrimPrefix(hdr, "Bearer ")
+			claims := jwt.MapClaims{}
+			token, err := keys.Parse(tokenStr, claims)
+			if err != nil || !token.Valid {
+				unauthorized(w, r)
+				return
+			}
+			userID, ok := claims["user_id"].(string)
+			if !ok {
+				unauthorized(w, r)
+				return
+			}
+			sessionID, ok := claims["sid"].(string)
+			if !ok {
+				unauthorized(w, r)
+				return
+			}
+
+			// Сесія могла бути відкликана (logout) до закінчення терміну токена
+			active, err := sessions.IsActive(r.Context(), sessionID, userID)
+			if err != nil {
+				problem.Internal(w, r, fmt.Errorf("session lookup: %w", err))
+				return
+			}
+			if !active {
+				unauthorized(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, SessionIDKey, sessionID)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
-		if err != nil || !token.Valid {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		userID, ok := claims["user_id"].(string)
-		if !ok {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	}
+}
+
+// unauthorized відповідає 401 мовою запиту
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, http.StatusUnauthorized, "unauthorized")
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+const migrateUsage = `usage: moodtracker migrate <command> [arg]
+
+commands:
+  up [N]      apply all pending migrations, or only the next N
+  down [N]    roll back N migrations (default 1); "down all" rolls back everything
+  goto V      migrate up or down to version V
+  version     print the current version
+  force V     mark version V as applied and clean, without running it
+              (use after fixing a failed migration)
+
+DATABASE_URL selects the database and the migration dialect.`
+
+// runMigrate виконує підкоманду "migrate" над m і пише результат в out
+func runMigrate(m *migrate.Migrate, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	cmd, args := args[0], args[1:]
+
+	var err error
+	switch cmd {
+	case "up":
+		var n int
+		if n, err = optionalSteps(args); err == nil {
+			if n == 0 {
+				err = m.Up()
+			} else {
+				err = m.Steps(n)
+			}
+		}
+	case "down":
+		if len(args) == 1 && args[0] == "all" {
+			err = m.Down()
+			break
+		}
+		var n int
+		if n, err = optionalSteps(args); err == nil {
+			if n == 0 {
+				n = 1
+			}
+			err = m.Steps(-n)
+		}
+	case "goto":
+		var v uint64
+		if v, err = versionArg(args); err == nil {
+			err = m.Migrate(uint(v))
+		}
+	case "force":
+		var v uint64
+		if v, err = versionArg(args); err == nil {
+			err = m.Force(int(v))
+		}
+	case "version":
+		// нижче друкуємо версію в будь-якому разі
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", cmd, migrateUsage)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintln(out, "no migrations applied")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Fprintf(out, "version %d (dirty)\n", version)
+	} else {
+		fmt.Fprintf(out, "version %d\n", version)
+	}
+	return nil
+}
+
+func optionalSteps(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 || len(args) > 1 {
+		return 0, fmt.Errorf("expected a positive number of steps, got %q", args)
+	}
+	return n, nil
+}
+
+func versionArg(args []string) (uint64, error) {
+	if len(args) != 1 {
+		return 0, errors.New("expected exactly one version argument")
+	}
+	v, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q", args[0])
+	}
+	return v, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"moodtracker/db"
+)
+
+func TestRunMigrate(t *testing.T) {
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "mood.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	m, err := database.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := runMigrate(m, args, &out); err != nil {
+			t.Fatalf("migrate %v: %v", args, err)
+		}
+		return strings.TrimSpace(out.String())
+	}
+
+	if got := run("version"); got != "no migrations applied" {
+		t.Errorf("version: %q", got)
+	}
+	if got := run("up", "2"); got != "version 2" {
+		t.Errorf("up 2: %q", got)
+	}
+	if got := run("up"); got != "version 19" {
+		t.Errorf("up: %q", got)
+	}
+	// повторний up нічого не змінює і не є помилкою
+	if got := run("up"); got != "version 19" {
+		t.Errorf("up без змін: %q", got)
+	}
+	if got := run("down"); got != "version 18" {
+		t.Errorf("down: %q", got)
+	}
+	if got := run("goto", "1"); got != "version 1" {
+		t.Errorf("goto 1: %q", got)
+	}
+	if got := run("force", "3"); got != "version 3" {
+		t.Errorf("force 3: %q", got)
+	}
+	if got := run("down", "all"); got != "no migrations applied" {
+		t.Errorf("down all: %q", got)
+	}
+}
+
+func TestRunMigrate_BadArgs(t *testing.T) {
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "mood.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	m, err := database.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{nil, {"sideways"}, {"up", "-1"}, {"goto"}, {"force", "x"}} {
+		if err := runMigrate(m, args, &bytes.Buffer{}); err == nil {
+			t.Errorf("migrate %v: очікував помилку", args)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+// Package migrations вбудовує SQL-міграції в бінарник: по каталогу на кожен діалект
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
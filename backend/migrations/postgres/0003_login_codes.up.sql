@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS login_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email VARCHAR(255) NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Пошук останнього коду та ліміт видачі кодів на email
+CREATE INDEX IF NOT EXISTS idx_login_codes_email_created ON login_codes(email, created_at);
@@ -0,0 +1,6 @@
+-- Відкат можливий лише якщо ні в кого немає кількох записів на один день
+DROP INDEX IF EXISTS ux_mood_user_date_single;
+ALTER TABLE mood ADD CONSTRAINT ux_user_date UNIQUE (user_id, date);
+ALTER TABLE mood DROP COLUMN IF EXISTS one_per_day;
+ALTER TABLE mood DROP COLUMN IF EXISTS logged_at;
+ALTER TABLE users DROP COLUMN IF EXISTS entry_mode;
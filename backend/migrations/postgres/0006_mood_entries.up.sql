@@ -0,0 +1,12 @@
+-- Режим внесення настрою: один запис на день або кілька
+ALTER TABLE users ADD COLUMN IF NOT EXISTS entry_mode VARCHAR(10) NOT NULL DEFAULT 'single'
+    CHECK (entry_mode IN ('single', 'multiple'));
+
+-- Необов'язковий час запису в межах дня
+ALTER TABLE mood ADD COLUMN IF NOT EXISTS logged_at TIMESTAMP WITH TIME ZONE NULL;
+
+-- one_per_day = запис зроблено в режимі "один на день". Унікальність дня
+-- діє лише серед таких записів, тож у режимі "кілька на день" обмеження немає.
+ALTER TABLE mood ADD COLUMN IF NOT EXISTS one_per_day BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE mood DROP CONSTRAINT IF EXISTS ux_user_date;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_mood_user_date_single ON mood(user_id, date) WHERE one_per_day;
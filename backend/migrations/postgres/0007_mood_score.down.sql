@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_icons;
+ALTER TABLE mood DROP COLUMN IF EXISTS score;
@@ -0,0 +1,22 @@
+-- Місце настрою на шкалі 0..5. Старі записи з іконками поза каталогом лишаються з NULL
+ALTER TABLE mood ADD COLUMN IF NOT EXISTS score SMALLINT NULL CHECK (score BETWEEN 0 AND 5);
+
+UPDATE mood SET score = CASE icon
+    WHEN '😃' THEN 5
+    WHEN '😊' THEN 4
+    WHEN '😐' THEN 3
+    WHEN '😞' THEN 2
+    WHEN '😢' THEN 1
+    WHEN '😡' THEN 0
+END;
+
+-- Власні іконки користувача, прив'язані до тієї ж шкали
+CREATE TABLE IF NOT EXISTS user_icons (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    icon VARCHAR(50) NOT NULL,
+    label VARCHAR(50) NOT NULL,
+    score SMALLINT NOT NULL CHECK (score BETWEEN 0 AND 5),
+    color VARCHAR(7) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, icon)
+);
@@ -0,0 +1,7 @@
+-- "Заморожені" дні: пропуск такого дня не обриває серію записів
+CREATE TABLE IF NOT EXISTS streak_freezes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, date)
+);
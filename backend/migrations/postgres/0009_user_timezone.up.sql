@@ -0,0 +1,2 @@
+-- Часовий пояс користувача (IANA): від нього залежать "сьогодні", нагадування і звіти
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
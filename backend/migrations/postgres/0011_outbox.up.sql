@@ -0,0 +1,19 @@
+-- Черга вихідних повідомлень. Воркери забирають завдання через FOR UPDATE SKIP LOCKED,
+-- тож кілька інстансів не беруть одне завдання одночасно.
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY,
+    idempotency_key TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'sent', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Вибірка завдань, яким настав час
+CREATE INDEX IF NOT EXISTS idx_outbox_status_run_at ON outbox(status, run_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS push_subscriptions;
+DROP TABLE IF EXISTS notification_settings;
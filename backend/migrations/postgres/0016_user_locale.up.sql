@@ -0,0 +1,2 @@
+-- Мова бота, нагадувань і звітів користувача
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(8) NOT NULL DEFAULT 'uk';
@@ -0,0 +1,2 @@
+-- Номер версії запису для ETag і If-Match: зростає з кожним оновленням
+ALTER TABLE mood ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_mood_comment_search;
+DROP INDEX IF EXISTS idx_mood_user_date_id;
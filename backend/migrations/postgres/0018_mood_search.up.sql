@@ -0,0 +1,5 @@
+-- Сторінки GET /mood ідуть за ключем (date, id)
+CREATE INDEX IF NOT EXISTS idx_mood_user_date_id ON mood(user_id, date, id);
+
+-- Пошук по коментарях: вираз індексу збігається з умовою в запиті
+CREATE INDEX IF NOT EXISTS idx_mood_comment_search ON mood USING GIN (to_tsvector('simple', COALESCE(comment, '')));
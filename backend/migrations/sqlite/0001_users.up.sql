@@ -0,0 +1,9 @@
+-- Часові мітки зберігаються текстом у UTC у форматі драйвера (_time_format=sqlite),
+-- тож їх можна порівнювати як рядки
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    telegram_chat_id INTEGER UNIQUE NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
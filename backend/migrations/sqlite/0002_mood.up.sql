@@ -0,0 +1,14 @@
+-- date зберігається як 'YYYY-MM-DD'
+CREATE TABLE IF NOT EXISTS mood (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    icon TEXT NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT ux_user_date UNIQUE (user_id, date)
+);
+
+-- Індекс для швидкого пошуку за датою
+CREATE INDEX IF NOT EXISTS idx_mood_user_date ON mood(user_id, date);
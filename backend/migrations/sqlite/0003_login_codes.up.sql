@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS login_codes (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+-- Пошук останнього коду та ліміт видачі кодів на email
+CREATE INDEX IF NOT EXISTS idx_login_codes_email_created ON login_codes(email, created_at);
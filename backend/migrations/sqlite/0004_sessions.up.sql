@@ -0,0 +1,14 @@
+-- Refresh-токени: кожна ротація додає рядок у ту ж сім'ю (family_id = сесія/пристрій)
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    family_id TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_family ON sessions(family_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
@@ -0,0 +1,19 @@
+-- Відкат можливий лише якщо ні в кого немає кількох записів на один день
+CREATE TABLE mood_old (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    icon TEXT NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT ux_user_date UNIQUE (user_id, date)
+);
+INSERT INTO mood_old (id, user_id, date, icon, comment, created_at, updated_at)
+    SELECT id, user_id, date, icon, comment, created_at, updated_at FROM mood;
+DROP TABLE mood;
+ALTER TABLE mood_old RENAME TO mood;
+
+CREATE INDEX IF NOT EXISTS idx_mood_user_date ON mood(user_id, date);
+
+ALTER TABLE users DROP COLUMN entry_mode;
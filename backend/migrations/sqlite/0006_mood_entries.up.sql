@@ -0,0 +1,24 @@
+-- Режим внесення настрою: один запис на день або кілька
+ALTER TABLE users ADD COLUMN entry_mode TEXT NOT NULL DEFAULT 'single'
+    CHECK (entry_mode IN ('single', 'multiple'));
+
+-- SQLite не вміє видаляти обмеження таблиці, тож перебудовуємо mood без ux_user_date.
+-- one_per_day = запис зроблено в режимі "один на день"; унікальність дня діє лише серед таких записів.
+CREATE TABLE mood_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    icon TEXT NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    logged_at TIMESTAMP NULL,
+    one_per_day BOOLEAN NOT NULL DEFAULT 1
+);
+INSERT INTO mood_new (id, user_id, date, icon, comment, created_at, updated_at)
+    SELECT id, user_id, date, icon, comment, created_at, updated_at FROM mood;
+DROP TABLE mood;
+ALTER TABLE mood_new RENAME TO mood;
+
+CREATE INDEX IF NOT EXISTS idx_mood_user_date ON mood(user_id, date);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_mood_user_date_single ON mood(user_id, date) WHERE one_per_day;
@@ -0,0 +1,7 @@
+-- "Заморожені" дні: пропуск такого дня не обриває серію записів
+CREATE TABLE IF NOT EXISTS streak_freezes (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (user_id, date)
+);
@@ -0,0 +1,2 @@
+-- Часовий пояс користувача (IANA): від нього залежать "сьогодні", нагадування і звіти
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,11 @@
+-- Розклад нагадувань і звітів користувача. Хто не має рядка – отримує типовий розклад
+-- (щодня о 20:00, звіт щотижня). Списки зберігаються через кому: "08:00,20:00", "1,2,3,4,5".
+CREATE TABLE IF NOT EXISTS reminder_settings (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    times TEXT NOT NULL DEFAULT '20:00',
+    weekdays TEXT NOT NULL DEFAULT '0,1,2,3,4,5,6',
+    quiet_start TEXT NOT NULL DEFAULT '',
+    quiet_end TEXT NOT NULL DEFAULT '',
+    report_frequency TEXT NOT NULL DEFAULT 'weekly' CHECK (report_frequency IN ('weekly', 'monthly', 'off')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
@@ -0,0 +1,7 @@
+-- Оренди для вибору лідера: фонові завдання (планувальник нагадувань) виконує лише
+-- інстанс, що тримає оренду; якщо він зникне, оренду після expires_at забере інший.
+CREATE TABLE IF NOT EXISTS leases (
+    name TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
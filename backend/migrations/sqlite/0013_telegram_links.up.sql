@@ -0,0 +1,7 @@
+-- Одноразові токени прив'язки Telegram: застосунок видає посилання t.me/<bot>?start=<token>,
+-- а бот за командою /start <token> прив'язує чат, з якого її отримав.
+CREATE TABLE IF NOT EXISTS telegram_links (
+    token VARCHAR(64) PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,8 @@
+-- Оброблені оновлення Telegram: webhook і long polling пропускають повторну доставку того самого update_id.
+-- Telegram зберігає оновлення не довше доби, тож старіші позначки видаляються.
+CREATE TABLE IF NOT EXISTS telegram_updates (
+    update_id INTEGER PRIMARY KEY,
+    received_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_telegram_updates_received_at ON telegram_updates(received_at);
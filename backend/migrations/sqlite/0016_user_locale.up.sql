@@ -0,0 +1,2 @@
+-- Мова бота, нагадувань і звітів користувача
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT 'uk';
@@ -0,0 +1 @@
+ALTER TABLE mood DROP COLUMN version;
@@ -0,0 +1,2 @@
+-- Номер версії запису для ETag і If-Match: зростає з кожним оновленням
+ALTER TABLE mood ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
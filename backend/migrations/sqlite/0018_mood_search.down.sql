@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS mood_fts_delete;
+DROP TRIGGER IF EXISTS mood_fts_update;
+DROP TRIGGER IF EXISTS mood_fts_insert;
+DROP TABLE IF EXISTS mood_fts;
+DROP INDEX IF EXISTS idx_mood_user_date_id;
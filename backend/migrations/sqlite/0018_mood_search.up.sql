@@ -0,0 +1,17 @@
+-- Сторінки GET /mood ідуть за ключем (date, id)
+CREATE INDEX IF NOT EXISTS idx_mood_user_date_id ON mood(user_id, date, id);
+
+-- Пошук по коментарях: FTS5 зберігає id запису, бо rowid таблиці mood може змінитися після VACUUM.
+-- unicode61 зводить регістр і для кирилиці.
+CREATE VIRTUAL TABLE IF NOT EXISTS mood_fts USING fts5(id UNINDEXED, comment, tokenize = 'unicode61 remove_diacritics 2');
+INSERT INTO mood_fts (id, comment) SELECT id, COALESCE(comment, '') FROM mood;
+
+CREATE TRIGGER IF NOT EXISTS mood_fts_insert AFTER INSERT ON mood BEGIN
+    INSERT INTO mood_fts (id, comment) VALUES (new.id, COALESCE(new.comment, ''));
+END;
+CREATE TRIGGER IF NOT EXISTS mood_fts_update AFTER UPDATE OF comment ON mood BEGIN
+    UPDATE mood_fts SET comment = COALESCE(new.comment, '') WHERE id = old.id;
+END;
+CREATE TRIGGER IF NOT EXISTS mood_fts_delete AFTER DELETE ON mood BEGIN
+    DELETE FROM mood_fts WHERE id = old.id;
+END;
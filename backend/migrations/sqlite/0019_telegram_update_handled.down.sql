@@ -0,0 +1 @@
+ALTER TABLE telegram_updates DROP COLUMN handled_at;
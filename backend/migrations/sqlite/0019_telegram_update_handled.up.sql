@@ -0,0 +1,4 @@
+-- Час, коли оновлення Telegram оброблено до кінця. Поки його немає, оновлення лише взяте
+-- в обробку: якщо процес упав, повторну доставку обробить інший після закінчення оренди.
+ALTER TABLE telegram_updates ADD COLUMN handled_at TIMESTAMP;
+UPDATE telegram_updates SET handled_at = received_at WHERE handled_at IS NULL;
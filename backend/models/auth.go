@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// LoginCode – одноразовий код входу, надісланий на email (зберігається лише хеш)
+type LoginCode struct {
+	ID        string     `db:"id"`
+	Email     string     `db:"email"`
+	CodeHash  string     `db:"code_hash"`
+	Attempts  int        `db:"attempts"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// Session – один refresh-токен; ротовані токени однієї сесії мають спільний FamilyID
+type Session struct {
+	ID        string     `db:"id"`
+	FamilyID  string     `db:"family_id"`
+	UserID    string     `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RotatedAt *time.Time `db:"rotated_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// OIDCState – незавершений вхід через SSO
+type OIDCState struct {
+	State        string    `db:"state"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// Identity – обліковий запис зовнішнього провайдера, прив'язаний до користувача
+type Identity struct {
+	Issuer    string    `db:"issuer"`
+	Subject   string    `db:"subject"`
+	UserID    string    `db:"user_id"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// TelegramLink – одноразовий токен для прив'язки чату через t.me/<bot>?start=<token>
+type TelegramLink struct {
+	Token     string    `db:"token" json:"token"`
+	UserID    string    `db:"user_id" json:"-"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
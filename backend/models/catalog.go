@@ -0,0 +1,65 @@
+package models
+
+import "moodtracker/i18n"
+
+// Межі числової шкали настрою (0 – найгірший, 5 – найкращий)
+const (
+	MinScore = 0
+	MaxScore = 5
+)
+
+// MoodIcon – іконка настрою, її назва, місце на шкалі та колір для графіків
+type MoodIcon struct {
+	Icon   string `db:"icon" json:"icon"`
+	Label  string `db:"label" json:"label"`
+	Key    string `db:"-" json:"-"` // ключ назви в i18n для вбудованої іконки; у власних – лише Label
+	Score  int    `db:"score" json:"score"`
+	Color  string `db:"color" json:"color"`
+	Custom bool   `db:"-" json:"custom,omitempty"` // власна іконка користувача
+}
+
+// Localize повертає копію mi з назвою мовою l; назву власної іконки користувач задав сам
+func (mi MoodIcon) Localize(l i18n.Locale) MoodIcon {
+	if mi.Key != "" {
+		mi.Label = i18n.T(l, mi.Key)
+	}
+	return mi
+}
+
+// Catalog – вбудований каталог настроїв, від найкращого до найгіршого.
+// Назви беруться з i18n за Key: див. LocalCatalog.
+var Catalog = []MoodIcon{
+	{Icon: "😃", Key: "icon.great", Score: 5, Color: "#2e7d32"},
+	{Icon: "😊", Key: "icon.good", Score: 4, Color: "#7cb342"},
+	{Icon: "😐", Key: "icon.neutral", Score: 3, Color: "#fdd835"},
+	{Icon: "😞", Key: "icon.sad", Score: 2, Color: "#fb8c00"},
+	{Icon: "😢", Key: "icon.bad", Score: 1, Color: "#e53935"},
+	{Icon: "😡", Key: "icon.angry", Score: 0, Color: "#b71c1c"},
+}
+
+// LocalCatalog – вбудований каталог з назвами мовою l
+func LocalCatalog(l i18n.Locale) []MoodIcon {
+	icons := make([]MoodIcon, len(Catalog))
+	for i, mi := range Catalog {
+		icons[i] = mi.Localize(l)
+	}
+	return icons
+}
+
+// CatalogIcon шукає icon у вбудованому каталозі
+func CatalogIcon(icon string) (MoodIcon, bool) {
+	for _, mi := range Catalog {
+		if mi.Icon == icon {
+			return mi, true
+		}
+	}
+	return MoodIcon{}, false
+}
+
+// IconName – icon разом з назвою мовою l, якщо іконка з вбудованого каталогу: "😃 Чудово"
+func IconName(l i18n.Locale, icon string) string {
+	if mi, ok := CatalogIcon(icon); ok {
+		return icon + " " + mi.Localize(l).Label
+	}
+	return icon
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Стан завдання в черзі вихідних повідомлень
+const (
+	JobPending = "pending" // чекає на run_at
+	JobSending = "sending" // узяте воркером до locked_until
+	JobSent    = "sent"
+	JobDead    = "dead" // вичерпано спроби або помилка, яку повтор не виправить
+)
+
+// Види завдань – канали доставки сповіщень
+const (
+	JobTelegram = "telegram"
+	JobEmail    = "email"
+	JobWebhook  = "webhook" // POST на адресу, яку вказав користувач
+	JobWebPush  = "webpush"
+)
+
+// Job – повідомлення в черзі outbox
+type Job struct {
+	ID             string     `db:"id" json:"id"`
+	IdempotencyKey string     `db:"idempotency_key" json:"idempotency_key"` // те саме повідомлення не стає в чергу двічі
+	Kind           string     `db:"kind" json:"kind"`
+	Recipient      string     `db:"recipient" json:"recipient"` // адреса в каналі (chat_id, email...); за нею обмежується частота
+	Payload        string     `db:"payload" json:"payload"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"` // невдалі спроби доставки
+	RunAt          time.Time  `db:"run_at" json:"run_at"`
+	LockedUntil    *time.Time `db:"locked_until" json:"locked_until,omitempty"`
+	LastError      string     `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
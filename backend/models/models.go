@@ -1,13 +1,58 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"moodtracker/i18n"
+)
 
 type Mood struct {
-	ID        string    `db:"id" json:"id"`
-	UserID    string    `db:"user_id" json:"user_id"`
-	Date      time.Time `db:"date" json:"date"`
-	Icon      string    `db:"icon" json:"icon"`
-	Comment   string    `db:"comment" json:"comment"`
+	ID        string     `db:"id" json:"id"`
+	UserID    string     `db:"user_id" json:"user_id"`
+	Date      time.Time  `db:"date" json:"date"`
+	LoggedAt  *time.Time `db:"logged_at" json:"logged_at,omitempty"` // час запису в межах дня, якщо вказано
+	Icon      string     `db:"icon" json:"icon"`
+	Score     *int       `db:"score" json:"score,omitempty"` // місце icon на шкалі; nil для старих записів з іконками поза каталогом
+	Comment   string     `db:"comment" json:"comment"`
+	OnePerDay bool       `db:"one_per_day" json:"-"`   // запис зроблено в режимі EntryModeSingle
+	Version   int        `db:"version" json:"version"` // зростає з кожним оновленням; з нього будується ETag
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Режими внесення настрою
+const (
+	EntryModeSingle   = "single"   // один запис на день
+	EntryModeMultiple = "multiple" // кілька записів на день (ранок, вечір...)
+)
+
+// UserSettings – налаштування, які користувач змінює сам
+type UserSettings struct {
+	EntryMode string      `db:"entry_mode" json:"entry_mode"`
+	Timezone  string      `db:"timezone" json:"timezone"` // IANA, наприклад "Europe/Kyiv"
+	Locale    i18n.Locale `db:"locale" json:"locale"`     // мова бота, нагадувань і звітів
+}
+
+// Location повертає часовий пояс користувача; невідомий або порожній – UTC
+func (s UserSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Today – поточна дата користувача (як значення колонки DATE)
+func (s UserSettings) Today(now time.Time) time.Time {
+	y, m, d := now.In(s.Location()).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+type User struct {
+	ID             string `db:"id" json:"id"`
+	Email          string `db:"email" json:"email"`
+	TelegramChatID *int64 `db:"telegram_chat_id" json:"telegram_chat_id,omitempty"`
+	UserSettings
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
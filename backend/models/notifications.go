@@ -0,0 +1,87 @@
+package models
+
+import (
+	"net/url"
+	"time"
+
+	"moodtracker/i18n"
+)
+
+// Види сповіщень
+const (
+	NotifyReminder = "reminder"
+	NotifyReport   = "report"
+)
+
+// NotificationSettings – якими каналами (models.Job*) користувач отримує кожен вид сповіщень
+type NotificationSettings struct {
+	UserID        string   `json:"-"`
+	Reminders     []string `json:"reminders"`
+	Reports       []string `json:"reports"`
+	WebhookURL    string   `json:"webhook_url,omitempty"`
+	WebhookSecret string   `json:"webhook_secret,omitempty"` // ключ HMAC-підпису запитів; генерує сервер
+}
+
+// DefaultNotificationSettings – усе в Telegram, як до появи інших каналів
+func DefaultNotificationSettings(userID string) NotificationSettings {
+	return NotificationSettings{
+		UserID:    userID,
+		Reminders: []string{JobTelegram},
+		Reports:   []string{JobTelegram},
+	}
+}
+
+// Channels повертає канали для виду сповіщень kind
+func (n NotificationSettings) Channels(kind string) []string {
+	switch kind {
+	case NotifyReminder:
+		return n.Reminders
+	case NotifyReport:
+		return n.Reports
+	default:
+		return nil
+	}
+}
+
+// Validate перевіряє канали і адресу webhook
+func (n *NotificationSettings) Validate() error {
+	webhook := false
+	for _, list := range [][]string{n.Reminders, n.Reports} {
+		seen := map[string]bool{}
+		for _, ch := range list {
+			switch ch {
+			case JobTelegram, JobEmail, JobWebPush:
+			case JobWebhook:
+				webhook = true
+			default:
+				return i18n.NewError("unknown_channel", ch)
+			}
+			if seen[ch] {
+				return i18n.NewError("duplicate_channel", ch)
+			}
+			seen[ch] = true
+		}
+	}
+
+	if n.WebhookURL == "" {
+		if webhook {
+			return i18n.NewError("webhook_url_required")
+		}
+		return nil
+	}
+	u, err := url.Parse(n.WebhookURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return i18n.NewError("invalid_webhook_url")
+	}
+	return nil
+}
+
+// PushSubscription – підписка браузера на Web Push (PushSubscription.toJSON())
+type PushSubscription struct {
+	ID        string    `db:"id" json:"id"`
+	UserID    string    `db:"user_id" json:"-"`
+	Endpoint  string    `db:"endpoint" json:"endpoint"`
+	P256dh    string    `db:"p256dh" json:"-"` // відкритий ключ браузера, base64url
+	Auth      string    `db:"auth" json:"-"`   // секрет автентифікації, base64url
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
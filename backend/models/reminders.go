@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"moodtracker/i18n"
+)
+
+// Частота звіту в Telegram
+const (
+	ReportWeekly  = "weekly"  // щопонеділка за попередні 7 днів
+	ReportMonthly = "monthly" // першого числа за попередній місяць
+	ReportOff     = "off"
+)
+
+// Обмеження налаштувань нагадувань
+const (
+	MaxReminderTimes = 6
+	DefaultReportAt  = "09:00" // час звіту, якщо він не припадає на тихі години
+)
+
+// ReminderSettings – коли користувач отримує нагадування і звіти (у своєму часовому поясі)
+type ReminderSettings struct {
+	UserID          string   `json:"-"`
+	Times           []string `json:"times"`    // "HH:MM"; порожній список вимикає нагадування
+	Weekdays        []int    `json:"weekdays"` // 0 – неділя … 6 – субота, як time.Weekday; порожній – щодня
+	QuietStart      string   `json:"quiet_start,omitempty"`
+	QuietEnd        string   `json:"quiet_end,omitempty"`
+	ReportFrequency string   `json:"report_frequency"`
+}
+
+// DefaultReminderSettings – нагадування щодня о 20:00 і щотижневий звіт
+func DefaultReminderSettings(userID string) ReminderSettings {
+	return ReminderSettings{
+		UserID:          userID,
+		Times:           []string{"20:00"},
+		Weekdays:        []int{0, 1, 2, 3, 4, 5, 6},
+		ReportFrequency: ReportWeekly,
+	}
+}
+
+// clock розбирає "HH:MM" у хвилини від півночі; годину можна вказати однією цифрою ("9:00")
+func clock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, i18n.NewError("invalid_time", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// normalize розбирає s і повертає його у формі "HH:MM": "9:00" -> "09:00"
+func normalize(s string) (string, error) {
+	m, err := clock(s)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d:%02d", m/60, m%60), nil
+}
+
+// Validate перевіряє налаштування і приводить їх до збережуваної форми: час – "HH:MM"
+// (дублікати шукаються вже в цій формі), times і weekdays впорядковані, а порожній
+// weekdays означає "щодня" і розгортається в усі сім днів
+func (r *ReminderSettings) Validate() error {
+	if len(r.Times) > MaxReminderTimes {
+		return i18n.NewPluralError("too_many_times", MaxReminderTimes)
+	}
+	seen := map[string]bool{}
+	for i, t := range r.Times {
+		norm, err := normalize(t)
+		if err != nil {
+			return err
+		}
+		if seen[norm] {
+			return i18n.NewError("duplicate_reminder_time", norm)
+		}
+		seen[norm] = true
+		r.Times[i] = norm
+	}
+	sort.Strings(r.Times)
+
+	if len(r.Weekdays) == 0 {
+		r.Weekdays = []int{0, 1, 2, 3, 4, 5, 6}
+	}
+	days := map[int]bool{}
+	for _, d := range r.Weekdays {
+		if d < 0 || d > 6 {
+			return i18n.NewError("invalid_weekday")
+		}
+		if days[d] {
+			return i18n.NewError("duplicate_weekday", d)
+		}
+		days[d] = true
+	}
+	sort.Ints(r.Weekdays)
+
+	if (r.QuietStart == "") != (r.QuietEnd == "") {
+		return i18n.NewError("quiet_hours_pair")
+	}
+	if r.QuietStart != "" {
+		start, err := normalize(r.QuietStart)
+		if err != nil {
+			return err
+		}
+		end, err := normalize(r.QuietEnd)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return i18n.NewError("quiet_hours_empty")
+		}
+		r.QuietStart, r.QuietEnd = start, end
+	}
+
+	switch r.ReportFrequency {
+	case ReportWeekly, ReportMonthly, ReportOff:
+		return nil
+	default:
+		return i18n.NewError("invalid_report_frequency")
+	}
+}
+
+// quiet повідомляє, чи припадає хвилина дня m на тихі години [QuietStart, QuietEnd)
+func (r ReminderSettings) quiet(m int) bool {
+	if r.QuietStart == "" {
+		return false
+	}
+	start, _ := clock(r.QuietStart)
+	end, _ := clock(r.QuietEnd)
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end // тихі години через північ, наприклад 22:00–07:00
+}
+
+// RemindAt повідомляє, чи треба нагадати о локальному часі local
+func (r ReminderSettings) RemindAt(local time.Time) bool {
+	m := local.Hour()*60 + local.Minute()
+	if r.quiet(m) {
+		return false
+	}
+	dayOK := false
+	for _, d := range r.Weekdays {
+		dayOK = dayOK || time.Weekday(d) == local.Weekday()
+	}
+	if !dayOK {
+		return false
+	}
+	for _, t := range r.Times {
+		if c, _ := clock(t); c == m {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportPeriod повертає період звіту, якщо о локальному часі local його треба надіслати.
+// Звіт іде о 09:00, а якщо це тихі години – одразу після них.
+func (r ReminderSettings) ReportPeriod(local time.Time) (from, to time.Time, ok bool) {
+	at, _ := clock(DefaultReportAt)
+	if r.quiet(at) {
+		at, _ = clock(r.QuietEnd)
+	}
+	if local.Hour()*60+local.Minute() != at {
+		return time.Time{}, time.Time{}, false
+	}
+
+	y, mon, d := local.Date()
+	today := time.Date(y, mon, d, 0, 0, 0, 0, time.UTC)
+	switch {
+	case r.ReportFrequency == ReportWeekly && local.Weekday() == time.Monday:
+		return today.AddDate(0, 0, -7), today.AddDate(0, 0, -1), true
+	case r.ReportFrequency == ReportMonthly && d == 1:
+		return today.AddDate(0, -1, 0), today.AddDate(0, 0, -1), true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"context"
+
+	"moodtracker/mailer"
+	"moodtracker/models"
+)
+
+// Email надсилає сповіщення листом на адресу акаунта
+type Email struct {
+	Mailer mailer.Mailer
+}
+
+func (e *Email) Channel() string { return models.JobEmail }
+
+func (e *Email) Recipients(_ context.Context, u models.User, _ models.NotificationSettings) ([]string, error) {
+	return []string{u.Email}, nil
+}
+
+func (e *Email) Send(ctx context.Context, to string, msg Message) error {
+	return e.Mailer.Send(ctx, mailer.Message{To: to, Subject: msg.Title, Body: msg.Body})
+}
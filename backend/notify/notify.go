@@ -0,0 +1,125 @@
+// Package notify доставляє сповіщення (нагадування, звіти) тими каналами, які обрав
+// користувач. Кожен канал реалізує Notifier; Dispatcher ставить сповіщення в чергу
+// outbox окремим завданням на кожну адресу, а воркер outbox викликає Notifier.Send.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"moodtracker/models"
+	"moodtracker/outbox"
+	"moodtracker/repository"
+)
+
+// Message – сповіщення незалежно від каналу
+type Message struct {
+	Type  string `json:"type"`  // models.NotifyReminder або models.NotifyReport
+	Title string `json:"title"` // тема листа, заголовок push
+	Body  string `json:"body"`  // повний текст; Telegram надсилає лише його
+}
+
+// Notifier доставляє сповіщення одним каналом
+type Notifier interface {
+	// Channel – назва каналу, вона ж вид завдання outbox (models.Job*)
+	Channel() string
+	// Recipients повертає адреси користувача в цьому каналі: chat_id, email, id підписки.
+	// Порожній список – канал для користувача не налаштовано.
+	Recipients(ctx context.Context, u models.User, s models.NotificationSettings) ([]string, error)
+	// Send надсилає msg на адресу recipient; помилки позначаються через outbox.Permanent і outbox.RetryAfter
+	Send(ctx context.Context, recipient string, msg Message) error
+}
+
+// Dispatcher ставить сповіщення в чергу каналами з налаштувань користувача
+type Dispatcher struct {
+	Jobs      repository.JobRepository
+	Settings  repository.NotificationRepository
+	notifiers map[string]Notifier
+}
+
+func NewDispatcher(jobs repository.JobRepository, settings repository.NotificationRepository, notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{Jobs: jobs, Settings: settings, notifiers: map[string]Notifier{}}
+	for _, n := range notifiers {
+		d.notifiers[n.Channel()] = n
+	}
+	return d
+}
+
+// Channels – канали, увімкнені на сервері
+func (d *Dispatcher) Channels() []string {
+	out := make([]string, 0, len(d.notifiers))
+	for ch := range d.notifiers {
+		out = append(out, ch)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Notify ставить msg у чергу для кожного обраного каналу й адреси користувача.
+// Повторний виклик з тим самим key нічого не додає.
+func (d *Dispatcher) Notify(ctx context.Context, u models.User, key string, msg Message) error {
+	s, err := d.Settings.Get(ctx, u.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		def := models.DefaultNotificationSettings(u.ID)
+		s = &def
+	} else if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, ch := range s.Channels(msg.Type) {
+		n, ok := d.notifiers[ch]
+		if !ok {
+			continue // канал вимкнено на сервері (немає токена бота, ключів VAPID...)
+		}
+		recipients, err := n.Recipients(ctx, u, *s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s recipients: %w", ch, err))
+			continue
+		}
+		for _, to := range recipients {
+			if err := outbox.Enqueue(ctx, d.Jobs, key+":"+ch+":"+to, ch, to, string(payload)); err != nil {
+				errs = append(errs, fmt.Errorf("enqueue %s: %w", ch, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Deliver – функції доставки для outbox.Worker за видом завдання
+func (d *Dispatcher) Deliver() map[string]outbox.DeliverFunc {
+	out := map[string]outbox.DeliverFunc{}
+	for ch, n := range d.notifiers {
+		out[ch] = func(ctx context.Context, j models.Job) error {
+			var msg Message
+			if err := json.Unmarshal([]byte(j.Payload), &msg); err != nil {
+				// завдання, поставлені до появи каналів, містять лише текст для Telegram
+				msg = Message{Body: j.Payload}
+			}
+			return n.Send(ctx, j.Recipient, msg)
+		}
+	}
+	return out
+}
+
+// statusError переводить відповідь HTTP-каналу в помилку для outbox: 408, 429 і 5xx
+// повторюються з затримкою, решта 4xx – повтор не допоможе. 429 навмисно не стає
+// outbox.RetryAfter: той пригальмовує всю чергу, а адресу тут обирає користувач.
+func statusError(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	err := fmt.Errorf("unexpected status %s", resp.Status)
+	if code := resp.StatusCode; code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500 {
+		return err
+	}
+	return outbox.Permanent(err)
+}
@@ -0,0 +1,342 @@
+package notify
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"moodtracker/models"
+	"moodtracker/outbox"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
+)
+
+// recorder запам'ятовує надіслані повідомлення
+type recorder struct {
+	channel string
+	sent    []string
+	err     error
+}
+
+func (r *recorder) Channel() string { return r.channel }
+
+func (r *recorder) Recipients(_ context.Context, u models.User, _ models.NotificationSettings) ([]string, error) {
+	return []string{u.ID + "@" + r.channel}, nil
+}
+
+func (r *recorder) Send(_ context.Context, to string, msg Message) error {
+	r.sent = append(r.sent, to+" "+msg.Title+" "+msg.Body)
+	return r.err
+}
+
+func claim(t *testing.T, jobs repository.JobRepository) []models.Job {
+	t.Helper()
+	list, err := jobs.Claim(context.Background(), time.Now().Add(time.Hour), 100, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestDispatcher(t *testing.T) {
+	repos := memory.New()
+	ctx := context.Background()
+	tg, mail := &recorder{channel: models.JobTelegram}, &recorder{channel: models.JobEmail}
+	d := NewDispatcher(repos.Jobs, repos.Notifications, tg, mail)
+	u := models.User{ID: "u1", Email: "u1@example.com"}
+
+	if got := d.Channels(); len(got) != 2 || got[0] != models.JobEmail || got[1] != models.JobTelegram {
+		t.Errorf("неправильні канали: %v", got)
+	}
+
+	// типові налаштування – лише Telegram
+	msg := Message{Type: models.NotifyReminder, Title: "Нагадування", Body: "текст"}
+	if err := d.Notify(ctx, u, "k1", msg); err != nil {
+		t.Fatal(err)
+	}
+	if jobs := claim(t, repos.Jobs); len(jobs) != 1 || jobs[0].Kind != models.JobTelegram || jobs[0].Recipient != "u1@telegram" {
+		t.Fatalf("очікував одне завдання Telegram, отримав %+v", jobs)
+	}
+
+	// вебхук не зареєстровано на сервері – канал пропускається; повторний key не дублює
+	err := repos.Notifications.Save(ctx, &models.NotificationSettings{
+		UserID: "u1", Reminders: []string{models.JobEmail, models.JobWebhook}, Reports: []string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := d.Notify(ctx, u, "k2", msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Notify(ctx, u, "k3", Message{Type: models.NotifyReport, Body: "звіт"}); err != nil {
+		t.Fatal(err)
+	}
+	jobs := claim(t, repos.Jobs)
+	if len(jobs) != 1 || jobs[0].Kind != models.JobEmail {
+		t.Fatalf("очікував одне завдання email, отримав %+v", jobs)
+	}
+
+	// воркер outbox передає повідомлення каналу вже розібраним
+	deliver := d.Deliver()
+	if err := deliver[models.JobEmail](ctx, jobs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(mail.sent) != 1 || mail.sent[0] != "u1@email Нагадування текст" {
+		t.Errorf("неправильний лист: %q", mail.sent)
+	}
+	// старі завдання Telegram містять лише текст
+	if err := deliver[models.JobTelegram](ctx, models.Job{Recipient: "42", Payload: "старий текст"}); err != nil || tg.sent[0] != "42  старий текст" {
+		t.Errorf("старе завдання: %q, %v", tg.sent, err)
+	}
+}
+
+func TestWebhook_Send(t *testing.T) {
+	repos := memory.New()
+	ctx := context.Background()
+	var got struct {
+		body      []byte
+		signature string
+	}
+	status := http.StatusOK
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.body, _ = io.ReadAll(r.Body)
+		got.signature = r.Header.Get(SignatureHeader)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(repos.Notifications)
+	w.Client = srv.Client()
+	msg := Message{Type: models.NotifyReport, Title: "Звіт", Body: "текст"}
+
+	if err := w.Send(ctx, "u1", msg); !outbox.IsPermanent(err) {
+		t.Errorf("без налаштувань: очікував постійну помилку, отримав %v", err)
+	}
+
+	s := &models.NotificationSettings{UserID: "u1", Reports: []string{models.JobWebhook}, WebhookURL: srv.URL, WebhookSecret: "secret"}
+	if err := repos.Notifications.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if to, _ := w.Recipients(ctx, models.User{ID: "u1"}, *s); len(to) != 1 || to[0] != "u1" {
+		t.Errorf("неправильні адреси: %v", to)
+	}
+	if err := w.Send(ctx, "u1", msg); err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(got.body, &payload); err != nil || payload["title"] != "Звіт" || payload["type"] != models.NotifyReport || payload["sent_at"] == nil {
+		t.Errorf("неправильне тіло: %s", got.body)
+	}
+	if got.signature != Sign("secret", got.body) {
+		t.Errorf("неправильний підпис: %q", got.signature)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := w.Send(ctx, "u1", msg); err == nil || outbox.IsPermanent(err) {
+		t.Errorf("503: очікував тимчасову помилку, отримав %v", err)
+	}
+	status = http.StatusNotFound
+	if err := w.Send(ctx, "u1", msg); !outbox.IsPermanent(err) {
+		t.Errorf("404: очікував постійну помилку, отримав %v", err)
+	}
+}
+
+func TestPublicClient_RejectsLocalAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := publicClient().Get(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "is not public") {
+		t.Errorf("очікував відмову для loopback, отримав %v", err)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"8.8.8.8":                true,
+		"2606:4700::1111":        true,
+		"::ffff:8.8.8.8":         true,
+		"127.0.0.1":              false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"0.0.0.0":                false,
+		"100.64.0.1":             false,
+		"100.127.255.254":        false,
+		"192.0.0.8":              false,
+		"198.18.0.1":             false,
+		"198.19.255.255":         false,
+		"::1":                    false,
+		"fd00::1":                false,
+		"fe80::1":                false,
+		"64:ff9b::a00:1":         false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:10.0.0.1":        false,
+		"::ffff:192.168.0.1":     false,
+		"::ffff:169.254.169.254": false,
+		"::ffff:100.64.0.1":      false,
+		"::ffff:198.18.0.1":      false,
+	} {
+		if got := isPublic(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isPublic(%s) = %v, очікував %v", addr, got, want)
+		}
+	}
+}
+
+// browser імітує ключі підписки браузера
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return &browser{key: key, auth: auth}
+}
+
+func (b *browser) subscription(id, userID, endpoint string) models.PushSubscription {
+	return models.PushSubscription{
+		ID: id, UserID: userID, Endpoint: endpoint, CreatedAt: time.Now(),
+		P256dh: base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:   base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+// decrypt – розшифрування на боці браузера за RFC 8291
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt, rs, idlen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	asBytes, ciphertext := body[21:21+idlen], body[21+idlen:]
+	if rs != recordSize {
+		t.Fatalf("неправильний rs: %d", rs)
+	}
+	asPublic, err := ecdh.P256().NewPublicKey(asBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := b.key.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := "WebPush: info\x00" + string(b.key.PublicKey().Bytes()) + string(asBytes)
+	ikm, _ := hkdf.Key(sha256.New, shared, b.auth, info, 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("не вдалося розшифрувати: %v", err)
+	}
+	if plain[len(plain)-1] != 0x02 {
+		t.Fatalf("немає роздільника останнього запису")
+	}
+	return plain[:len(plain)-1]
+}
+
+func TestWebPush_Send(t *testing.T) {
+	repos := memory.New()
+	ctx := context.Background()
+	b := newBrowser(t)
+
+	var got *http.Request
+	var body []byte
+	status := http.StatusCreated
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, body = r, nil
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	serverKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	p, err := NewWebPush(repos.PushSubs, VAPIDConfig{PrivateKey: base64.RawURLEncoding.EncodeToString(serverKey.Bytes()), Subject: "mailto:admin@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Client = srv.Client()
+	sub := b.subscription("sub-1", "u1", srv.URL+"/push/abc")
+	if err := repos.PushSubs.Save(ctx, &sub); err != nil {
+		t.Fatal(err)
+	}
+	if to, _ := p.Recipients(ctx, models.User{ID: "u1"}, models.NotificationSettings{}); len(to) != 1 || to[0] != "sub-1" {
+		t.Errorf("неправильні адреси: %v", to)
+	}
+
+	msg := Message{Type: models.NotifyReminder, Title: "Нагадування", Body: "Не забудь"}
+	if err := p.Send(ctx, "sub-1", msg); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("Content-Encoding") != "aes128gcm" || got.Header.Get("TTL") != "86400" {
+		t.Errorf("неправильні заголовки: %v", got.Header)
+	}
+	var decoded Message
+	if err := json.Unmarshal(b.decrypt(t, body), &decoded); err != nil || decoded != msg {
+		t.Errorf("неправильний вміст: %+v, %v", decoded, err)
+	}
+
+	// VAPID: JWT підписаний ключем сервера, aud – origin push-сервісу
+	auth := got.Header.Get("Authorization")
+	token, key, ok := strings.Cut(strings.TrimPrefix(auth, "vapid t="), ", k=")
+	if !ok || key != p.PublicKey() || key != base64.RawURLEncoding.EncodeToString(serverKey.PublicKey().Bytes()) {
+		t.Fatalf("неправильний Authorization: %q", auth)
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) { return &p.key.PublicKey, nil },
+		jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(srv.URL))
+	if err != nil || claims["sub"] != "mailto:admin@example.com" {
+		t.Errorf("неправильний VAPID JWT: %v, %v", claims, err)
+	}
+
+	// 410 – браузер відписався: підписку видаляємо
+	status = http.StatusGone
+	if err := p.Send(ctx, "sub-1", msg); !outbox.IsPermanent(err) {
+		t.Errorf("410: очікував постійну помилку, отримав %v", err)
+	}
+	if _, err := repos.PushSubs.Get(ctx, "sub-1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("підписка мала зникнути, отримав %v", err)
+	}
+}
+
+func TestValidateSubscription(t *testing.T) {
+	good := newBrowser(t).subscription("s", "u", "https://push.example.com/abc")
+	if err := ValidateSubscription(good); err != nil {
+		t.Fatal(err)
+	}
+	for name, mutate := range map[string]func(*models.PushSubscription){
+		"http":   func(s *models.PushSubscription) { s.Endpoint = "http://push.example.com/abc" },
+		"p256dh": func(s *models.PushSubscription) { s.P256dh = "AAAA" },
+		"auth":   func(s *models.PushSubscription) { s.Auth = "short" },
+	} {
+		s := good
+		mutate(&s)
+		if err := ValidateSubscription(s); err == nil {
+			t.Errorf("%s: очікував помилку", name)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"moodtracker/models"
+	"moodtracker/outbox"
+	"moodtracker/repository"
+)
+
+// SignatureHeader містить "sha256=<hex>" – HMAC-SHA256 тіла запиту з ключем webhook_secret
+const SignatureHeader = "X-Moodtracker-Signature"
+
+// Webhook надсилає сповіщення POST-запитом з JSON на webhook_url користувача.
+// Адресою в черзі є id користувача: URL і ключ читаються під час надсилання,
+// тож зміна налаштувань діє і на вже поставлені завдання.
+type Webhook struct {
+	Settings repository.NotificationRepository
+	Client   *http.Client
+}
+
+func NewWebhook(settings repository.NotificationRepository) *Webhook {
+	return &Webhook{Settings: settings, Client: publicClient()}
+}
+
+// webhookPayload – тіло запиту до webhook користувача
+type webhookPayload struct {
+	Message
+	SentAt time.Time `json:"sent_at"`
+}
+
+func (w *Webhook) Channel() string { return models.JobWebhook }
+
+func (w *Webhook) Recipients(_ context.Context, u models.User, s models.NotificationSettings) ([]string, error) {
+	if s.WebhookURL == "" {
+		return nil, nil
+	}
+	return []string{u.ID}, nil
+}
+
+func (w *Webhook) Send(ctx context.Context, userID string, msg Message) error {
+	s, err := w.Settings.Get(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && s.WebhookURL == "") {
+		return outbox.Permanent(errors.New("webhook is not configured"))
+	} else if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(webhookPayload{Message: msg, SentAt: time.Now().UTC()})
+	if err != nil {
+		return outbox.Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return outbox.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(s.WebhookSecret, body))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook post: %w", err)
+	}
+	defer resp.Body.Close()
+	return statusError(resp)
+}
+
+// Sign повертає значення заголовка SignatureHeader для тіла body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// blocked – діапазони, які IsGlobalUnicast і IsPrivate вважають публічними,
+// хоча вони ведуть у внутрішню чи службову мережу
+var blocked = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "ця мережа"
+	netip.MustParsePrefix("100.64.0.0/10"),   // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // службові призначення IETF
+	netip.MustParsePrefix("192.0.2.0/24"),    // документація
+	netip.MustParsePrefix("198.18.0.0/15"),   // тестування продуктивності
+	netip.MustParsePrefix("198.51.100.0/24"), // документація
+	netip.MustParsePrefix("203.0.113.0/24"),  // документація
+	netip.MustParsePrefix("240.0.0.0/4"),     // зарезервовано
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64: IPv4-адреса всередині IPv6
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // локальний NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // документація
+	// IPv4-mapped адреси знімаються через Unmap; ці діапазони – на випадок, якщо ні
+	netip.MustParsePrefix("::ffff:0.0.0.0/104"),
+	netip.MustParsePrefix("::ffff:10.0.0.0/104"),
+	netip.MustParsePrefix("::ffff:127.0.0.0/104"),
+	netip.MustParsePrefix("::ffff:169.254.0.0/112"),
+	netip.MustParsePrefix("::ffff:172.16.0.0/108"),
+	netip.MustParsePrefix("::ffff:192.168.0.0/112"),
+}
+
+// isPublic повідомляє, чи можна з'єднуватися з адресою, яку задав користувач
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range blocked {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicClient – HTTP-клієнт для адрес, які задав користувач: з'єднання з
+// loopback, приватними, link-local і службовими адресами (див. blocked)
+// відхиляються, щоб webhook чи push-endpoint не став доступом до внутрішньої мережі (SSRF)
+func publicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !isPublic(addr) {
+				return fmt.Errorf("address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		// переадресація могла б обійти перевірку схеми https
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"moodtracker/i18n"
+	"moodtracker/models"
+	"moodtracker/outbox"
+	"moodtracker/repository"
+)
+
+// recordSize – розмір запису aes128gcm; повідомлення шифруємо одним записом
+const recordSize = 4096
+
+// VAPIDConfig – ключ сервера застосунку для Web Push (RFC 8292)
+type VAPIDConfig struct {
+	PrivateKey string // скаляр P-256, base64url без доповнення
+	Subject    string // контакт для push-сервісу: mailto: або https:
+}
+
+// VAPIDConfigFromEnv читає WEBPUSH_VAPID_PRIVATE_KEY і WEBPUSH_SUBJECT; ok=false – Web Push вимкнено
+func VAPIDConfigFromEnv() (cfg VAPIDConfig, ok bool) {
+	cfg = VAPIDConfig{
+		PrivateKey: os.Getenv("WEBPUSH_VAPID_PRIVATE_KEY"),
+		Subject:    os.Getenv("WEBPUSH_SUBJECT"),
+	}
+	if cfg.Subject == "" {
+		from := os.Getenv("MAIL_FROM")
+		if from == "" {
+			from = "no-reply@moodtracker.local"
+		}
+		cfg.Subject = "mailto:" + from
+	}
+	return cfg, cfg.PrivateKey != ""
+}
+
+// WebPush надсилає сповіщення в браузери через їхні push-сервіси (RFC 8030),
+// шифруючи вміст ключами підписки (RFC 8291). Адреса в черзі – id підписки.
+type WebPush struct {
+	Subs   repository.PushSubscriptionRepository
+	Client *http.Client
+	TTL    time.Duration // скільки push-сервіс зберігає повідомлення для офлайн-браузера
+
+	key     *ecdsa.PrivateKey
+	public  string
+	subject string
+}
+
+func NewWebPush(subs repository.PushSubscriptionRepository, cfg VAPIDConfig) (*WebPush, error) {
+	d, err := base64.RawURLEncoding.DecodeString(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+	priv, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+	pub := priv.PublicKey().Bytes() // 0x04 || X || Y
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+	return &WebPush{
+		Subs:    subs,
+		Client:  publicClient(),
+		TTL:     24 * time.Hour,
+		key:     key,
+		public:  base64.RawURLEncoding.EncodeToString(pub),
+		subject: cfg.Subject,
+	}, nil
+}
+
+// PublicKey – applicationServerKey для pushManager.subscribe у браузері
+func (p *WebPush) PublicKey() string { return p.public }
+
+func (p *WebPush) Channel() string { return models.JobWebPush }
+
+func (p *WebPush) Recipients(ctx context.Context, u models.User, _ models.NotificationSettings) ([]string, error) {
+	subs, err := p.Subs.ListByUser(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(subs))
+	for i, s := range subs {
+		ids[i] = s.ID
+	}
+	return ids, nil
+}
+
+func (p *WebPush) Send(ctx context.Context, id string, msg Message) error {
+	sub, err := p.Subs.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return outbox.Permanent(errors.New("push subscription was removed"))
+	} else if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return outbox.Permanent(err)
+	}
+	body, err := Encrypt(sub.P256dh, sub.Auth, payload)
+	if err != nil {
+		return outbox.Permanent(err)
+	}
+	auth, err := p.vapid(sub.Endpoint)
+	if err != nil {
+		return outbox.Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return outbox.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(p.TTL.Seconds())))
+	req.Header.Set("Authorization", auth)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push post: %w", err)
+	}
+	defer resp.Body.Close()
+	// 404 і 410 – браузер відписався: підписка більше не знадобиться
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		if err := p.Subs.Delete(ctx, sub.UserID, sub.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return outbox.Permanent(fmt.Errorf("push subscription expired: %s", resp.Status))
+	}
+	return statusError(resp)
+}
+
+// vapid повертає заголовок Authorization для push-сервісу, якому належить endpoint
+func (p *WebPush) vapid(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": p.subject,
+	}).SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	return "vapid t=" + token + ", k=" + p.public, nil
+}
+
+// Encrypt шифрує payload для підписки з ключами p256dh і auth (base64url) за RFC 8291:
+// одноразовий ключ ECDH, HKDF-SHA-256 і один запис aes128gcm (RFC 8188)
+func Encrypt(p256dh, auth string, payload []byte) ([]byte, error) {
+	uaBytes, err := base64.RawURLEncoding.DecodeString(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaBytes)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh: %w", err)
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(auth)
+	if err != nil || len(secret) != 16 {
+		return nil, errors.New("auth must be 16 bytes of base64url")
+	}
+	if len(payload)+1+16 > recordSize {
+		return nil, fmt.Errorf("payload of %d bytes does not fit one record", len(payload))
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	info := "WebPush: info\x00" + string(uaBytes) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, shared, secret, info, 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// заголовок: salt || rs || idlen || keyid (відкритий ключ сервера)
+	out := append([]byte{}, salt...)
+	out = binary.BigEndian.AppendUint32(out, recordSize)
+	out = append(out, byte(len(asPublic)))
+	out = append(out, asPublic...)
+	// 0x02 – роздільник останнього запису
+	return gcm.Seal(out, nonce, append(append([]byte{}, payload...), 0x02), nil), nil
+}
+
+// ValidateSubscription перевіряє endpoint і ключі підписки браузера
+func ValidateSubscription(s models.PushSubscription) error {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return i18n.NewError("invalid_push_endpoint")
+	}
+	key, err := base64.RawURLEncoding.DecodeString(s.P256dh)
+	if err != nil {
+		return i18n.NewError("invalid_push_p256dh")
+	}
+	if _, err := ecdh.P256().NewPublicKey(key); err != nil {
+		return i18n.NewError("invalid_push_p256dh")
+	}
+	if secret, err := base64.RawURLEncoding.DecodeString(s.Auth); err != nil || len(secret) != 16 {
+		return i18n.NewError("invalid_push_auth")
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+// Package outbox доставляє повідомлення з постійної черги (таблиця outbox).
+// Завдання переживають перезапуск, повторюються з експоненційною затримкою,
+// після MaxAttempts невдач переходять у стан dead, а частота надсилання
+// обмежена і загалом, і для кожного одержувача.
+//
+// Обмеження частоти рахує кожен процес окремо, а воркер працює на кожній репліці.
+// Щоб разом репліки не перевищили ліміти каналу, інтервали ділять між ними:
+// див. Worker.PerReplica і OUTBOX_REPLICAS.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+// DeliverFunc надсилає одне завдання свого каналу
+type DeliverFunc func(ctx context.Context, j models.Job) error
+
+// RetryAfterError – канал просить зачекати (Telegram 429); така спроба не вважається невдалою
+type RetryAfterError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("retry after %s: %v", e.After, e.Err)
+}
+
+func (e *RetryAfterError) Unwrap() error { return e.Err }
+
+// RetryAfter позначає помилку, після якої надсилати можна не раніше ніж через d
+func RetryAfter(d time.Duration, err error) error {
+	return &RetryAfterError{After: d, Err: err}
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent позначає помилку, яку повтор не виправить (чат не існує, бота заблоковано)
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent повідомляє, чи позначено err через Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Скільки зберігаються завершені завдання. Надіслане тримає свій IdempotencyKey:
+// ключі сповіщень прив'язані до дати, тож тижня досить, щоб їх не поставили вдруге.
+// Dead-завдання лишаються довше, щоб встигнути розібратися з причиною.
+const (
+	SentRetention = 7 * 24 * time.Hour
+	DeadRetention = 30 * 24 * time.Hour
+)
+
+// Prune видаляє завершені завдання, старші за SentRetention і DeadRetention на момент now
+func Prune(ctx context.Context, jobs repository.JobRepository, now time.Time) error {
+	return jobs.Prune(ctx, now.Add(-SentRetention), now.Add(-DeadRetention))
+}
+
+// Enqueue ставить повідомлення в чергу. Повторний виклик з тим самим key нічого не робить.
+func Enqueue(ctx context.Context, jobs repository.JobRepository, key, kind, recipient, payload string) error {
+	now := time.Now()
+	err := jobs.Enqueue(ctx, &models.Job{
+		ID:             uuid.NewString(),
+		IdempotencyKey: key,
+		Kind:           kind,
+		Recipient:      recipient,
+		Payload:        payload,
+		RunAt:          now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return nil
+	}
+	return err
+}
+
+// Worker забирає завдання з черги і розсилає їх пулом з Workers горутин
+type Worker struct {
+	Jobs    repository.JobRepository
+	Deliver map[string]DeliverFunc // за models.Job.Kind
+
+	Workers      int           // одночасні надсилання
+	Batch        int           // завдань за одне звернення до БД
+	Poll         time.Duration // пауза, коли черга порожня
+	Lease        time.Duration // після неї завдання впалого воркера бере інший
+	Global       time.Duration // мінімальний інтервал між будь-якими двома надсиланнями цього процесу
+	PerRecipient time.Duration // мінімальний інтервал між повідомленнями одному одержувачу з цього процесу
+	MaxAttempts  int
+	Backoff      time.Duration // затримка після першої невдачі; далі подвоюється
+	MaxBackoff   time.Duration
+
+	mu         sync.Mutex
+	next       time.Time            // найближчий вільний момент для будь-якого надсилання
+	recipients map[string]time.Time // найближчий вільний момент для одержувача
+}
+
+// New повертає воркер з обмеженнями Telegram: 30 повідомлень на секунду загалом
+// і одне на секунду в чат
+func New(jobs repository.JobRepository, deliver map[string]DeliverFunc) *Worker {
+	return &Worker{
+		Jobs:         jobs,
+		Deliver:      deliver,
+		Workers:      4,
+		Batch:        50,
+		Poll:         time.Second,
+		Lease:        time.Minute,
+		Global:       time.Second / 30,
+		PerRecipient: time.Second,
+		MaxAttempts:  8,
+		Backoff:      30 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+// ReplicasFromEnv читає OUTBOX_REPLICAS: скільки реплік одночасно розсилають чергу (за замовчуванням 1)
+func ReplicasFromEnv() (int, error) {
+	v := os.Getenv("OUTBOX_REPLICAS")
+	if v == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid OUTBOX_REPLICAS %q, want a positive number of replicas", v)
+	}
+	return n, nil
+}
+
+// PerReplica ділить ліміти частоти між n репліками: кожна надсилає в n разів рідше,
+// тож разом вони вкладаються в ліміти, задані для одного процесу
+func (w *Worker) PerReplica(n int) *Worker {
+	if n > 1 {
+		w.Global *= time.Duration(n)
+		w.PerRecipient *= time.Duration(n)
+	}
+	return w
+}
+
+// Run обробляє чергу, доки не скасовано ctx
+func (w *Worker) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := w.RunOnce(ctx)
+		if err != nil {
+			log.Printf("outbox claim err: %v", err)
+		}
+		if n == 0 || err != nil {
+			sleep(ctx, w.Poll)
+		}
+	}
+}
+
+// RunOnce забирає одну порцію завдань, чекає на їх обробку і повертає їх кількість
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	jobs, err := w.Jobs.Claim(ctx, time.Now(), w.Batch, w.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	sem := make(chan struct{}, max(w.Workers, 1))
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		// слот рахуємо, коли є вільний воркер: попереднє надсилання могло отримати 429
+		sem <- struct{}{}
+		at := w.slot(j.Recipient)
+		// Одержувач зайнятий надовго – повертаємо завдання в чергу, щоб не тримати інших
+		if time.Until(at) > w.Poll {
+			<-sem
+			j.Status, j.RunAt = models.JobPending, at
+			w.release(ctx, &j)
+			continue
+		}
+		if !sleep(ctx, time.Until(at)) {
+			<-sem
+			break // решту завдань забере інший воркер після закінчення оренди
+		}
+		w.take(j.Recipient, at)
+
+		wg.Add(1)
+		go func(j models.Job) {
+			defer func() { <-sem; wg.Done() }()
+			w.handle(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+	return len(jobs), nil
+}
+
+// handle надсилає завдання і записує результат
+func (w *Worker) handle(ctx context.Context, j models.Job) {
+	var err error
+	if deliver, ok := w.Deliver[j.Kind]; ok {
+		err = deliver(ctx, j)
+	} else {
+		err = Permanent(fmt.Errorf("no delivery for kind %q", j.Kind))
+	}
+
+	now := time.Now()
+	var retry *RetryAfterError
+	switch {
+	case err == nil:
+		j.Status, j.LastError = models.JobSent, ""
+	case errors.As(err, &retry):
+		// обмеження частоти діє на весь канал, тож пригальмовуємо всі надсилання
+		j.Status, j.RunAt, j.LastError = models.JobPending, now.Add(retry.After), err.Error()
+		w.pause(j.RunAt)
+	case IsPermanent(err):
+		j.Attempts++
+		j.Status, j.LastError = models.JobDead, err.Error()
+	default:
+		j.Attempts++
+		j.LastError = err.Error()
+		if j.Attempts >= w.MaxAttempts {
+			j.Status = models.JobDead
+		} else {
+			j.Status, j.RunAt = models.JobPending, now.Add(w.backoff(j.Attempts))
+		}
+	}
+	if j.Status == models.JobDead {
+		log.Printf("outbox job %s (%s) is dead after %d attempts: %s", j.ID, j.IdempotencyKey, j.Attempts, j.LastError)
+	}
+	w.release(ctx, &j)
+}
+
+func (w *Worker) release(ctx context.Context, j *models.Job) {
+	// результат записуємо навіть після скасування ctx, інакше завдання чекатиме кінця оренди
+	err := w.Jobs.Release(context.WithoutCancel(ctx), j)
+	switch {
+	case errors.Is(err, repository.ErrStale):
+		// оренда скінчилася, і завдання вже забрав інший воркер: його результат головніший
+		log.Printf("outbox lease lost for %s, result discarded", j.ID)
+	case err != nil:
+		log.Printf("outbox release err for %s: %v", j.ID, err)
+	}
+}
+
+// backoff – затримка перед спробою після attempts невдач: Backoff, 2·Backoff, 4·Backoff… до MaxBackoff
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.Backoff
+	for i := 1; i < attempts && d < w.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, w.MaxBackoff)
+}
+
+// slot – найближчий момент, коли можна написати recipient
+func (w *Worker) slot(recipient string) time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	at := time.Now()
+	if w.next.After(at) {
+		at = w.next
+	}
+	if r := w.recipients[recipient]; r.After(at) {
+		at = r
+	}
+	return at
+}
+
+// take займає момент at для recipient
+func (w *Worker) take(recipient string, at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.recipients == nil {
+		w.recipients = map[string]time.Time{}
+	}
+	w.next = at.Add(w.Global)
+	w.recipients[recipient] = at.Add(w.PerRecipient)
+	// старі записи вже нічого не обмежують
+	for r, t := range w.recipients {
+		if t.Before(at) {
+			delete(w.recipients, r)
+		}
+	}
+}
+
+// pause відкладає всі надсилання до until
+func (w *Worker) pause(until time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if until.After(w.next) {
+		w.next = until
+	}
+}
+
+// sleep чекає d і повертає false, якщо ctx скасовано раніше
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
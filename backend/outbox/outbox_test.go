@@ -0,0 +1,252 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"moodtracker/models"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
+)
+
+// recorder запам'ятовує результати спроб, записані воркером
+type recorder struct {
+	repository.JobRepository
+	released map[string]models.Job // за IdempotencyKey
+}
+
+func (r *recorder) Release(ctx context.Context, j *models.Job) error {
+	r.released[j.IdempotencyKey] = *j
+	return r.JobRepository.Release(ctx, j)
+}
+
+func setupWorker(t *testing.T, deliver DeliverFunc) (*Worker, *recorder) {
+	rec := &recorder{JobRepository: memory.New().Jobs, released: map[string]models.Job{}}
+	w := New(rec, map[string]DeliverFunc{models.JobTelegram: deliver})
+	// один воркер: надсилання йдуть по черзі, і deliver у тестах не потребує синхронізації
+	w.Workers, w.Global, w.PerRecipient, w.Poll = 1, 0, 0, 50*time.Millisecond
+	return w, rec
+}
+
+func enqueue(t *testing.T, jobs repository.JobRepository, key, recipient string) {
+	if err := Enqueue(context.Background(), jobs, key, models.JobTelegram, recipient, "text "+key); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorker_Sent(t *testing.T) {
+	var delivered []string
+	w, rec := setupWorker(t, func(_ context.Context, j models.Job) error {
+		delivered = append(delivered, j.Payload)
+		return nil
+	})
+	enqueue(t, rec, "a", "1")
+	enqueue(t, rec, "a", "1") // той самий ключ – без дубля
+
+	if n, err := w.RunOnce(context.Background()); err != nil || n != 1 {
+		t.Fatalf("очікував 1 завдання, отримав %d, %v", n, err)
+	}
+	if len(delivered) != 1 || delivered[0] != "text a" || rec.released["a"].Status != models.JobSent {
+		t.Errorf("неправильна доставка: %v, %+v", delivered, rec.released["a"])
+	}
+	if n, _ := w.RunOnce(context.Background()); n != 0 {
+		t.Errorf("надіслане завдання не має повторюватися, забрано %d", n)
+	}
+}
+
+func TestWorker_RetryWithBackoffThenDead(t *testing.T) {
+	w, rec := setupWorker(t, func(context.Context, models.Job) error { return errors.New("timeout") })
+	w.MaxAttempts, w.Backoff = 3, 0
+	enqueue(t, rec, "a", "1")
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		w.RunOnce(context.Background())
+		j := rec.released["a"]
+		if j.Attempts != attempt || j.LastError != "timeout" {
+			t.Fatalf("спроба %d: %+v", attempt, j)
+		}
+	}
+	if got := rec.released["a"].Status; got != models.JobDead {
+		t.Errorf("після MaxAttempts очікував dead, отримав %s", got)
+	}
+	if n, _ := w.RunOnce(context.Background()); n != 0 {
+		t.Errorf("dead-завдання не має повторюватися, забрано %d", n)
+	}
+}
+
+func TestWorker_Backoff(t *testing.T) {
+	w := New(nil, nil)
+	w.Backoff, w.MaxBackoff = time.Second, 5*time.Second
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 20: 5 * time.Second} {
+		if got := w.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, очікував %s", attempts, got, want)
+		}
+	}
+
+	w, rec := setupWorker(t, func(context.Context, models.Job) error { return errors.New("timeout") })
+	enqueue(t, rec, "a", "1")
+	start := time.Now()
+	w.RunOnce(context.Background())
+	if j := rec.released["a"]; j.Status != models.JobPending || j.RunAt.Before(start.Add(w.Backoff)) {
+		t.Errorf("очікував повтор не раніше ніж через %s: %+v", w.Backoff, j)
+	}
+}
+
+func TestWorker_Permanent(t *testing.T) {
+	w, rec := setupWorker(t, func(context.Context, models.Job) error { return Permanent(errors.New("chat not found")) })
+	enqueue(t, rec, "a", "1")
+	if err := Enqueue(context.Background(), rec, "b", "sms", "1", "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	w.RunOnce(context.Background())
+	if j := rec.released["a"]; j.Status != models.JobDead || j.Attempts != 1 {
+		t.Errorf("постійна помилка: очікував dead, отримав %+v", j)
+	}
+	if j := rec.released["b"]; j.Status != models.JobDead {
+		t.Errorf("невідомий канал: очікував dead, отримав %+v", j)
+	}
+}
+
+func TestWorker_RetryAfter(t *testing.T) {
+	calls := 0
+	w, rec := setupWorker(t, func(context.Context, models.Job) error {
+		calls++
+		return RetryAfter(time.Hour, errors.New("Too Many Requests"))
+	})
+	enqueue(t, rec, "a", "1")
+	enqueue(t, rec, "b", "2")
+
+	start := time.Now()
+	w.RunOnce(context.Background())
+	a := rec.released["a"]
+	if a.Status != models.JobPending || a.Attempts != 0 || a.RunAt.Before(start.Add(time.Hour)) {
+		t.Errorf("429 не рахується як невдача і відкладає завдання: %+v", a)
+	}
+	// після 429 канал пригальмовано: наступне завдання повертається в чергу без спроби
+	if b := rec.released["b"]; calls != 1 || b.Status != models.JobPending || b.RunAt.Before(start.Add(time.Hour)) {
+		t.Errorf("очікував паузу всіх надсилань, викликів %d, b: %+v", calls, b)
+	}
+}
+
+func TestWorker_PerRecipientRate(t *testing.T) {
+	var delivered []string
+	w, rec := setupWorker(t, func(_ context.Context, j models.Job) error {
+		delivered = append(delivered, j.IdempotencyKey)
+		return nil
+	})
+	w.PerRecipient = time.Hour
+	enqueue(t, rec, "a", "1")
+	enqueue(t, rec, "b", "1")
+	enqueue(t, rec, "c", "2")
+
+	w.RunOnce(context.Background())
+	if len(delivered) != 2 || rec.released["b"].Status != models.JobPending {
+		t.Errorf("другому повідомленню в той самий чат треба чекати: %v, %+v", delivered, rec.released["b"])
+	}
+}
+
+func TestWorker_GlobalRate(t *testing.T) {
+	w, rec := setupWorker(t, func(context.Context, models.Job) error { return nil })
+	w.Global = 20 * time.Millisecond
+	for _, key := range []string{"a", "b", "c"} {
+		enqueue(t, rec, key, key)
+	}
+
+	start := time.Now()
+	w.RunOnce(context.Background())
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("три надсилання мали зайняти щонайменше 40ms, зайняли %s", d)
+	}
+}
+
+func TestWorker_ExpiredLease(t *testing.T) {
+	w, rec := setupWorker(t, func(context.Context, models.Job) error { return nil })
+	enqueue(t, rec, "a", "1")
+
+	// воркер забрав завдання і впав, не записавши результат
+	if jobs, _ := rec.Claim(context.Background(), time.Now(), 10, -time.Second); len(jobs) != 1 {
+		t.Fatalf("очікував 1 завдання, отримав %d", len(jobs))
+	}
+	if n, _ := w.RunOnce(context.Background()); n != 1 || rec.released["a"].Status != models.JobSent {
+		t.Errorf("завдання з простроченою орендою має забрати інший воркер: %+v", rec.released["a"])
+	}
+}
+
+func TestWorker_LostLease(t *testing.T) {
+	var w *Worker
+	var rec *recorder
+	w, rec = setupWorker(t, func(ctx context.Context, j models.Job) error {
+		// поки воркер надсилав, оренда скінчилася і завдання забрав інший
+		if jobs, _ := rec.Claim(ctx, j.LockedUntil.Add(time.Second), 10, time.Minute); len(jobs) != 1 {
+			t.Errorf("очікував, що завдання забере інший воркер, отримав %d", len(jobs))
+		}
+		return errors.New("timeout")
+	})
+	enqueue(t, rec, "a", "1")
+
+	w.RunOnce(context.Background())
+	// результат першого воркера відкинуто: завдання досі за другим
+	if jobs, _ := rec.Claim(context.Background(), time.Now(), 10, time.Minute); len(jobs) != 0 {
+		t.Errorf("утрачена оренда не має повертати завдання в чергу: %+v", jobs)
+	}
+}
+
+func TestWorker_PerReplica(t *testing.T) {
+	w := New(nil, nil).PerReplica(3)
+	if w.Global != 3*(time.Second/30) || w.PerRecipient != 3*time.Second {
+		t.Errorf("інтервали мають зрости втричі: %s, %s", w.Global, w.PerRecipient)
+	}
+	if w := New(nil, nil).PerReplica(1); w.Global != time.Second/30 || w.PerRecipient != time.Second {
+		t.Errorf("одна репліка не змінює інтервали: %s, %s", w.Global, w.PerRecipient)
+	}
+}
+
+func TestReplicasFromEnv(t *testing.T) {
+	for v, want := range map[string]int{"": 1, "3": 3} {
+		t.Setenv("OUTBOX_REPLICAS", v)
+		if n, err := ReplicasFromEnv(); err != nil || n != want {
+			t.Errorf("OUTBOX_REPLICAS=%q: очікував %d, отримав %d, %v", v, want, n, err)
+		}
+	}
+	for _, v := range []string{"0", "-1", "two"} {
+		t.Setenv("OUTBOX_REPLICAS", v)
+		if _, err := ReplicasFromEnv(); err == nil {
+			t.Errorf("OUTBOX_REPLICAS=%q: очікував помилку", v)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	jobs := memory.New().Jobs
+	enqueue(t, jobs, "sent", "1")
+	enqueue(t, jobs, "dead", "2")
+	claimed, _ := jobs.Claim(ctx, time.Now(), 10, time.Minute)
+	for _, j := range claimed {
+		j.Status = j.IdempotencyKey // ключі збігаються зі станами
+		if err := jobs.Release(ctx, &j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// через тиждень надіслане вже не потрібне, а dead ще зберігається
+	if err := Prune(ctx, jobs, time.Now().Add(SentRetention+time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jobs.Enqueue(ctx, &models.Job{ID: "new-sent", IdempotencyKey: "sent"}); err != nil {
+		t.Errorf("надіслане завдання мало видалитися: %v", err)
+	}
+	if err := jobs.Enqueue(ctx, &models.Job{ID: "new-dead", IdempotencyKey: "dead"}); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("dead-завдання зберігається DeadRetention: %v", err)
+	}
+
+	if err := Prune(ctx, jobs, time.Now().Add(DeadRetention+time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jobs.Enqueue(ctx, &models.Job{ID: "new-dead", IdempotencyKey: "dead"}); err != nil {
+		t.Errorf("dead-завдання мало видалитися: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Package problem – відповіді з помилками у форматі RFC 7807 (application/problem+json).
+// Кожна помилка має стабільний машинний код (ключ каталогу i18n, наприклад
+// "mood_already_exists") і detail мовою запиту; внутрішні подробиці лишаються в лозі.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"moodtracker/i18n"
+	"moodtracker/repository"
+)
+
+// ContentType – тип тіла відповіді з помилкою
+const ContentType = "application/problem+json"
+
+// Коди помилок, які не прив'язані до окремого обробника
+const (
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal_error"
+)
+
+// Problem – тіло відповіді за RFC 7807 з розширенням code
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"` // некоректні поля запиту
+}
+
+// FieldError – помилка окремого поля тіла запиту
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// Coder – помилка зі стабільним кодом (i18n.Error, validate.Errors)
+type Coder interface {
+	Code() string
+}
+
+// Fielder – помилка з переліком некоректних полів (validate.Errors)
+type Fielder interface {
+	Fields(l i18n.Locale) []FieldError
+}
+
+// Locale – мова відповіді за заголовком Accept-Language
+func Locale(r *http.Request) i18n.Locale {
+	return i18n.Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Write відповідає статусом status з кодом code; detail – повідомлення code з каталогу
+func Write(w http.ResponseWriter, r *http.Request, status int, code string, args ...any) {
+	newProblem(r, status, code, i18n.T(Locale(r), code, args...)).write(w)
+}
+
+// WriteError відповідає статусом status з кодом і текстом помилки err.
+// Помилка без коду вважається внутрішньою (див. Internal).
+func WriteError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	var c Coder
+	if !errors.As(err, &c) {
+		Internal(w, r, err)
+		return
+	}
+	l := Locale(r)
+	p := newProblem(r, status, c.Code(), i18n.Message(l, err))
+	var f Fielder
+	if errors.As(err, &f) {
+		p.Errors = f.Fields(l)
+	}
+	p.write(w)
+}
+
+// Internal відповідає на непередбачену помилку сховища чи сервісу:
+// порушення унікальності – 409, відсутній запис – 404, решта – 500.
+// Текст err клієнту не показується, лише пишеться в лог.
+func Internal(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrConflict):
+		Write(w, r, http.StatusConflict, CodeConflict)
+	case errors.Is(err, repository.ErrNotFound):
+		Write(w, r, http.StatusNotFound, CodeNotFound)
+	default:
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		Write(w, r, http.StatusInternalServerError, CodeInternal)
+	}
+}
+
+// NotFound – обробник невідомих маршрутів
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusNotFound, CodeNotFound)
+}
+
+// MethodNotAllowed – обробник маршрутів без потрібного методу
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed)
+}
+
+func newProblem(r *http.Request, status int, code, detail string) *Problem {
+	return &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+}
+
+func (p *Problem) write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
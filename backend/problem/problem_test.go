@@ -0,0 +1,84 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"moodtracker/i18n"
+	"moodtracker/repository"
+)
+
+func record(t *testing.T, lang string, respond func(w http.ResponseWriter, r *http.Request)) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/mood/m1", nil)
+	r.Header.Set("Accept-Language", lang)
+	w := httptest.NewRecorder()
+	respond(w, r)
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("очікував %s, отримав %q", ContentType, ct)
+	}
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("не вдалося розпарсити відповідь: %v", err)
+	}
+	if p.Status != w.Code {
+		t.Errorf("status у тілі %d, а в заголовку %d", p.Status, w.Code)
+	}
+	return w, p
+}
+
+func TestWrite(t *testing.T) {
+	_, p := record(t, "en", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, http.StatusNotFound, CodeNotFound)
+	})
+	want := Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "not found", Instance: "/api/mood/m1", Code: "not_found"}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("очікував %+v, отримав %+v", want, p)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	err := fmt.Errorf("create: %w", i18n.NewError("mood_already_exists"))
+	_, p := record(t, "uk", func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, r, http.StatusConflict, err)
+	})
+	if p.Code != "mood_already_exists" || p.Detail != i18n.T(i18n.UK, "mood_already_exists") {
+		t.Errorf("неправильна відповідь: %+v", p)
+	}
+
+	// помилка без коду – внутрішня, її текст клієнт не бачить
+	w, p := record(t, "uk", func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, r, http.StatusBadRequest, errors.New("pq: secret detail"))
+	})
+	if w.Code != http.StatusInternalServerError || p.Code != CodeInternal || strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("неправильна відповідь: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestInternal(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("%w: ux_user_date", repository.ErrConflict), http.StatusConflict, CodeConflict},
+		{fmt.Errorf("load m1: %w", repository.ErrNotFound), http.StatusNotFound, CodeNotFound},
+		{errors.New("db error: connection refused"), http.StatusInternalServerError, CodeInternal},
+	} {
+		w, p := record(t, "en", func(w http.ResponseWriter, r *http.Request) {
+			Internal(w, r, tc.err)
+		})
+		if w.Code != tc.status || p.Code != tc.code {
+			t.Errorf("%v: очікував %d %s, отримав %d %s", tc.err, tc.status, tc.code, w.Code, p.Code)
+		}
+		if strings.Contains(w.Body.String(), tc.err.Error()) {
+			t.Errorf("%v: відповідь розкриває внутрішню помилку", tc.err)
+		}
+	}
+}
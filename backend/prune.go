@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"moodtracker/handlers"
+	"moodtracker/leader"
+	"moodtracker/outbox"
+	"moodtracker/repository"
+)
+
+// pruneLoop щогодини видаляє прострочені дані входу (коди з email, state SSO, refresh-токени)
+// і завершені завдання черги. Працює лише на лідері, щоб репліки не виконували
+// той самий DELETE паралельно.
+func pruneLoop(ctx context.Context, elector *leader.Elector, auth *handlers.AuthHandler, jobs repository.JobRepository) {
+	t := time.NewTicker(time.Hour)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if !elector.IsLeader() {
+				continue
+			}
+			if err := auth.Prune(ctx, now); err != nil {
+				log.Printf("auth prune err: %v", err)
+			}
+			if err := outbox.Prune(ctx, jobs, now); err != nil {
+				log.Printf("outbox prune err: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package reminders щохвилини визначає, кому з користувачів настав час нагадування
+// чи звіту за їхнім часовим поясом і розкладом, і передає сповіщення в notify.
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-co-op/gocron"
+
+	"moodtracker/i18n"
+	"moodtracker/leader"
+	"moodtracker/models"
+	"moodtracker/notify"
+	"moodtracker/repository"
+	"moodtracker/stats"
+	"moodtracker/streaks"
+)
+
+// Scheduler ставить у чергу нагадування і звіти кожному користувачу за його
+// власним часовим поясом і розкладом з reminder_settings
+type Scheduler struct {
+	Users     repository.UserRepository
+	Moods     repository.MoodRepository
+	Reminders repository.ReminderRepository
+	Streaks   *streaks.Service
+	Notify    *notify.Dispatcher
+}
+
+func NewScheduler(repos repository.Set, d *notify.Dispatcher) *Scheduler {
+	return &Scheduler{
+		Users:     repos.Users,
+		Moods:     repos.Moods,
+		Reminders: repos.Reminders,
+		Streaks:   streaks.NewService(repos.Moods, repos.Freezes),
+		Notify:    d,
+	}
+}
+
+// Start щохвилини викликає tick; розклад виконує лише лідер
+func (s *Scheduler) Start(elector *leader.Elector) {
+	cron := gocron.NewScheduler(time.UTC)
+	cron.Every(1).Minute().StartAt(time.Now().Truncate(time.Minute).Add(time.Minute)).Do(func() {
+		if elector.IsLeader() {
+			s.tick(time.Now())
+		}
+	})
+	cron.StartAsync()
+}
+
+// tick надсилає все, що для когось із користувачів припадає на хвилину now
+func (s *Scheduler) tick(now time.Time) {
+	ctx := context.Background()
+	list, err := s.Users.List(ctx)
+	if err != nil {
+		log.Printf("scheduler users err: %v", err)
+		return
+	}
+
+	// Розклади читаємо одним запитом; хто їх не змінював – отримує типовий
+	saved, err := s.Reminders.List(ctx)
+	if err != nil {
+		log.Printf("scheduler reminders err: %v", err)
+		return
+	}
+	schedule := map[string]models.ReminderSettings{}
+	for _, rs := range saved {
+		schedule[rs.UserID] = rs
+	}
+
+	// Нагадування групуємо за локальною датою: для кожної – один запит "хто ще без запису"
+	remind := map[time.Time][]models.User{}
+	for _, u := range list {
+		rs, ok := schedule[u.ID]
+		if !ok {
+			rs = models.DefaultReminderSettings(u.ID)
+		}
+		local := now.In(u.Location())
+		today := u.Today(now)
+		if rs.RemindAt(local) {
+			remind[today] = append(remind[today], u)
+		}
+		if from, to, ok := rs.ReportPeriod(local); ok {
+			s.sendReport(ctx, u, rs.ReportFrequency, from, to)
+		}
+	}
+	for today, due := range remind {
+		s.sendDailyReminder(ctx, now, today, due)
+	}
+}
+
+// notify ставить сповіщення в чергу; key не дає поставити його двічі
+// (наприклад, якщо tick для тієї самої хвилини виконався повторно)
+func (s *Scheduler) notify(ctx context.Context, u models.User, key string, msg notify.Message) {
+	if err := s.Notify.Notify(ctx, u, key, msg); err != nil {
+		log.Printf("failed to enqueue %s: %v", key, err)
+	}
+}
+
+// sendDailyReminder ставить у чергу нагадування тим із due, хто ще не додав настрій за свій день today
+func (s *Scheduler) sendDailyReminder(ctx context.Context, now, today time.Time, due []models.User) {
+	pending, err := s.Users.ListWithoutMood(ctx, today)
+	if err != nil {
+		log.Printf("sendDailyReminder db error: %v", err)
+		return
+	}
+	without := map[string]bool{}
+	for _, u := range pending {
+		without[u.ID] = true
+	}
+
+	for _, u := range due {
+		if !without[u.ID] {
+			continue
+		}
+		streak, err := s.Streaks.Get(ctx, u.ID, today)
+		if err != nil {
+			log.Printf("streaks query err for %s: %v", u.ID, err)
+		}
+		local := now.In(u.Location()).Format("2006-01-02T15:04")
+		s.notify(ctx, u, "reminder:"+u.ID+":"+local, notify.Message{
+			Type:  models.NotifyReminder,
+			Title: i18n.T(u.Locale, "reminder.title"),
+			Body:  ReminderText(u.Locale, streak),
+		})
+	}
+}
+
+// sendReport збирає статистику користувача за період from..to і ставить звіт у чергу
+func (s *Scheduler) sendReport(ctx context.Context, u models.User, frequency string, from, to time.Time) {
+	entries, err := s.Moods.List(ctx, u.ID, repository.MoodFilter{From: &from, To: &to})
+	if err != nil {
+		log.Printf("stats query err for %s: %v", u.ID, err)
+		return
+	}
+	streak, err := s.Streaks.Get(ctx, u.ID, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("streaks query err for %s: %v", u.ID, err)
+		return
+	}
+	period := "report.weekly"
+	if frequency == models.ReportMonthly {
+		period = "report.monthly"
+	}
+	s.notify(ctx, u, fmt.Sprintf("report:%s:%s:%s", u.ID, day(from), day(to)), notify.Message{
+		Type:  models.NotifyReport,
+		Title: i18n.T(u.Locale, period+".subject"),
+		Body:  ReportText(u.Locale, i18n.T(u.Locale, period+".title"), stats.Compute(entries, from, to, stats.ByDay), streak),
+	})
+}
+
+func day(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// ReminderText – нагадування мовою l з поточною серією, щоб її не обірвати
+func ReminderText(l i18n.Locale, st streaks.Streaks) string {
+	text := i18n.T(l, "reminder.text")
+	if st.Current > 0 {
+		text += "\n" + i18n.N(l, "reminder.streak", st.Current)
+	}
+	if streaks.IsMilestone(st.Current + 1) {
+		text += "\n" + i18n.N(l, "reminder.milestone", st.Current+1)
+	}
+	return text
+}
+
+// ReportText форматує звіт за період мовою l
+func ReportText(l i18n.Locale, title string, r stats.Report, st streaks.Streaks) string {
+	text := title + "\n"
+	for _, c := range r.Counts {
+		text += fmt.Sprintf("%s — %d\n", models.IconName(l, c.Icon), c.Count)
+	}
+	if r.AvgScore != nil {
+		text += i18n.T(l, "report.average", *r.AvgScore, models.MaxScore) + "\n"
+	}
+	if r.MostCommon != "" {
+		text += i18n.T(l, "report.most_common", models.IconName(l, r.MostCommon)) + "\n"
+	}
+	text += i18n.T(l, "report.days_logged", r.DaysLogged, r.DaysLogged+len(r.MissingDays)) + "\n"
+	text += i18n.T(l, "report.streaks", i18n.N(l, "days", st.Current), i18n.N(l, "days", st.Longest))
+
+	// віха, досягнута протягом звітного періоду
+	for i := len(streaks.Milestones) - 1; i >= 0; i-- {
+		m := streaks.Milestones[i]
+		if m <= st.Current && m > st.Current-r.DaysLogged {
+			text += "\n" + i18n.N(l, "report.milestone", m)
+			break
+		}
+	}
+	return text
+}
@@ -0,0 +1,322 @@
+package reminders
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"moodtracker/i18n"
+	"moodtracker/mailer"
+	"moodtracker/models"
+	"moodtracker/notify"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
+	"moodtracker/stats"
+	"moodtracker/streaks"
+)
+
+// chatNotifier – канал Telegram без бота: адреса – chat_id, надсилати нічого не треба
+type chatNotifier struct{}
+
+func (chatNotifier) Channel() string { return models.JobTelegram }
+
+func (chatNotifier) Recipients(_ context.Context, u models.User, _ models.NotificationSettings) ([]string, error) {
+	if u.TelegramChatID == nil {
+		return nil, nil
+	}
+	return []string{strconv.FormatInt(*u.TelegramChatID, 10)}, nil
+}
+
+func (chatNotifier) Send(context.Context, string, notify.Message) error { return nil }
+
+// queue забирає завдання, які scheduler поставив у чергу outbox
+type queue struct {
+	jobs repository.JobRepository
+	last []models.Job // забрані останнім викликом chats
+}
+
+// chats забирає з черги нові завдання і повертає чат-ID отримувачів
+func (q *queue) chats(t *testing.T) []int64 {
+	t.Helper()
+	jobs, err := q.jobs.Claim(context.Background(), time.Now().AddDate(1, 0, 0), 100, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.last = jobs
+	out := []int64{}
+	for _, j := range jobs {
+		id, _ := strconv.ParseInt(j.Recipient, 10, 64)
+		out = append(out, id)
+	}
+	return out
+}
+
+func setupScheduler(t *testing.T) (*Scheduler, repository.Set, *queue) {
+	repos := memory.New()
+	d := notify.NewDispatcher(repos.Jobs, repos.Notifications, chatNotifier{}, &notify.Email{Mailer: &mailer.LogMailer{}})
+	return NewScheduler(repos, d), repos, &queue{jobs: repos.Jobs}
+}
+
+func seedTelegramUser(t *testing.T, repos repository.Set, id, tz string, chatID int64) {
+	ctx := context.Background()
+	u := &models.User{ID: id, Email: id + "@example.com", UserSettings: models.UserSettings{Timezone: tz}}
+	if err := repos.Users.Create(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Users.SetTelegramChatID(ctx, id, chatID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReportText(t *testing.T) {
+	score := func(v int) *int { return &v }
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	moods := []models.Mood{
+		{Date: from, Icon: "😃", Score: score(5)},
+		{Date: from.AddDate(0, 0, 5), Icon: "😞", Score: score(2)},
+		{Date: from.AddDate(0, 0, 6), Icon: "😃", Score: score(5)},
+	}
+
+	text := ReportText(i18n.UK, "Твій звіт за останній тиждень:", stats.Compute(moods, from, from.AddDate(0, 0, 6), stats.ByDay), streaks.Streaks{Current: 8, Longest: 12})
+	for _, want := range []string{"тиждень", "😃 Чудово — 2", "😞 Сумно — 1", "Середній бал: 4.0 з 5", "Найчастіший настрій: 😃 Чудово", "Днів із записами: 3 з 7",
+		"Поточна серія: 8 днів, найдовша: 12 днів", "Віха: 7 днів"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("у звіті немає %q:\n%s", want, text)
+		}
+	}
+
+	text = ReportText(i18n.EN, "Your report for the last week:", stats.Compute(moods, from, from.AddDate(0, 0, 6), stats.ByDay), streaks.Streaks{})
+	for _, want := range []string{"😃 Great — 2", "😞 Sad — 1", "Most common mood: 😃 Great"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("назви іконок мають бути англійською, немає %q:\n%s", want, text)
+		}
+	}
+
+	text = ReportText(i18n.UK, "Твій звіт за минулий місяць:", stats.Compute(moods, from, from.AddDate(0, 1, -1), stats.ByDay), streaks.Streaks{})
+	for _, want := range []string{"минулий місяць", "Днів із записами: 3 з 31"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("у місячному звіті немає %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestReminderText(t *testing.T) {
+	if text := ReminderText(i18n.UK, streaks.Streaks{}); strings.Contains(text, "серія") {
+		t.Errorf("без серії не згадуємо її: %q", text)
+	}
+	text := ReminderText(i18n.UK, streaks.Streaks{Current: 6})
+	if !strings.Contains(text, "6 днів поспіль") || !strings.Contains(text, "буде 7 днів") {
+		t.Errorf("очікував серію і наступну віху: %q", text)
+	}
+	if text := ReminderText(i18n.UK, streaks.Streaks{Current: 2}); !strings.Contains(text, "2 дні поспіль") {
+		t.Errorf("очікував форму \"дні\": %q", text)
+	}
+	text = ReminderText(i18n.EN, streaks.Streaks{Current: 1})
+	if !strings.Contains(text, "Don't forget") || !strings.Contains(text, "1 day in a row") {
+		t.Errorf("очікував англійське нагадування: %q", text)
+	}
+}
+
+func TestTick_ReminderAtLocalTime(t *testing.T) {
+	sch, repos, q := setupScheduler(t)
+	seedTelegramUser(t, repos, "kyiv", "Europe/Kyiv", 1)
+	seedTelegramUser(t, repos, "utc", "UTC", 2)
+	seedTelegramUser(t, repos, "logged", "Europe/Kyiv", 3)
+	// о 20:00 за Києвом (18:00 UTC) у Токіо вже 03:00 наступного дня
+	seedTelegramUser(t, repos, "tokyo", "Asia/Tokyo", 4)
+	err := repos.Moods.Create(context.Background(), &models.Mood{
+		ID: "m1", UserID: "logged", Date: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), Icon: "😊",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sch.tick(time.Date(2025, 1, 15, 18, 0, 30, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("нагадування мав отримати лише чат 1, отримали %v", got)
+	}
+
+	// 20:00 за UTC – черга користувача utc; у Києві вже 22:00
+	sch.tick(time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || got[0] != 2 {
+		t.Errorf("нагадування мав отримати лише чат 2, отримали %v", got)
+	}
+
+	// не в початок години – нічого не надсилаємо
+	sch.tick(time.Date(2025, 1, 15, 18, 1, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 0 {
+		t.Errorf("зайві повідомлення: %v", got)
+	}
+}
+
+func TestTick_WeeklyReportOnLocalMonday(t *testing.T) {
+	sch, repos, q := setupScheduler(t)
+	seedTelegramUser(t, repos, "kyiv", "Europe/Kyiv", 1)
+	seedTelegramUser(t, repos, "la", "America/Los_Angeles", 2)
+
+	// понеділок 13 січня, 09:00 за Києвом; у Лос-Анджелесі ще неділя
+	sch.tick(time.Date(2025, 1, 13, 7, 0, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || got[0] != 1 || !strings.Contains(q.last[0].Payload, "звіт") {
+		t.Errorf("звіт мав отримати лише чат 1, отримали %v", got)
+	}
+}
+
+func TestTick_CustomSchedule(t *testing.T) {
+	sch, repos, q := setupScheduler(t)
+	seedTelegramUser(t, repos, "kyiv", "Europe/Kyiv", 1)
+	seedTelegramUser(t, repos, "default", "Europe/Kyiv", 2)
+	// двічі на день, лише в будні
+	err := repos.Reminders.Save(context.Background(), &models.ReminderSettings{
+		UserID: "kyiv", Times: []string{"08:30", "21:15"}, Weekdays: []int{1, 2, 3, 4, 5}, ReportFrequency: models.ReportOff,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// середа 15 січня, 08:30 і 21:15 за Києвом (UTC+2)
+	for _, at := range []time.Time{time.Date(2025, 1, 15, 6, 30, 0, 0, time.UTC), time.Date(2025, 1, 15, 19, 15, 0, 0, time.UTC)} {
+		sch.tick(at)
+		if got := q.chats(t); len(got) != 1 || got[0] != 1 {
+			t.Errorf("%v: нагадування мав отримати лише чат 1, отримали %v", at, got)
+		}
+	}
+
+	// 20:00 – типовий час, але не для kyiv
+	sch.tick(time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || got[0] != 2 {
+		t.Errorf("нагадування мав отримати лише чат 2, отримали %v", got)
+	}
+
+	// субота 18 січня – не будній день
+	sch.tick(time.Date(2025, 1, 18, 6, 30, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 0 {
+		t.Errorf("у вихідний нагадувань бути не мало: %v", got)
+	}
+
+	// понеділок 09:00 – звіт вимкнено, типовий користувач його отримує
+	sch.tick(time.Date(2025, 1, 13, 7, 0, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || got[0] != 2 {
+		t.Errorf("звіт мав отримати лише чат 2, отримали %v", got)
+	}
+}
+
+func TestTick_QuietHours(t *testing.T) {
+	sch, repos, q := setupScheduler(t)
+	seedTelegramUser(t, repos, "u", "UTC", 1)
+	// тихі години через північ накривають і 23:00, і типовий час звіту
+	err := repos.Reminders.Save(context.Background(), &models.ReminderSettings{
+		UserID: "u", Times: []string{"07:00", "23:00"}, Weekdays: []int{0, 1, 2, 3, 4, 5, 6},
+		QuietStart: "22:00", QuietEnd: "09:30", ReportFrequency: models.ReportWeekly,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, at := range []time.Time{time.Date(2025, 1, 13, 7, 0, 0, 0, time.UTC), time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC), time.Date(2025, 1, 13, 23, 0, 0, 0, time.UTC)} {
+		sch.tick(at)
+	}
+	if got := q.chats(t); len(got) != 0 {
+		t.Fatalf("у тихі години нічого не надсилаємо: %v", got)
+	}
+
+	// звіт переноситься на кінець тихих годин
+	sch.tick(time.Date(2025, 1, 13, 9, 30, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || !strings.Contains(q.last[0].Payload, "тиждень") {
+		t.Errorf("очікував тижневий звіт о 09:30, отримали %v", got)
+	}
+}
+
+func TestTick_MonthlyReport(t *testing.T) {
+	sch, repos, q := setupScheduler(t)
+	seedTelegramUser(t, repos, "u", "UTC", 1)
+	err := repos.Reminders.Save(context.Background(), &models.ReminderSettings{
+		UserID: "u", Times: []string{}, Weekdays: []int{}, ReportFrequency: models.ReportMonthly,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repos.Moods.Create(context.Background(), &models.Mood{
+		ID: "m1", UserID: "u", Date: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), Icon: "😊",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// понеділок 13 січня – не перше число
+	sch.tick(time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 0 {
+		t.Fatalf("місячний звіт лише першого числа: %v", got)
+	}
+
+	sch.tick(time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || !strings.Contains(q.last[0].Payload, "Днів із записами: 1 з 31") {
+		t.Errorf("очікував звіт за січень, отримали %+v", q.last)
+	}
+}
+
+func TestTick_Idempotent(t *testing.T) {
+	sch, repos, q := setupScheduler(t)
+	seedTelegramUser(t, repos, "u", "UTC", 1)
+
+	// повторний tick тієї самої хвилини (перезапуск, другий інстанс) не дублює повідомлення
+	at := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	sch.tick(at)
+	sch.tick(at.Add(20 * time.Second))
+	if got := q.chats(t); len(got) != 1 {
+		t.Errorf("очікував одне повідомлення, отримали %v", got)
+	}
+}
+
+func TestTick_Channels(t *testing.T) {
+	sch, repos, q := setupScheduler(t)
+	ctx := context.Background()
+	seedTelegramUser(t, repos, "both", "UTC", 1)
+	// без Telegram і без власних налаштувань – типовий канал Telegram недоступний
+	if err := repos.Users.Create(ctx, &models.User{ID: "nochat", Email: "nochat@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	err := repos.Notifications.Save(ctx, &models.NotificationSettings{
+		UserID: "both", Reminders: []string{models.JobTelegram, models.JobEmail}, Reports: []string{models.JobEmail},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sch.tick(time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC))
+	q.chats(t)
+	kinds := map[string]string{}
+	for _, j := range q.last {
+		kinds[j.Kind] = j.Recipient
+	}
+	if len(q.last) != 2 || kinds[models.JobTelegram] != "1" || kinds[models.JobEmail] != "both@example.com" {
+		t.Errorf("нагадування мало піти в Telegram і на пошту: %+v", q.last)
+	}
+
+	// понеділок 09:00 – звіт лише листом, з темою
+	sch.tick(time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC))
+	q.chats(t)
+	if len(q.last) != 1 || q.last[0].Kind != models.JobEmail || !strings.Contains(q.last[0].Payload, `"title":"Звіт за тиждень"`) {
+		t.Errorf("звіт мав піти лише листом: %+v", q.last)
+	}
+}
+
+func TestTick_Locale(t *testing.T) {
+	sch, repos, q := setupScheduler(t)
+	ctx := context.Background()
+	seedTelegramUser(t, repos, "en", "UTC", 1)
+	settings := models.UserSettings{EntryMode: models.EntryModeSingle, Timezone: "UTC", Locale: i18n.EN}
+	if err := repos.Users.UpdateSettings(ctx, "en", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	sch.tick(time.Date(2025, 1, 15, 20, 0, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || !strings.Contains(q.last[0].Payload, "Don't forget to log today's mood") {
+		t.Errorf("очікував нагадування англійською: %+v", q.last)
+	}
+	sch.tick(time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC))
+	if got := q.chats(t); len(got) != 1 || !strings.Contains(q.last[0].Payload, "Weekly report") || !strings.Contains(q.last[0].Payload, "Days logged: 0 of 7") {
+		t.Errorf("очікував звіт англійською: %+v", q.last)
+	}
+}
@@ -0,0 +1,1002 @@
+// Package memory – реалізація репозиторіїв у пам'яті для тестів і локальних експериментів
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"moodtracker/i18n"
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+var errDuplicateDate = fmt.Errorf("%w: ux_mood_user_date_single", repository.ErrConflict)
+
+// store – спільні дані всіх репозиторіїв одного набору (як таблиці однієї БД)
+type store struct {
+	mu         sync.Mutex
+	moods      map[string]models.Mood
+	users      map[string]models.User
+	icons      map[[2]string]models.MoodIcon // ключ – user_id та icon
+	freezes    map[string]map[time.Time]bool // user_id -> заморожені дні
+	reminders  map[string]models.ReminderSettings
+	notify     map[string]models.NotificationSettings
+	pushSubs   map[string]models.PushSubscription
+	jobs       map[string]models.Job
+	leases     map[string]lease
+	links      map[string]models.TelegramLink
+	updates    map[int64]telegramUpdate
+	loginCodes map[string]models.LoginCode
+	sessions   map[string]models.Session
+	states     map[string]models.OIDCState
+	identities map[[2]string]models.Identity
+}
+
+// New повертає порожній набір репозиторіїв
+func New() repository.Set {
+	s := &store{
+		moods:      map[string]models.Mood{},
+		users:      map[string]models.User{},
+		icons:      map[[2]string]models.MoodIcon{},
+		freezes:    map[string]map[time.Time]bool{},
+		reminders:  map[string]models.ReminderSettings{},
+		notify:     map[string]models.NotificationSettings{},
+		pushSubs:   map[string]models.PushSubscription{},
+		jobs:       map[string]models.Job{},
+		leases:     map[string]lease{},
+		links:      map[string]models.TelegramLink{},
+		updates:    map[int64]telegramUpdate{},
+		loginCodes: map[string]models.LoginCode{},
+		sessions:   map[string]models.Session{},
+		states:     map[string]models.OIDCState{},
+		identities: map[[2]string]models.Identity{},
+	}
+	return repository.Set{
+		Moods:           &moodRepo{s},
+		Users:           &userRepo{s},
+		Icons:           &iconRepo{s},
+		Freezes:         &freezeRepo{s},
+		Reminders:       &reminderRepo{s},
+		Notifications:   &notificationRepo{s},
+		PushSubs:        &pushSubRepo{s},
+		Jobs:            &jobRepo{s},
+		Leases:          &leaseRepo{s},
+		LoginCodes:      &loginCodeRepo{s},
+		Sessions:        &sessionRepo{s},
+		Identities:      &identityRepo{s},
+		TelegramLinks:   &telegramLinkRepo{s},
+		TelegramUpdates: &telegramUpdateRepo{s},
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// dateOnly відкидає час, як колонка DATE
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// entryTime – момент запису в межах дня: logged_at, а якщо його немає – created_at
+func entryTime(m models.Mood) time.Time {
+	if m.LoggedAt != nil {
+		return *m.LoggedAt
+	}
+	return m.CreatedAt
+}
+
+type moodRepo struct{ *store }
+
+func (r *moodRepo) Create(_ context.Context, m *models.Mood) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// як частковий унікальний індекс ux_mood_user_date_single у БД
+	for _, other := range r.moods {
+		if m.OnePerDay && other.OnePerDay && other.UserID == m.UserID && sameDay(other.Date, m.Date) {
+			return errDuplicateDate
+		}
+	}
+	m.Version = 1
+	r.moods[m.ID] = *m
+	return nil
+}
+
+func (r *moodRepo) List(_ context.Context, userID string, f repository.MoodFilter) ([]models.Mood, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []models.Mood{}
+	for _, m := range r.moods {
+		if m.UserID != userID {
+			continue
+		}
+		d := dateOnly(m.Date)
+		if f.From != nil && d.Before(dateOnly(*f.From)) {
+			continue
+		}
+		if f.To != nil && d.After(dateOnly(*f.To)) {
+			continue
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		di, dj := dateOnly(out[i].Date), dateOnly(out[j].Date)
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return entryTime(out[i]).Before(entryTime(out[j]))
+	})
+	return out, nil
+}
+
+func (r *moodRepo) Page(ctx context.Context, userID string, q repository.MoodQuery) ([]models.Mood, error) {
+	all, err := r.List(ctx, userID, q.MoodFilter)
+	if err != nil {
+		return nil, err
+	}
+	// before – чи йде a перед b у порядку сторінки
+	before := func(a, b repository.MoodKey) bool {
+		da, db := dateOnly(a.Date), dateOnly(b.Date)
+		if !da.Equal(db) {
+			return da.Before(db) != q.Desc
+		}
+		return a.ID != b.ID && (a.ID < b.ID) != q.Desc
+	}
+	key := func(m models.Mood) repository.MoodKey { return repository.MoodKey{Date: m.Date, ID: m.ID} }
+	sort.Slice(all, func(i, j int) bool { return before(key(all[i]), key(all[j])) })
+
+	terms := repository.SearchTerms(q.Search)
+	out := []models.Mood{}
+	for _, m := range all {
+		switch {
+		case q.Icon != "" && m.Icon != q.Icon,
+			q.Score != nil && (m.Score == nil || *m.Score != *q.Score),
+			q.After != nil && !before(*q.After, key(m)),
+			!matches(m.Comment, terms):
+			continue
+		}
+		if len(out) == q.Limit {
+			break
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// matches – кожне слово terms є початком якогось слова comment (як префіксний пошук у БД)
+func matches(comment string, terms []string) bool {
+	words := repository.SearchTerms(comment)
+	for _, t := range terms {
+		found := false
+		for _, w := range words {
+			if strings.HasPrefix(w, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *moodRepo) Get(_ context.Context, userID, id string) (*models.Mood, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.moods[id]
+	if !ok || m.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return &m, nil
+}
+
+func (r *moodRepo) Update(_ context.Context, m *models.Mood) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.moods[m.ID]
+	if !ok || cur.UserID != m.UserID {
+		return repository.ErrNotFound
+	}
+	if cur.Version != m.Version {
+		return repository.ErrStale
+	}
+	for _, other := range r.moods {
+		if cur.OnePerDay && other.OnePerDay && other.ID != m.ID && other.UserID == m.UserID && sameDay(other.Date, m.Date) {
+			return errDuplicateDate
+		}
+	}
+	cur.Date, cur.LoggedAt = m.Date, m.LoggedAt
+	cur.Icon, cur.Score, cur.Comment, cur.UpdatedAt = m.Icon, m.Score, m.Comment, m.UpdatedAt
+	m.Version++
+	cur.Version = m.Version
+	r.moods[m.ID] = cur
+	return nil
+}
+
+func (r *moodRepo) Delete(_ context.Context, userID, id string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.moods[id]
+	if !ok || m.UserID != userID {
+		return repository.ErrNotFound
+	}
+	if version != 0 && m.Version != version {
+		return repository.ErrStale
+	}
+	delete(r.moods, id)
+	return nil
+}
+
+func (r *moodRepo) LoggedDays(_ context.Context, userID string) ([]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := map[time.Time]bool{}
+	for _, m := range r.moods {
+		if m.UserID == userID {
+			seen[dateOnly(m.Date)] = true
+		}
+	}
+	return sortedDays(seen), nil
+}
+
+func sortedDays(set map[time.Time]bool) []time.Time {
+	out := []time.Time{}
+	for d := range set {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+type freezeRepo struct{ *store }
+
+func (r *freezeRepo) List(_ context.Context, userID string) ([]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedDays(r.freezes[userID]), nil
+}
+
+func (r *freezeRepo) Add(_ context.Context, userID string, day time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := dateOnly(day)
+	if r.freezes[userID][d] {
+		return fmt.Errorf("%w: streak_freezes_pkey", repository.ErrConflict)
+	}
+	if r.freezes[userID] == nil {
+		r.freezes[userID] = map[time.Time]bool{}
+	}
+	r.freezes[userID][d] = true
+	return nil
+}
+
+func (r *freezeRepo) Remove(_ context.Context, userID string, day time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := dateOnly(day)
+	if !r.freezes[userID][d] {
+		return repository.ErrNotFound
+	}
+	delete(r.freezes[userID], d)
+	return nil
+}
+
+type reminderRepo struct{ *store }
+
+func (r *reminderRepo) Get(_ context.Context, userID string) (*models.ReminderSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, ok := r.reminders[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &rs, nil
+}
+
+func (r *reminderRepo) Save(_ context.Context, rs *models.ReminderSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reminders[rs.UserID] = *rs
+	return nil
+}
+
+func (r *reminderRepo) Delete(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.reminders[userID]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.reminders, userID)
+	return nil
+}
+
+func (r *reminderRepo) List(_ context.Context) ([]models.ReminderSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []models.ReminderSettings{}
+	for _, rs := range r.reminders {
+		out = append(out, rs)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out, nil
+}
+
+type notificationRepo struct{ *store }
+
+func (r *notificationRepo) Get(_ context.Context, userID string) (*models.NotificationSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.notify[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &n, nil
+}
+
+func (r *notificationRepo) Save(_ context.Context, n *models.NotificationSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notify[n.UserID] = *n
+	return nil
+}
+
+func (r *notificationRepo) Delete(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.notify[userID]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.notify, userID)
+	return nil
+}
+
+type pushSubRepo struct{ *store }
+
+func (r *pushSubRepo) Save(_ context.Context, s *models.PushSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// як UNIQUE (endpoint) з ON CONFLICT DO UPDATE
+	for id, other := range r.pushSubs {
+		if other.Endpoint == s.Endpoint {
+			delete(r.pushSubs, id)
+			s.ID = id
+		}
+	}
+	r.pushSubs[s.ID] = *s
+	return nil
+}
+
+func (r *pushSubRepo) Get(_ context.Context, id string) (*models.PushSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.pushSubs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r *pushSubRepo) ListByUser(_ context.Context, userID string) ([]models.PushSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []models.PushSubscription{}
+	for _, s := range r.pushSubs {
+		if s.UserID == userID {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *pushSubRepo) Delete(_ context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.pushSubs[id]; !ok || s.UserID != userID {
+		return repository.ErrNotFound
+	}
+	delete(r.pushSubs, id)
+	return nil
+}
+
+type jobRepo struct{ *store }
+
+func (r *jobRepo) Enqueue(_ context.Context, j *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.jobs {
+		if other.IdempotencyKey == j.IdempotencyKey {
+			return repository.ErrConflict
+		}
+	}
+	j.Status = models.JobPending
+	r.jobs[j.ID] = *j
+	return nil
+}
+
+func (r *jobRepo) Claim(_ context.Context, now time.Time, limit int, lease time.Duration) ([]models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	due := []models.Job{}
+	for _, j := range r.jobs {
+		if (j.Status == models.JobPending && !j.RunAt.After(now)) ||
+			(j.Status == models.JobSending && j.LockedUntil != nil && !j.LockedUntil.After(now)) {
+			due = append(due, j)
+		}
+	}
+	sort.Slice(due, func(i, k int) bool { return due[i].RunAt.Before(due[k].RunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	until := now.Add(lease)
+	for i := range due {
+		due[i].Status, due[i].LockedUntil, due[i].UpdatedAt = models.JobSending, &until, now
+		r.jobs[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+func (r *jobRepo) Release(_ context.Context, j *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.jobs[j.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if stored.Status != models.JobSending || stored.LockedUntil == nil || j.LockedUntil == nil || !stored.LockedUntil.Equal(*j.LockedUntil) {
+		return repository.ErrStale
+	}
+	stored.Status, stored.Attempts, stored.RunAt, stored.LastError = j.Status, j.Attempts, j.RunAt, j.LastError
+	stored.LockedUntil, stored.UpdatedAt = nil, time.Now()
+	r.jobs[j.ID] = stored
+	return nil
+}
+
+func (r *jobRepo) Prune(_ context.Context, sentBefore, deadBefore time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, j := range r.jobs {
+		if (j.Status == models.JobSent && j.UpdatedAt.Before(sentBefore)) ||
+			(j.Status == models.JobDead && j.UpdatedAt.Before(deadBefore)) {
+			delete(r.jobs, id)
+		}
+	}
+	return nil
+}
+
+type lease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+type leaseRepo struct{ *store }
+
+func (r *leaseRepo) Acquire(_ context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.leases[name]; ok && l.holder != holder && l.expiresAt.After(now) {
+		return false, nil
+	}
+	r.leases[name] = lease{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *leaseRepo) Release(_ context.Context, name, holder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.leases[name]; ok && l.holder == holder {
+		delete(r.leases, name)
+	}
+	return nil
+}
+
+type telegramLinkRepo struct{ *store }
+
+func (r *telegramLinkRepo) Create(_ context.Context, l *models.TelegramLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.links[l.Token] = *l
+	return nil
+}
+
+func (r *telegramLinkRepo) Consume(_ context.Context, token string, now time.Time) (*models.TelegramLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.links[token]
+	if !ok || !l.ExpiresAt.After(now) {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.links, token)
+	return &l, nil
+}
+
+func (r *telegramLinkRepo) Redeem(_ context.Context, token string, chatID int64, now time.Time) (*models.TelegramLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.links[token]
+	if !ok || !l.ExpiresAt.After(now) {
+		return nil, repository.ErrNotFound
+	}
+	for id, other := range r.users {
+		if id != l.UserID && other.TelegramChatID != nil && *other.TelegramChatID == chatID {
+			return nil, repository.ErrConflict
+		}
+	}
+	if u, ok := r.users[l.UserID]; ok {
+		u.TelegramChatID = &chatID
+		r.users[l.UserID] = u
+	}
+	delete(r.links, token)
+	return &l, nil
+}
+
+type telegramUpdate struct {
+	receivedAt time.Time
+	handledAt  *time.Time
+}
+
+type telegramUpdateRepo struct{ *store }
+
+func (r *telegramUpdateRepo) Claim(_ context.Context, updateID int64, now time.Time, lease time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.updates[updateID]; ok {
+		if u.handledAt != nil {
+			return false, nil
+		}
+		if !u.receivedAt.Before(now.Add(-lease)) {
+			return false, repository.ErrConflict
+		}
+	}
+	r.updates[updateID] = telegramUpdate{receivedAt: now}
+	return true, nil
+}
+
+func (r *telegramUpdateRepo) Done(_ context.Context, updateID int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.updates[updateID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	u.handledAt = &at
+	r.updates[updateID] = u
+	return nil
+}
+
+func (r *telegramUpdateRepo) Prune(_ context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, u := range r.updates {
+		if u.receivedAt.Before(before) {
+			delete(r.updates, id)
+		}
+	}
+	return nil
+}
+
+type iconRepo struct{ *store }
+
+func (r *iconRepo) List(_ context.Context, userID string) ([]models.MoodIcon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []models.MoodIcon{}
+	for key, mi := range r.icons {
+		if key[0] == userID {
+			out = append(out, mi)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Icon < out[j].Icon
+	})
+	return out, nil
+}
+
+func (r *iconRepo) Get(_ context.Context, userID, icon string) (*models.MoodIcon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mi, ok := r.icons[[2]string{userID, icon}]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &mi, nil
+}
+
+func (r *iconRepo) Create(_ context.Context, userID string, mi *models.MoodIcon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]string{userID, mi.Icon}
+	if _, ok := r.icons[key]; ok {
+		return fmt.Errorf("%w: user_icons_pkey", repository.ErrConflict)
+	}
+	stored := *mi
+	stored.Custom = true
+	r.icons[key] = stored
+	return nil
+}
+
+func (r *iconRepo) Delete(_ context.Context, userID, icon string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]string{userID, icon}
+	if _, ok := r.icons[key]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.icons, key)
+	return nil
+}
+
+type userRepo struct{ *store }
+
+func (r *userRepo) Create(_ context.Context, u *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt, u.UpdatedAt = now, now
+	}
+	if u.EntryMode == "" {
+		u.EntryMode = models.EntryModeSingle // DEFAULT у БД
+	}
+	if u.Timezone == "" {
+		u.Timezone = "UTC"
+	}
+	if u.Locale == "" {
+		u.Locale = i18n.Default
+	}
+	r.users[u.ID] = *u
+	return nil
+}
+
+func (r *userRepo) GetByEmail(_ context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *userRepo) GetByID(_ context.Context, id string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &u, nil
+}
+
+func (r *userRepo) GetByTelegramChatID(_ context.Context, chatID int64) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.TelegramChatID != nil && *u.TelegramChatID == chatID {
+			return &u, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *userRepo) SetTelegramChatID(_ context.Context, userID string, chatID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return nil // як UPDATE без збігів
+	}
+	// telegram_chat_id унікальний
+	for id, other := range r.users {
+		if id != userID && other.TelegramChatID != nil && *other.TelegramChatID == chatID {
+			return repository.ErrConflict
+		}
+	}
+	u.TelegramChatID = &chatID
+	r.users[userID] = u
+	return nil
+}
+
+func (r *userRepo) UnlinkTelegram(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok || u.TelegramChatID == nil {
+		return repository.ErrNotFound
+	}
+	u.TelegramChatID = nil
+	r.users[userID] = u
+	return nil
+}
+
+func (r *userRepo) GetSettings(_ context.Context, userID string) (*models.UserSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	s := u.UserSettings
+	return &s, nil
+}
+
+func (r *userRepo) UpdateSettings(_ context.Context, userID string, s models.UserSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	u.UserSettings, u.UpdatedAt = s, time.Now()
+	r.users[userID] = u
+	return nil
+}
+
+func (r *userRepo) List(_ context.Context) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.filterUsers(func(models.User) bool { return true }), nil
+}
+
+func (r *userRepo) ListWithoutMood(_ context.Context, day time.Time) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	logged := map[string]bool{}
+	for _, m := range r.moods {
+		if sameDay(m.Date, day) {
+			logged[m.UserID] = true
+		}
+	}
+	return r.filterUsers(func(u models.User) bool { return !logged[u.ID] }), nil
+}
+
+// filterUsers викликається під r.mu
+func (r *userRepo) filterUsers(keep func(models.User) bool) []models.User {
+	out := []models.User{}
+	for _, u := range r.users {
+		if keep(u) {
+			out = append(out, u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+type loginCodeRepo struct{ *store }
+
+func (r *loginCodeRepo) Create(_ context.Context, c *models.LoginCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	r.loginCodes[c.ID] = *c
+	return nil
+}
+
+func (r *loginCodeRepo) CreateLimited(_ context.Context, c *models.LoginCode, l repository.LoginLimits) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.countSince(c.Email, l.Since) >= l.Codes {
+		return false, nil
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	r.loginCodes[c.ID] = *c
+	return true, nil
+}
+
+func (r *loginCodeRepo) CountSince(_ context.Context, email string, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.countSince(email, since), nil
+}
+
+func (r *loginCodeRepo) countSince(email string, since time.Time) int {
+	n := 0
+	for _, c := range r.loginCodes {
+		if c.Email == email && c.CreatedAt.After(since) {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *loginCodeRepo) LatestActive(_ context.Context, email string, now time.Time) (*models.LoginCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *models.LoginCode
+	for _, c := range r.loginCodes {
+		if c.Email != email || c.UsedAt != nil || !c.ExpiresAt.After(now) {
+			continue
+		}
+		if latest == nil || c.CreatedAt.After(latest.CreatedAt) {
+			c := c
+			latest = &c
+		}
+	}
+	if latest == nil {
+		return nil, repository.ErrNotFound
+	}
+	return latest, nil
+}
+
+func (r *loginCodeRepo) IncrementAttempts(_ context.Context, code *models.LoginCode, l repository.LoginLimits) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := 0
+	for _, c := range r.loginCodes {
+		if c.Email == code.Email && c.CreatedAt.After(l.Since) {
+			total += c.Attempts
+		}
+	}
+	c, ok := r.loginCodes[code.ID]
+	if !ok || c.Attempts >= l.CodeAttempts || total >= l.EmailAttempts {
+		return false, nil
+	}
+	c.Attempts++
+	r.loginCodes[code.ID] = c
+	return true, nil
+}
+
+func (r *loginCodeRepo) Prune(_ context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, c := range r.loginCodes {
+		if c.CreatedAt.Before(before) {
+			delete(r.loginCodes, id)
+		}
+	}
+	return nil
+}
+
+func (r *loginCodeRepo) MarkUsed(_ context.Context, id string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.loginCodes[id]
+	if !ok || c.UsedAt != nil {
+		return false, nil
+	}
+	c.UsedAt = &at
+	r.loginCodes[id] = c
+	return true, nil
+}
+
+type sessionRepo struct{ *store }
+
+func (r *sessionRepo) Create(_ context.Context, s *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	r.sessions[s.ID] = *s
+	return nil
+}
+
+func (r *sessionRepo) GetByTokenHash(_ context.Context, hash string) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		if s.TokenHash == hash {
+			return &s, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *sessionRepo) MarkRotated(_ context.Context, id string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	if !ok || s.RotatedAt != nil || s.RevokedAt != nil {
+		return false, nil
+	}
+	s.RotatedAt = &at
+	r.sessions[id] = s
+	return true, nil
+}
+
+func (r *sessionRepo) RevokeFamily(_ context.Context, familyID string, at time.Time) error {
+	r.revoke(func(s models.Session) bool { return s.FamilyID == familyID }, at)
+	return nil
+}
+
+func (r *sessionRepo) RevokeUser(_ context.Context, userID string, at time.Time) error {
+	r.revoke(func(s models.Session) bool { return s.UserID == userID }, at)
+	return nil
+}
+
+func (r *sessionRepo) revoke(match func(models.Session) bool, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.sessions {
+		if match(s) && s.RevokedAt == nil {
+			s.RevokedAt = &at
+			r.sessions[id] = s
+		}
+	}
+}
+
+func (r *sessionRepo) IsActive(_ context.Context, familyID, userID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		if s.FamilyID == familyID && s.UserID == userID && s.RevokedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *sessionRepo) Prune(_ context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.sessions {
+		if s.ExpiresAt.Before(before) || (s.RevokedAt != nil && s.RevokedAt.Before(before)) {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+type identityRepo struct{ *store }
+
+func (r *identityRepo) SaveState(_ context.Context, s *models.OIDCState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[s.State] = *s
+	return nil
+}
+
+func (r *identityRepo) ConsumeState(_ context.Context, state string, now time.Time) (*models.OIDCState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.states[state]
+	if !ok || !s.ExpiresAt.After(now) {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.states, state)
+	return &s, nil
+}
+
+func (r *identityRepo) PruneStates(_ context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for state, s := range r.states {
+		if !s.ExpiresAt.After(now) {
+			delete(r.states, state)
+		}
+	}
+	return nil
+}
+
+func (r *identityRepo) FindUserID(_ context.Context, issuer, subject string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, ok := r.identities[[2]string{issuer, subject}]
+	if !ok {
+		return "", repository.ErrNotFound
+	}
+	return id.UserID, nil
+}
+
+func (r *identityRepo) Link(_ context.Context, id *models.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.identities[[2]string{id.Issuer, id.Subject}] = *id
+	return nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"moodtracker/models"
+)
+
+// ErrNotFound повертається, коли запис не існує або належить іншому користувачу
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict повертається, коли запис порушує обмеження унікальності
+// (наприклад, другий запис настрою на день у режимі "один на день")
+var ErrConflict = errors.New("conflict")
+
+// ErrStale повертається, коли запис змінили після того, як його прочитали:
+// версія в сховищі не збігається з очікуваною
+var ErrStale = errors.New("stale version")
+
+// MoodFilter – необов'язкові межі дат (включно) для MoodRepository.List
+type MoodFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// MoodKey – місце запису в порядку сторінок (date, id)
+type MoodKey struct {
+	Date time.Time
+	ID   string
+}
+
+// MoodQuery – сторінка записів для API: межі дат, фільтри, порядок за (date, id) і курсор
+type MoodQuery struct {
+	MoodFilter
+	Icon   string   // лише записи з цією іконкою; "" – будь-які
+	Score  *int     // лише записи з цим місцем на шкалі
+	Search string   // слова коментаря (див. SearchTerms)
+	Desc   bool     // від нових до старих
+	After  *MoodKey // ключ останнього запису попередньої сторінки
+	Limit  int
+}
+
+// SearchTerms розбиває пошуковий запит на слова з літер і цифр у нижньому регістрі.
+// Запис підходить, якщо кожне слово запиту є початком якогось слова коментаря.
+func SearchTerms(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+type MoodRepository interface {
+	// Create зберігає новий запис з версією 1
+	Create(ctx context.Context, m *models.Mood) error
+	// List повертає записи за датою, а в межах дня – за часом (logged_at або created_at)
+	List(ctx context.Context, userID string, f MoodFilter) ([]models.Mood, error)
+	// Page повертає до q.Limit записів, що йдуть за q.After у порядку (date, id)
+	Page(ctx context.Context, userID string, q MoodQuery) ([]models.Mood, error)
+	Get(ctx context.Context, userID, id string) (*models.Mood, error)
+	// Update змінює date, logged_at, icon, score, comment та updated_at запису m.ID користувача m.UserID,
+	// якщо його версія досі m.Version, і збільшує m.Version. ErrStale, якщо запис уже змінили;
+	// ErrConflict, якщо перенесений на інший день запис порушує "один запис на день".
+	Update(ctx context.Context, m *models.Mood) error
+	// Delete видаляє запис; version – очікувана версія (ErrStale, якщо не збігається), 0 – будь-яка
+	Delete(ctx context.Context, userID, id string, version int) error
+	// LoggedDays повертає дні (без повторів, за зростанням), у які користувач мав хоч один запис
+	LoggedDays(ctx context.Context, userID string) ([]time.Time, error)
+}
+
+// FreezeRepository – "заморожені" дні, які не обривають серію
+type FreezeRepository interface {
+	// List повертає заморожені дні користувача за зростанням
+	List(ctx context.Context, userID string) ([]time.Time, error)
+	// Add повертає ErrConflict, якщо день уже заморожено
+	Add(ctx context.Context, userID string, day time.Time) error
+	Remove(ctx context.Context, userID string, day time.Time) error
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, u *models.User) error
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByTelegramChatID(ctx context.Context, chatID int64) (*models.User, error)
+	// SetTelegramChatID повертає ErrConflict, якщо чат уже прив'язано до іншого користувача
+	SetTelegramChatID(ctx context.Context, userID string, chatID int64) error
+	// UnlinkTelegram від'єднує Telegram-чат; ErrNotFound, якщо чат не прив'язано
+	UnlinkTelegram(ctx context.Context, userID string) error
+	GetSettings(ctx context.Context, userID string) (*models.UserSettings, error)
+	UpdateSettings(ctx context.Context, userID string, s models.UserSettings) error
+	// List повертає всіх користувачів за id
+	List(ctx context.Context) ([]models.User, error)
+	// ListWithoutMood – користувачі, які ще не внесли настрій за день day
+	ListWithoutMood(ctx context.Context, day time.Time) ([]models.User, error)
+}
+
+// IconRepository – власні іконки користувачів; вбудований каталог – models.Catalog
+type IconRepository interface {
+	List(ctx context.Context, userID string) ([]models.MoodIcon, error)
+	Get(ctx context.Context, userID, icon string) (*models.MoodIcon, error)
+	// Create повертає ErrConflict, якщо користувач уже має таку іконку
+	Create(ctx context.Context, userID string, mi *models.MoodIcon) error
+	Delete(ctx context.Context, userID, icon string) error
+}
+
+// ReminderRepository – налаштування нагадувань; користувачі без запису мають models.DefaultReminderSettings
+type ReminderRepository interface {
+	// Get повертає ErrNotFound, якщо користувач не змінював налаштувань
+	Get(ctx context.Context, userID string) (*models.ReminderSettings, error)
+	// Save створює або замінює налаштування s.UserID
+	Save(ctx context.Context, s *models.ReminderSettings) error
+	Delete(ctx context.Context, userID string) error
+	// List повертає всі збережені налаштування (для планувальника)
+	List(ctx context.Context) ([]models.ReminderSettings, error)
+}
+
+// NotificationRepository – канали сповіщень; користувачі без запису мають models.DefaultNotificationSettings
+type NotificationRepository interface {
+	// Get повертає ErrNotFound, якщо користувач не змінював налаштувань
+	Get(ctx context.Context, userID string) (*models.NotificationSettings, error)
+	// Save створює або замінює налаштування s.UserID
+	Save(ctx context.Context, s *models.NotificationSettings) error
+	Delete(ctx context.Context, userID string) error
+}
+
+// PushSubscriptionRepository – підписки браузерів на Web Push
+type PushSubscriptionRepository interface {
+	// Save додає підписку; підписка з тим самим endpoint замінюється (разом із власником)
+	Save(ctx context.Context, s *models.PushSubscription) error
+	Get(ctx context.Context, id string) (*models.PushSubscription, error)
+	ListByUser(ctx context.Context, userID string) ([]models.PushSubscription, error)
+	// Delete повертає ErrNotFound, якщо в користувача немає такої підписки
+	Delete(ctx context.Context, userID, id string) error
+}
+
+// JobRepository – черга вихідних повідомлень (outbox)
+type JobRepository interface {
+	// Enqueue повертає ErrConflict, якщо завдання з таким IdempotencyKey уже є
+	Enqueue(ctx context.Context, j *models.Job) error
+	// Claim забирає до limit завдань, яким настав час на момент now (разом із тими, чия
+	// оренда прострочена), і позначає їх JobSending до now+lease
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Job, error)
+	// Release записує результат спроби: Status, Attempts, RunAt і LastError – лише поки
+	// триває оренда з Claim (j.LockedUntil). ErrStale, якщо оренда скінчилася і завдання
+	// вже забрав інший воркер або записав результат
+	Release(ctx context.Context, j *models.Job) error
+	// Prune видаляє надіслані завдання, завершені раніше за sentBefore, і dead – раніше за deadBefore
+	Prune(ctx context.Context, sentBefore, deadBefore time.Time) error
+}
+
+// LeaseRepository – іменовані оренди для вибору лідера серед інстансів
+type LeaseRepository interface {
+	// Acquire бере або продовжує оренду name для holder до now+ttl. Повертає false,
+	// якщо оренду тримає інший holder і вона ще не закінчилася.
+	Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	// Release віддає оренду, якщо її тримає holder
+	Release(ctx context.Context, name, holder string) error
+}
+
+// TelegramLinkRepository – токени прив'язки Telegram-чату
+type TelegramLinkRepository interface {
+	Create(ctx context.Context, l *models.TelegramLink) error
+	// Consume видаляє токен і повертає його, якщо він не прострочений на момент now
+	Consume(ctx context.Context, token string, now time.Time) (*models.TelegramLink, error)
+	// Redeem разом споживає токен і прив'язує chatID до його користувача: ErrNotFound – токена
+	// немає або він прострочений, ErrConflict – чат уже прив'язано до іншого користувача.
+	// Якщо прив'язати не вдалося, токен лишається чинним.
+	Redeem(ctx context.Context, token string, chatID int64, now time.Time) (*models.TelegramLink, error)
+}
+
+// TelegramUpdateRepository запам'ятовує оброблені update_id: Telegram може доставити
+// оновлення повторно (повтор webhook, зміна лідера під час long polling)
+type TelegramUpdateRepository interface {
+	// Claim бере оновлення в обробку на час lease. Повертає false, якщо оновлення вже
+	// оброблене, і ErrConflict, якщо його ще обробляє інший процес. Взяте, але не завершене
+	// оновлення (процес упав до Done) після закінчення lease можна взяти знову
+	Claim(ctx context.Context, updateID int64, now time.Time, lease time.Duration) (bool, error)
+	// Done позначає оновлення обробленим
+	Done(ctx context.Context, updateID int64, at time.Time) error
+	// Prune видаляє позначки, отримані раніше за before
+	Prune(ctx context.Context, before time.Time) error
+}
+
+// LoginLimits – ліміти входу за кодом з email; до лімітів email рахуються коди, видані після Since
+type LoginLimits struct {
+	Since         time.Time
+	Codes         int // виданих кодів на один email
+	CodeAttempts  int // спроб на один код
+	EmailAttempts int // спроб на всі коди email разом
+}
+
+type LoginCodeRepository interface {
+	Create(ctx context.Context, c *models.LoginCode) error
+	// CreateLimited зберігає код, лише якщо email отримав менше за l.Codes кодів; false – ліміт
+	// вичерпано. Перевірка і запис атомарні: паралельні запити не проскакують ліміт.
+	CreateLimited(ctx context.Context, c *models.LoginCode, l LoginLimits) (bool, error)
+	CountSince(ctx context.Context, email string, since time.Time) (int, error)
+	// LatestActive – найсвіжіший невикористаний і не прострочений на момент now код
+	LatestActive(ctx context.Context, email string, now time.Time) (*models.LoginCode, error)
+	// IncrementAttempts зараховує спробу для коду c, лише якщо на нього було менше за
+	// l.CodeAttempts спроб, а на всі коди c.Email – менше за l.EmailAttempts (атомарно,
+	// як і CreateLimited); false – спроби вичерпано
+	IncrementAttempts(ctx context.Context, c *models.LoginCode, l LoginLimits) (bool, error)
+	// MarkUsed повертає false, якщо код уже використано (захист від гонки)
+	MarkUsed(ctx context.Context, id string, at time.Time) (bool, error)
+	// Prune видаляє коди, видані раніше за before
+	Prune(ctx context.Context, before time.Time) error
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, s *models.Session) error
+	GetByTokenHash(ctx context.Context, hash string) (*models.Session, error)
+	// MarkRotated повертає false, якщо токен уже ротовано або відкликано
+	MarkRotated(ctx context.Context, id string, at time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+	// IsActive повідомляє, чи лишився в сесії хоч один невідкликаний токен
+	IsActive(ctx context.Context, familyID, userID string) (bool, error)
+	// Prune видаляє токени, прострочені або відкликані раніше за before. Ротований, але ще
+	// чинний токен лишається: його повторне пред'явлення має відкликати сім'ю.
+	Prune(ctx context.Context, before time.Time) error
+}
+
+type IdentityRepository interface {
+	SaveState(ctx context.Context, s *models.OIDCState) error
+	// ConsumeState видаляє state і повертає його, якщо він не прострочений на момент now
+	ConsumeState(ctx context.Context, state string, now time.Time) (*models.OIDCState, error)
+	// PruneStates видаляє незавершені входи, прострочені на момент now
+	PruneStates(ctx context.Context, now time.Time) error
+	FindUserID(ctx context.Context, issuer, subject string) (string, error)
+	Link(ctx context.Context, id *models.Identity) error
+}
+
+// Set – усі репозиторії однієї реалізації сховища
+type Set struct {
+	Moods           MoodRepository
+	Users           UserRepository
+	Icons           IconRepository
+	Freezes         FreezeRepository
+	Reminders       ReminderRepository
+	Notifications   NotificationRepository
+	PushSubs        PushSubscriptionRepository
+	Jobs            JobRepository
+	Leases          LeaseRepository
+	LoginCodes      LoginCodeRepository
+	Sessions        SessionRepository
+	Identities      IdentityRepository
+	TelegramLinks   TelegramLinkRepository
+	TelegramUpdates TelegramUpdateRepository
+}
@@ -0,0 +1,245 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+type LoginCodeRepo struct {
+	db *sqlx.DB
+}
+
+func (r *LoginCodeRepo) Create(ctx context.Context, c *models.LoginCode) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`INSERT INTO login_codes (id, email, code_hash, expires_at) VALUES (?, ?, ?, ?)`),
+		c.ID, c.Email, c.CodeHash, ts(c.ExpiresAt))
+	return err
+}
+
+// perEmail виконує fn в транзакції, яка тримає блокування email: перевірки лімітів
+// і записи для одного email ідуть по черзі. У PostgreSQL це advisory lock до кінця
+// транзакції; SQLite працює з одним з'єднанням, тож транзакції й так не перетинаються.
+func (r *LoginCodeRepo) perEmail(ctx context.Context, email string, fn func(tx *sqlx.Tx) (bool, error)) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	if r.db.DriverName() == "postgres" {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "login_codes:"+email); err != nil {
+			return false, err
+		}
+	}
+	ok, err := fn(tx)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *LoginCodeRepo) CreateLimited(ctx context.Context, c *models.LoginCode, l repository.LoginLimits) (bool, error) {
+	return r.perEmail(ctx, c.Email, func(tx *sqlx.Tx) (bool, error) {
+		var n int
+		err := tx.GetContext(ctx, &n,
+			r.db.Rebind(`SELECT COUNT(*) FROM login_codes WHERE email=? AND created_at > ?`), c.Email, ts(l.Since))
+		if err != nil || n >= l.Codes {
+			return false, err
+		}
+		_, err = tx.ExecContext(ctx,
+			r.db.Rebind(`INSERT INTO login_codes (id, email, code_hash, expires_at) VALUES (?, ?, ?, ?)`),
+			c.ID, c.Email, c.CodeHash, ts(c.ExpiresAt))
+		return err == nil, err
+	})
+}
+
+func (r *LoginCodeRepo) CountSince(ctx context.Context, email string, since time.Time) (int, error) {
+	var n int
+	err := r.db.GetContext(ctx, &n,
+		r.db.Rebind(`SELECT COUNT(*) FROM login_codes WHERE email=? AND created_at > ?`), email, ts(since))
+	return n, err
+}
+
+func (r *LoginCodeRepo) LatestActive(ctx context.Context, email string, now time.Time) (*models.LoginCode, error) {
+	var c models.LoginCode
+	err := r.db.GetContext(ctx, &c,
+		r.db.Rebind(`SELECT id, email, code_hash, attempts, expires_at, used_at, created_at FROM login_codes
+         WHERE email=? AND used_at IS NULL AND expires_at > ?
+         ORDER BY created_at DESC LIMIT 1`),
+		email, ts(now))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &c, nil
+}
+
+func (r *LoginCodeRepo) IncrementAttempts(ctx context.Context, c *models.LoginCode, l repository.LoginLimits) (bool, error) {
+	return r.perEmail(ctx, c.Email, func(tx *sqlx.Tx) (bool, error) {
+		var n int
+		err := tx.GetContext(ctx, &n,
+			r.db.Rebind(`SELECT COALESCE(SUM(attempts), 0) FROM login_codes WHERE email=? AND created_at > ?`), c.Email, ts(l.Since))
+		if err != nil || n >= l.EmailAttempts {
+			return false, err
+		}
+		return affected(tx.ExecContext(ctx,
+			r.db.Rebind(`UPDATE login_codes SET attempts = attempts + 1 WHERE id=? AND attempts < ?`), c.ID, l.CodeAttempts))
+	})
+}
+
+func (r *LoginCodeRepo) MarkUsed(ctx context.Context, id string, at time.Time) (bool, error) {
+	return affected(r.db.ExecContext(ctx,
+		r.db.Rebind(`UPDATE login_codes SET used_at=? WHERE id=? AND used_at IS NULL`), ts(at), id))
+}
+
+func (r *LoginCodeRepo) Prune(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM login_codes WHERE created_at < ?`), ts(before))
+	return err
+}
+
+type SessionRepo struct {
+	db *sqlx.DB
+}
+
+func (r *SessionRepo) Create(ctx context.Context, s *models.Session) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`INSERT INTO sessions (id, family_id, user_id, token_hash, expires_at) VALUES (?, ?, ?, ?, ?)`),
+		s.ID, s.FamilyID, s.UserID, s.TokenHash, ts(s.ExpiresAt))
+	return err
+}
+
+func (r *SessionRepo) GetByTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	var s models.Session
+	err := r.db.GetContext(ctx, &s,
+		r.db.Rebind(`SELECT id, family_id, user_id, token_hash, expires_at, rotated_at, revoked_at, created_at
+         FROM sessions WHERE token_hash=?`), hash)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &s, nil
+}
+
+func (r *SessionRepo) MarkRotated(ctx context.Context, id string, at time.Time) (bool, error) {
+	return affected(r.db.ExecContext(ctx,
+		r.db.Rebind(`UPDATE sessions SET rotated_at=? WHERE id=? AND rotated_at IS NULL AND revoked_at IS NULL`), ts(at), id))
+}
+
+func (r *SessionRepo) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`UPDATE sessions SET revoked_at=? WHERE family_id=? AND revoked_at IS NULL`), ts(at), familyID)
+	return err
+}
+
+func (r *SessionRepo) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`UPDATE sessions SET revoked_at=? WHERE user_id=? AND revoked_at IS NULL`), ts(at), userID)
+	return err
+}
+
+func (r *SessionRepo) IsActive(ctx context.Context, familyID, userID string) (bool, error) {
+	var n int
+	err := r.db.GetContext(ctx, &n,
+		r.db.Rebind(`SELECT COUNT(*) FROM sessions WHERE family_id=? AND user_id=? AND revoked_at IS NULL`),
+		familyID, userID)
+	return n > 0, err
+}
+
+func (r *SessionRepo) Prune(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`DELETE FROM sessions WHERE expires_at < ? OR revoked_at < ?`), ts(before), ts(before))
+	return err
+}
+
+type IdentityRepo struct {
+	db *sqlx.DB
+}
+
+func (r *IdentityRepo) SaveState(ctx context.Context, s *models.OIDCState) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`INSERT INTO oidc_states (state, nonce, code_verifier, expires_at) VALUES (?, ?, ?, ?)`),
+		s.State, s.Nonce, s.CodeVerifier, ts(s.ExpiresAt))
+	return err
+}
+
+// ConsumeState: DELETE ... RETURNING робить state одноразовим
+func (r *IdentityRepo) ConsumeState(ctx context.Context, state string, now time.Time) (*models.OIDCState, error) {
+	var s models.OIDCState
+	err := r.db.GetContext(ctx, &s,
+		r.db.Rebind(`DELETE FROM oidc_states WHERE state=? AND expires_at > ? RETURNING state, nonce, code_verifier, expires_at`),
+		state, ts(now))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &s, nil
+}
+
+func (r *IdentityRepo) PruneStates(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM oidc_states WHERE expires_at <= ?`), ts(now))
+	return err
+}
+
+func (r *IdentityRepo) FindUserID(ctx context.Context, issuer, subject string) (string, error) {
+	var userID string
+	err := r.db.GetContext(ctx, &userID,
+		r.db.Rebind(`SELECT user_id FROM user_identities WHERE issuer=? AND subject=?`), issuer, subject)
+	if err != nil {
+		return "", notFound(err)
+	}
+	return userID, nil
+}
+
+func (r *IdentityRepo) Link(ctx context.Context, id *models.Identity) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`INSERT INTO user_identities (issuer, subject, user_id, email) VALUES (?, ?, ?, ?)`),
+		id.Issuer, id.Subject, id.UserID, id.Email)
+	return err
+}
+
+type TelegramLinkRepo struct {
+	db *sqlx.DB
+}
+
+func (r *TelegramLinkRepo) Create(ctx context.Context, l *models.TelegramLink) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`INSERT INTO telegram_links (token, user_id, expires_at) VALUES (?, ?, ?)`),
+		l.Token, l.UserID, ts(l.ExpiresAt))
+	return err
+}
+
+// Consume: DELETE ... RETURNING робить токен одноразовим
+func (r *TelegramLinkRepo) Consume(ctx context.Context, token string, now time.Time) (*models.TelegramLink, error) {
+	var l models.TelegramLink
+	err := r.db.GetContext(ctx, &l,
+		r.db.Rebind(`DELETE FROM telegram_links WHERE token=? AND expires_at > ? RETURNING token, user_id, expires_at`),
+		token, ts(now))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &l, nil
+}
+
+// Redeem: токен видаляється в тій самій транзакції, що й прив'язка чату, тож помилка
+// прив'язки відкочує і його видалення
+func (r *TelegramLinkRepo) Redeem(ctx context.Context, token string, chatID int64, now time.Time) (*models.TelegramLink, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var l models.TelegramLink
+	err = tx.GetContext(ctx, &l,
+		r.db.Rebind(`DELETE FROM telegram_links WHERE token=? AND expires_at > ? RETURNING token, user_id, expires_at`),
+		token, ts(now))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	_, err = tx.ExecContext(ctx, r.db.Rebind(`UPDATE users SET telegram_chat_id=? WHERE id=?`), chatID, l.UserID)
+	if err != nil {
+		return nil, conflict(err)
+	}
+	return &l, tx.Commit()
+}
@@ -0,0 +1,48 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"moodtracker/models"
+)
+
+type IconRepo struct {
+	db *sqlx.DB
+}
+
+func (r *IconRepo) List(ctx context.Context, userID string) ([]models.MoodIcon, error) {
+	icons := []models.MoodIcon{}
+	err := r.db.SelectContext(ctx, &icons,
+		r.db.Rebind(`SELECT icon, label, score, color FROM user_icons WHERE user_id=? ORDER BY score DESC, icon`), userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range icons {
+		icons[i].Custom = true
+	}
+	return icons, nil
+}
+
+func (r *IconRepo) Get(ctx context.Context, userID, icon string) (*models.MoodIcon, error) {
+	var mi models.MoodIcon
+	err := r.db.GetContext(ctx, &mi,
+		r.db.Rebind(`SELECT icon, label, score, color FROM user_icons WHERE user_id=? AND icon=?`), userID, icon)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	mi.Custom = true
+	return &mi, nil
+}
+
+func (r *IconRepo) Create(ctx context.Context, userID string, mi *models.MoodIcon) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`INSERT INTO user_icons (user_id, icon, label, score, color) VALUES (?, ?, ?, ?, ?)`),
+		userID, mi.Icon, mi.Label, mi.Score, mi.Color)
+	return conflict(err)
+}
+
+func (r *IconRepo) Delete(ctx context.Context, userID, icon string) error {
+	return existing(r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM user_icons WHERE user_id=? AND icon=?`), userID, icon))
+}
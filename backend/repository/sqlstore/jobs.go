@@ -0,0 +1,95 @@
+package sqlstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+type JobRepo struct {
+	db *sqlx.DB
+}
+
+func (r *JobRepo) Enqueue(ctx context.Context, j *models.Job) error {
+	ok, err := affected(r.db.ExecContext(ctx, r.db.Rebind(`
+        INSERT INTO outbox (id, idempotency_key, kind, recipient, payload, status, run_at, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (idempotency_key) DO NOTHING`),
+		j.ID, j.IdempotencyKey, j.Kind, j.Recipient, j.Payload, models.JobPending, ts(j.RunAt), ts(j.CreatedAt), ts(j.UpdatedAt)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return repository.ErrConflict
+	}
+	return nil
+}
+
+func (r *JobRepo) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Job, error) {
+	// У PostgreSQL паралельні воркери пропускають рядки, які вже забирає інший;
+	// SQLite виконує запис по одному, тож там блокування не потрібне
+	lock := ""
+	if r.db.DriverName() == "postgres" {
+		lock = " FOR UPDATE SKIP LOCKED"
+	}
+	jobs := []models.Job{}
+	err := r.db.SelectContext(ctx, &jobs, r.db.Rebind(`
+        UPDATE outbox SET status=?, locked_until=?, updated_at=?
+        WHERE id IN (
+            SELECT id FROM outbox
+            WHERE (status=? AND run_at <= ?) OR (status=? AND locked_until <= ?)
+            ORDER BY run_at LIMIT ?`+lock+`
+        )
+        RETURNING *`),
+		models.JobSending, ts(now.Add(lease)), ts(now),
+		models.JobPending, ts(now), models.JobSending, ts(now), limit)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING не гарантує порядку
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].RunAt.Before(jobs[k].RunAt) })
+	return jobs, nil
+}
+
+func (r *JobRepo) Release(ctx context.Context, j *models.Job) error {
+	// Записуємо лише під своєю орендою: якщо вона скінчилася і завдання забрав
+	// інший воркер, його locked_until уже інший і наш результат застарів
+	var lockedUntil any
+	if j.LockedUntil != nil {
+		lockedUntil = ts(*j.LockedUntil)
+	}
+	ok, err := affected(r.db.ExecContext(ctx, r.db.Rebind(`
+        UPDATE outbox SET status=?, attempts=?, run_at=?, last_error=?, locked_until=NULL, updated_at=?
+        WHERE id=? AND status=? AND locked_until=?`),
+		j.Status, j.Attempts, ts(j.RunAt), j.LastError, ts(time.Now()), j.ID, models.JobSending, lockedUntil))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return r.lost(ctx, j.ID)
+	}
+	return nil
+}
+
+func (r *JobRepo) Prune(ctx context.Context, sentBefore, deadBefore time.Time) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`
+        DELETE FROM outbox WHERE (status=? AND updated_at < ?) OR (status=? AND updated_at < ?)`),
+		models.JobSent, ts(sentBefore), models.JobDead, ts(deadBefore))
+	return err
+}
+
+// lost пояснює, чому Release не зачепив жодного рядка:
+// завдання немає (ErrNotFound) або оренду вже втрачено (ErrStale)
+func (r *JobRepo) lost(ctx context.Context, id string) error {
+	var status string
+	err := r.db.GetContext(ctx, &status, r.db.Rebind(`SELECT status FROM outbox WHERE id=?`), id)
+	if err != nil {
+		return notFound(err)
+	}
+	return repository.ErrStale
+}
@@ -0,0 +1,26 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LeaseRepo struct {
+	db *sqlx.DB
+}
+
+func (r *LeaseRepo) Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	// Рядок оновлюється, лише якщо оренда наша або вже закінчилася; інакше змінено 0 рядків
+	return affected(r.db.ExecContext(ctx, r.db.Rebind(`
+        INSERT INTO leases (name, holder, expires_at) VALUES (?, ?, ?)
+        ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+        WHERE leases.holder = excluded.holder OR leases.expires_at <= ?`),
+		name, holder, ts(now.Add(ttl)), ts(now)))
+}
+
+func (r *LeaseRepo) Release(ctx context.Context, name, holder string) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM leases WHERE name=? AND holder=?`), name, holder)
+	return err
+}
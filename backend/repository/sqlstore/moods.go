@@ -0,0 +1,213 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+type MoodRepo struct {
+	db *sqlx.DB
+}
+
+func (r *MoodRepo) Create(ctx context.Context, m *models.Mood) error {
+	// дату передаємо рядком: так колонка DATE однаково порівнюється в PostgreSQL і SQLite
+	var loggedAt interface{}
+	if m.LoggedAt != nil {
+		loggedAt = ts(*m.LoggedAt)
+	}
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`
+        INSERT INTO mood (id, user_id, date, logged_at, icon, score, comment, one_per_day, version, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?)`),
+		m.ID, m.UserID, day(m.Date), loggedAt, m.Icon, m.Score, m.Comment, m.OnePerDay, ts(m.CreatedAt), ts(m.UpdatedAt))
+	if err != nil {
+		return conflict(err)
+	}
+	m.Version = 1
+	return nil
+}
+
+func (r *MoodRepo) List(ctx context.Context, userID string, f repository.MoodFilter) ([]models.Mood, error) {
+	query := `SELECT * FROM mood WHERE user_id=?`
+	args := []interface{}{userID}
+	if f.From != nil {
+		query += " AND date >= ?"
+		args = append(args, day(*f.From))
+	}
+	if f.To != nil {
+		query += " AND date <= ?"
+		args = append(args, day(*f.To))
+	}
+
+	query += " ORDER BY date, COALESCE(logged_at, created_at)"
+
+	moods := []models.Mood{}
+	if err := r.db.SelectContext(ctx, &moods, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return moods, nil
+}
+
+func (r *MoodRepo) Page(ctx context.Context, userID string, q repository.MoodQuery) ([]models.Mood, error) {
+	query := `SELECT * FROM mood WHERE user_id=?`
+	args := []interface{}{userID}
+	if q.From != nil {
+		query += " AND date >= ?"
+		args = append(args, day(*q.From))
+	}
+	if q.To != nil {
+		query += " AND date <= ?"
+		args = append(args, day(*q.To))
+	}
+	if q.Icon != "" {
+		query += " AND icon = ?"
+		args = append(args, q.Icon)
+	}
+	if q.Score != nil {
+		query += " AND score = ?"
+		args = append(args, *q.Score)
+	}
+	if terms := repository.SearchTerms(q.Search); len(terms) > 0 {
+		query += r.search()
+		args = append(args, r.searchQuery(terms))
+	}
+
+	order, cmp := "ASC", ">"
+	if q.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if q.After != nil {
+		query += " AND (date " + cmp + " ? OR (date = ? AND id " + cmp + " ?))"
+		args = append(args, day(q.After.Date), day(q.After.Date), q.After.ID)
+	}
+	query += " ORDER BY date " + order + ", id " + order + " LIMIT ?"
+	args = append(args, q.Limit)
+
+	moods := []models.Mood{}
+	if err := r.db.SelectContext(ctx, &moods, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return moods, nil
+}
+
+// search – умова повнотекстового пошуку по коментарю: у PostgreSQL за виразом
+// GIN-індексу idx_mood_comment_search, у SQLite – по таблиці FTS5 mood_fts
+func (r *MoodRepo) search() string {
+	if r.db.DriverName() == "postgres" {
+		return " AND to_tsvector('simple', COALESCE(comment, '')) @@ to_tsquery('simple', ?)"
+	}
+	return " AND id IN (SELECT id FROM mood_fts WHERE mood_fts MATCH ?)"
+}
+
+// searchQuery будує запит для search, у якому кожне слово шукається як префікс.
+// Слова складаються лише з літер і цифр (SearchTerms), тож екранувати нічого не треба.
+func (r *MoodRepo) searchQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	if r.db.DriverName() == "postgres" {
+		for i, t := range terms {
+			parts[i] = t + ":*"
+		}
+		return strings.Join(parts, " & ")
+	}
+	for i, t := range terms {
+		parts[i] = `"` + t + `"*`
+	}
+	return strings.Join(parts, " ")
+}
+
+func (r *MoodRepo) Get(ctx context.Context, userID, id string) (*models.Mood, error) {
+	var m models.Mood
+	err := r.db.GetContext(ctx, &m, r.db.Rebind(`SELECT * FROM mood WHERE id=? AND user_id=?`), id, userID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &m, nil
+}
+
+func (r *MoodRepo) Update(ctx context.Context, m *models.Mood) error {
+	var loggedAt interface{}
+	if m.LoggedAt != nil {
+		loggedAt = ts(*m.LoggedAt)
+	}
+	ok, err := affected(r.db.ExecContext(ctx, r.db.Rebind(`
+        UPDATE mood SET date=?, logged_at=?, icon=?, score=?, comment=?, updated_at=?, version=version+1
+        WHERE id=? AND user_id=? AND version=?`),
+		day(m.Date), loggedAt, m.Icon, m.Score, m.Comment, ts(m.UpdatedAt), m.ID, m.UserID, m.Version))
+	if err != nil {
+		return conflict(err)
+	}
+	if !ok {
+		return r.stale(ctx, m.UserID, m.ID)
+	}
+	m.Version++
+	return nil
+}
+
+func (r *MoodRepo) Delete(ctx context.Context, userID, id string, version int) error {
+	query := `DELETE FROM mood WHERE id=? AND user_id=?`
+	args := []interface{}{id, userID}
+	if version != 0 {
+		query += " AND version=?"
+		args = append(args, version)
+	}
+	ok, err := affected(r.db.ExecContext(ctx, r.db.Rebind(query), args...))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return r.stale(ctx, userID, id)
+	}
+	return nil
+}
+
+// stale пояснює, чому умовна зміна не зачепила жодного рядка:
+// запису немає (ErrNotFound) або його версія вже інша (ErrStale)
+func (r *MoodRepo) stale(ctx context.Context, userID, id string) error {
+	var version int
+	err := r.db.GetContext(ctx, &version, r.db.Rebind(`SELECT version FROM mood WHERE id=? AND user_id=?`), id, userID)
+	if err != nil {
+		return notFound(err)
+	}
+	return repository.ErrStale
+}
+
+func (r *MoodRepo) LoggedDays(ctx context.Context, userID string) ([]time.Time, error) {
+	// індекс (user_id, date) покриває запит повністю
+	days := []time.Time{}
+	err := r.db.SelectContext(ctx, &days,
+		r.db.Rebind(`SELECT DISTINCT date FROM mood WHERE user_id=? ORDER BY date`), userID)
+	if err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+type FreezeRepo struct {
+	db *sqlx.DB
+}
+
+func (r *FreezeRepo) List(ctx context.Context, userID string) ([]time.Time, error) {
+	days := []time.Time{}
+	err := r.db.SelectContext(ctx, &days,
+		r.db.Rebind(`SELECT date FROM streak_freezes WHERE user_id=? ORDER BY date`), userID)
+	if err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+func (r *FreezeRepo) Add(ctx context.Context, userID string, d time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		r.db.Rebind(`INSERT INTO streak_freezes (user_id, date) VALUES (?, ?)`), userID, day(d))
+	return conflict(err)
+}
+
+func (r *FreezeRepo) Remove(ctx context.Context, userID string, d time.Time) error {
+	return existing(r.db.ExecContext(ctx,
+		r.db.Rebind(`DELETE FROM streak_freezes WHERE user_id=? AND date=?`), userID, day(d)))
+}
@@ -0,0 +1,102 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"moodtracker/models"
+)
+
+type NotificationRepo struct {
+	db *sqlx.DB
+}
+
+// notificationRow – рядок notification_settings; списки каналів зберігаються через кому, як у reminder_settings
+type notificationRow struct {
+	UserID           string `db:"user_id"`
+	ReminderChannels string `db:"reminder_channels"`
+	ReportChannels   string `db:"report_channels"`
+	WebhookURL       string `db:"webhook_url"`
+	WebhookSecret    string `db:"webhook_secret"`
+}
+
+const notificationColumns = `user_id, reminder_channels, report_channels, webhook_url, webhook_secret`
+
+func (r *NotificationRepo) Get(ctx context.Context, userID string) (*models.NotificationSettings, error) {
+	var row notificationRow
+	err := r.db.GetContext(ctx, &row,
+		r.db.Rebind(`SELECT `+notificationColumns+` FROM notification_settings WHERE user_id=?`), userID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &models.NotificationSettings{
+		UserID:        row.UserID,
+		Reminders:     split(row.ReminderChannels),
+		Reports:       split(row.ReportChannels),
+		WebhookURL:    row.WebhookURL,
+		WebhookSecret: row.WebhookSecret,
+	}, nil
+}
+
+func (r *NotificationRepo) Save(ctx context.Context, n *models.NotificationSettings) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`
+        INSERT INTO notification_settings (`+notificationColumns+`, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT (user_id) DO UPDATE SET
+            reminder_channels = excluded.reminder_channels,
+            report_channels = excluded.report_channels,
+            webhook_url = excluded.webhook_url,
+            webhook_secret = excluded.webhook_secret,
+            updated_at = excluded.updated_at`),
+		n.UserID, strings.Join(n.Reminders, ","), strings.Join(n.Reports, ","),
+		n.WebhookURL, n.WebhookSecret, ts(time.Now()))
+	return err
+}
+
+func (r *NotificationRepo) Delete(ctx context.Context, userID string) error {
+	return existing(r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM notification_settings WHERE user_id=?`), userID))
+}
+
+type PushSubscriptionRepo struct {
+	db *sqlx.DB
+}
+
+const pushColumns = `id, user_id, endpoint, p256dh, auth, created_at`
+
+func (r *PushSubscriptionRepo) Save(ctx context.Context, s *models.PushSubscription) error {
+	// Той самий браузер (endpoint) після повторної підписки чи входу під іншим акаунтом
+	// лишається одним рядком: оновлюються ключі і власник, id зберігається
+	return r.db.GetContext(ctx, &s.ID, r.db.Rebind(`
+        INSERT INTO push_subscriptions (`+pushColumns+`)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT (endpoint) DO UPDATE SET
+            user_id = excluded.user_id,
+            p256dh = excluded.p256dh,
+            auth = excluded.auth
+        RETURNING id`),
+		s.ID, s.UserID, s.Endpoint, s.P256dh, s.Auth, ts(s.CreatedAt))
+}
+
+func (r *PushSubscriptionRepo) Get(ctx context.Context, id string) (*models.PushSubscription, error) {
+	var s models.PushSubscription
+	err := r.db.GetContext(ctx, &s, r.db.Rebind(`SELECT `+pushColumns+` FROM push_subscriptions WHERE id=?`), id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &s, nil
+}
+
+func (r *PushSubscriptionRepo) ListByUser(ctx context.Context, userID string) ([]models.PushSubscription, error) {
+	subs := []models.PushSubscription{}
+	err := r.db.SelectContext(ctx, &subs,
+		r.db.Rebind(`SELECT `+pushColumns+` FROM push_subscriptions WHERE user_id=? ORDER BY created_at`), userID)
+	return subs, err
+}
+
+func (r *PushSubscriptionRepo) Delete(ctx context.Context, userID, id string) error {
+	return existing(r.db.ExecContext(ctx,
+		r.db.Rebind(`DELETE FROM push_subscriptions WHERE id=? AND user_id=?`), id, userID))
+}
@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"moodtracker/models"
+)
+
+type ReminderRepo struct {
+	db *sqlx.DB
+}
+
+// reminderRow – рядок reminder_settings: списки зберігаються через кому,
+// щоб схема була однаковою для PostgreSQL і SQLite
+type reminderRow struct {
+	UserID          string `db:"user_id"`
+	Times           string `db:"times"`
+	Weekdays        string `db:"weekdays"`
+	QuietStart      string `db:"quiet_start"`
+	QuietEnd        string `db:"quiet_end"`
+	ReportFrequency string `db:"report_frequency"`
+}
+
+const reminderColumns = `user_id, times, weekdays, quiet_start, quiet_end, report_frequency`
+
+func split(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+func (row reminderRow) settings() models.ReminderSettings {
+	rs := models.ReminderSettings{
+		UserID:          row.UserID,
+		Times:           split(row.Times),
+		Weekdays:        []int{},
+		QuietStart:      row.QuietStart,
+		QuietEnd:        row.QuietEnd,
+		ReportFrequency: row.ReportFrequency,
+	}
+	for _, d := range split(row.Weekdays) {
+		n, _ := strconv.Atoi(d)
+		rs.Weekdays = append(rs.Weekdays, n)
+	}
+	return rs
+}
+
+func (r *ReminderRepo) Get(ctx context.Context, userID string) (*models.ReminderSettings, error) {
+	var row reminderRow
+	err := r.db.GetContext(ctx, &row,
+		r.db.Rebind(`SELECT `+reminderColumns+` FROM reminder_settings WHERE user_id=?`), userID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	rs := row.settings()
+	return &rs, nil
+}
+
+func (r *ReminderRepo) Save(ctx context.Context, rs *models.ReminderSettings) error {
+	days := make([]string, len(rs.Weekdays))
+	for i, d := range rs.Weekdays {
+		days[i] = strconv.Itoa(d)
+	}
+	// ON CONFLICT ... DO UPDATE підтримують і PostgreSQL, і SQLite
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`
+        INSERT INTO reminder_settings (`+reminderColumns+`, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (user_id) DO UPDATE SET
+            times = excluded.times,
+            weekdays = excluded.weekdays,
+            quiet_start = excluded.quiet_start,
+            quiet_end = excluded.quiet_end,
+            report_frequency = excluded.report_frequency,
+            updated_at = excluded.updated_at`),
+		rs.UserID, strings.Join(rs.Times, ","), strings.Join(days, ","),
+		rs.QuietStart, rs.QuietEnd, rs.ReportFrequency, ts(time.Now()))
+	return err
+}
+
+func (r *ReminderRepo) Delete(ctx context.Context, userID string) error {
+	return existing(r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM reminder_settings WHERE user_id=?`), userID))
+}
+
+func (r *ReminderRepo) List(ctx context.Context) ([]models.ReminderSettings, error) {
+	rows := []reminderRow{}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT `+reminderColumns+` FROM reminder_settings ORDER BY user_id`); err != nil {
+		return nil, err
+	}
+	out := make([]models.ReminderSettings, len(rows))
+	for i, row := range rows {
+		out[i] = row.settings()
+	}
+	return out, nil
+}
@@ -0,0 +1,903 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"moodtracker/db"
+	"moodtracker/i18n"
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+// setupSQLite відкриває новий файл SQLite і застосовує до нього міграції
+func setupSQLite(t *testing.T) repository.Set {
+	database, err := db.Open("sqlite://" + filepath.Join(t.TempDir(), "mood.db"))
+	if err != nil {
+		t.Fatalf("не вдалося відкрити SQLite: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.MigrateUp(); err != nil {
+		t.Fatalf("міграції SQLite: %v", err)
+	}
+	return New(database.DB)
+}
+
+func mustDate(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func seedUser(t *testing.T, repos repository.Set, id, email string) {
+	if err := repos.Users.Create(context.Background(), &models.User{ID: id, Email: email}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func seedMood(t *testing.T, repos repository.Set, id, userID, date, icon string) {
+	now := time.Now()
+	err := repos.Moods.Create(context.Background(), &models.Mood{
+		ID: id, UserID: userID, Date: mustDate(date), Icon: icon, Comment: "c", OnePerDay: true, CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLite_Moods(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+	seedUser(t, repos, "user-2", "b@example.com")
+
+	seedMood(t, repos, "m1", "user-1", "2025-01-01", "🙂")
+	seedMood(t, repos, "m2", "user-1", "2025-01-31", "😞")
+	seedMood(t, repos, "m3", "user-1", "2025-02-01", "🙂")
+	seedMood(t, repos, "m4", "user-2", "2025-01-15", "😡")
+
+	// ux_mood_user_date_single: один запис на день у режимі single
+	err := repos.Moods.Create(ctx, &models.Mood{ID: "dup", UserID: "user-1", Date: mustDate("2025-01-01"), Icon: "😐", OnePerDay: true})
+	if !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("очікував ErrConflict, отримав %v", err)
+	}
+
+	from, to := mustDate("2025-01-01"), mustDate("2025-01-31")
+	list, err := repos.Moods.List(ctx, "user-1", repository.MoodFilter{From: &from, To: &to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("очікував 2 записи в межах включно, отримав %+v", list)
+	}
+
+	m, err := repos.Moods.Get(ctx, "user-1", "m2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Date.Format("2006-01-02") != "2025-01-31" || m.Icon != "😞" {
+		t.Errorf("неправильний запис: %+v", m)
+	}
+	if _, err := repos.Moods.Get(ctx, "user-2", "m2"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("чужий запис: очікував ErrNotFound, отримав %v", err)
+	}
+
+	if m.Version != 1 {
+		t.Errorf("новий запис: очікував версію 1, отримав %d", m.Version)
+	}
+	stale := *m
+	m.Icon, m.Comment, m.UpdatedAt = "😃", "краще", time.Now()
+	if err := repos.Moods.Update(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != 2 {
+		t.Errorf("після оновлення: очікував версію 2, отримав %d", m.Version)
+	}
+	// друге оновлення зі старою версією не затирає перше
+	if err := repos.Moods.Update(ctx, &stale); !errors.Is(err, repository.ErrStale) {
+		t.Errorf("оновлення застарілої версії: очікував ErrStale, отримав %v", err)
+	}
+	if err := repos.Moods.Update(ctx, &models.Mood{ID: "m2", UserID: "user-2"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("оновлення чужого запису: очікував ErrNotFound, отримав %v", err)
+	}
+
+	if m, _ := repos.Moods.Get(ctx, "user-1", "m2"); m.Icon != "😃" || m.Comment != "краще" {
+		t.Errorf("запис не оновлено: %+v", m)
+	}
+
+	if err := repos.Moods.Delete(ctx, "user-1", "m2", 1); !errors.Is(err, repository.ErrStale) {
+		t.Errorf("видалення застарілої версії: очікував ErrStale, отримав %v", err)
+	}
+	if err := repos.Moods.Delete(ctx, "user-1", "m2", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Moods.Delete(ctx, "user-1", "m1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Moods.Delete(ctx, "user-1", "m1", 0); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне видалення: очікував ErrNotFound, отримав %v", err)
+	}
+}
+
+func TestSQLite_MoodPage(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+	seedUser(t, repos, "user-2", "b@example.com")
+	score := func(n int) *int { return &n }
+	for _, m := range []models.Mood{
+		{ID: "a", Date: mustDate("2025-01-10"), Icon: "😃", Score: score(5), Comment: "Прогулянка в парку"},
+		{ID: "c", Date: mustDate("2025-01-10"), Icon: "😞", Score: score(2), Comment: "погано спав"},
+		{ID: "b", Date: mustDate("2025-01-11"), Icon: "😃", Score: score(5), Comment: "прогулянки і сон"},
+		{ID: "d", Date: mustDate("2025-01-12"), Icon: "😐", Score: score(3), Comment: "робота"},
+	} {
+		m.UserID, m.CreatedAt = "user-1", time.Now()
+		if err := repos.Moods.Create(ctx, &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seedMood(t, repos, "x", "user-2", "2025-01-10", "😃")
+
+	page := func(q repository.MoodQuery) string {
+		t.Helper()
+		if q.Limit == 0 {
+			q.Limit = 10
+		}
+		list, err := repos.Moods.Page(ctx, "user-1", q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(list))
+		for i, m := range list {
+			ids[i] = m.ID
+		}
+		return strings.Join(ids, ",")
+	}
+
+	// ключ (date, id) в обидва боки
+	if got := page(repository.MoodQuery{Limit: 2}); got != "a,c" {
+		t.Errorf("перша сторінка: %s", got)
+	}
+	if got := page(repository.MoodQuery{After: &repository.MoodKey{Date: mustDate("2025-01-10"), ID: "c"}}); got != "b,d" {
+		t.Errorf("після (10.01, c): %s", got)
+	}
+	if got := page(repository.MoodQuery{Desc: true, After: &repository.MoodKey{Date: mustDate("2025-01-11"), ID: "b"}}); got != "c,a" {
+		t.Errorf("desc після (11.01, b): %s", got)
+	}
+
+	from := mustDate("2025-01-11")
+	if got := page(repository.MoodQuery{MoodFilter: repository.MoodFilter{From: &from}}); got != "b,d" {
+		t.Errorf("лише from: %s", got)
+	}
+	if got := page(repository.MoodQuery{Icon: "😃"}); got != "a,b" {
+		t.Errorf("icon: %s", got)
+	}
+	if got := page(repository.MoodQuery{Score: score(2)}); got != "c" {
+		t.Errorf("score: %s", got)
+	}
+
+	// FTS5: регістр не важливий, слово шукається за початком
+	if got := page(repository.MoodQuery{Search: "ПРОГУЛЯНК"}); got != "a,b" {
+		t.Errorf("пошук: %s", got)
+	}
+	if got := page(repository.MoodQuery{Search: "прогулянк сон"}); got != "b" {
+		t.Errorf("пошук кількох слів: %s", got)
+	}
+	if got := page(repository.MoodQuery{Search: `"); DROP TABLE mood; --`}); got != "" {
+		t.Errorf("пошук без слів-збігів: %s", got)
+	}
+
+	// індекс пошуку стежить за змінами коментаря і видаленням
+	m, _ := repos.Moods.Get(ctx, "user-1", "d")
+	m.Comment = "вечірня прогулянка"
+	if err := repos.Moods.Update(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Moods.Delete(ctx, "user-1", "a", 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := page(repository.MoodQuery{Search: "прогулянка"}); got != "d" {
+		t.Errorf("пошук після змін: %s", got)
+	}
+	if got := page(repository.MoodQuery{Search: "робота"}); got != "" {
+		t.Errorf("старий коментар лишився в індексі: %s", got)
+	}
+}
+
+func TestSQLite_MoodsPerDay(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+
+	// кілька записів на день: вечірній вносимо першим, список однаково йде за часом
+	evening := time.Date(2025, 1, 15, 21, 0, 0, 0, time.UTC)
+	morning := time.Date(2025, 1, 15, 8, 30, 0, 0, time.UTC)
+	for _, m := range []models.Mood{
+		{ID: "eve", UserID: "user-1", Date: mustDate("2025-01-15"), LoggedAt: &evening, Icon: "😞", CreatedAt: time.Now()},
+		{ID: "morn", UserID: "user-1", Date: mustDate("2025-01-15"), LoggedAt: &morning, Icon: "😃", CreatedAt: time.Now()},
+	} {
+		if err := repos.Moods.Create(ctx, &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := repos.Moods.List(ctx, "user-1", repository.MoodFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != "morn" || list[1].ID != "eve" {
+		t.Fatalf("неправильний порядок записів: %+v", list)
+	}
+	if list[0].LoggedAt == nil || !list[0].LoggedAt.Equal(morning) {
+		t.Errorf("logged_at не збережено: %v", list[0].LoggedAt)
+	}
+
+	// записи "кілька на день" не заважають одному запису в режимі single на інший день
+	seedMood(t, repos, "single", "user-1", "2025-01-16", "🙂")
+
+	// перенесення дня: запис single не може зайняти день іншого запису single
+	seedMood(t, repos, "other", "user-1", "2025-01-17", "😐")
+	moved, _ := repos.Moods.Get(ctx, "user-1", "single")
+	moved.Date = mustDate("2025-01-17")
+	if err := repos.Moods.Update(ctx, moved); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("очікував ErrConflict, отримав %v", err)
+	}
+	at := time.Date(2025, 1, 18, 9, 0, 0, 0, time.UTC)
+	moved.Date, moved.LoggedAt = mustDate("2025-01-18"), &at
+	if err := repos.Moods.Update(ctx, moved); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repos.Moods.Get(ctx, "user-1", "single"); got.Date.Format("2006-01-02") != "2025-01-18" || got.LoggedAt == nil || !got.LoggedAt.Equal(at) {
+		t.Errorf("запис не перенесено: %+v", got)
+	}
+
+	// нагадування не отримує той, хто вже має хоч один запис за день
+	users, err := repos.Users.ListWithoutMood(ctx, mustDate("2025-01-15"))
+	if err != nil || len(users) != 0 {
+		t.Errorf("очікував порожній список для нагадування, отримав %+v, %v", users, err)
+	}
+
+	settings, err := repos.Users.GetSettings(ctx, "user-1")
+	if err != nil || settings.EntryMode != models.EntryModeSingle {
+		t.Fatalf("очікував режим single за замовчуванням, отримав %+v, %v", settings, err)
+	}
+	if settings.Timezone != "UTC" || settings.Locale != i18n.UK {
+		t.Errorf("очікував UTC і uk за замовчуванням, отримав %+v", settings)
+	}
+	err = repos.Users.UpdateSettings(ctx, "user-1", models.UserSettings{EntryMode: models.EntryModeMultiple, Timezone: "Europe/Kyiv", Locale: i18n.EN})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := repos.Users.GetByID(ctx, "user-1"); u.Locale != i18n.EN {
+		t.Errorf("мову не збережено: %+v", u)
+	}
+	if settings, _ := repos.Users.GetSettings(ctx, "user-1"); settings.EntryMode != models.EntryModeMultiple || settings.Timezone != "Europe/Kyiv" {
+		t.Errorf("налаштування не збережено: %+v", settings)
+	}
+	if _, err := repos.Users.GetSettings(ctx, "nobody"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+}
+
+func TestSQLite_Users(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+	seedUser(t, repos, "user-2", "b@example.com")
+	seedUser(t, repos, "user-3", "c@example.com")
+
+	if _, err := repos.Users.GetByEmail(ctx, "nobody@example.com"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+	if err := repos.Users.SetTelegramChatID(ctx, "user-1", 11); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Users.SetTelegramChatID(ctx, "user-2", 22); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := repos.Users.List(ctx)
+	if err != nil || len(all) != 3 || all[0].ID != "user-1" || *all[1].TelegramChatID != 22 {
+		t.Fatalf("очікував 3 користувачі за id, отримав %+v, %v", all, err)
+	}
+
+	today := time.Date(2025, 3, 10, 21, 30, 0, 0, time.Local)
+	seedMood(t, repos, "m1", "user-1", "2025-03-10", "🙂")
+	pending, err := repos.Users.ListWithoutMood(ctx, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].ID != "user-2" || *pending[0].TelegramChatID != 22 || pending[1].ID != "user-3" {
+		t.Errorf("нагадування мали отримати user-2 і user-3, отримав %+v", pending)
+	}
+}
+
+func TestSQLite_LoginCodes(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, id := range []string{"c1", "c2"} {
+		err := repos.LoginCodes.Create(ctx, &models.LoginCode{
+			ID: id, Email: "a@example.com", CodeHash: id, ExpiresAt: now.Add(time.Duration(i+1) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// created_at береться з DEFAULT з точністю до мілісекунд
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	n, err := repos.LoginCodes.CountSince(ctx, "a@example.com", now.Add(-time.Hour))
+	if err != nil || n != 2 {
+		t.Errorf("очікував 2 коди за годину, отримав %d, %v", n, err)
+	}
+	if n, _ := repos.LoginCodes.CountSince(ctx, "a@example.com", time.Now().Add(time.Minute)); n != 0 {
+		t.Errorf("очікував 0 кодів у майбутньому, отримав %d", n)
+	}
+
+	lc, err := repos.LoginCodes.LatestActive(ctx, "a@example.com", time.Now())
+	if err != nil || lc.ID != "c2" {
+		t.Fatalf("очікував найсвіжіший код c2, отримав %+v, %v", lc, err)
+	}
+	// спроби зараховуються лише до ліміту коду, а потім – до ліміту email на всі коди
+	limits := repository.LoginLimits{Since: now.Add(-time.Hour), Codes: 3, CodeAttempts: 2, EmailAttempts: 3}
+	for i := 0; i < 3; i++ {
+		ok, err := repos.LoginCodes.IncrementAttempts(ctx, lc, limits)
+		if err != nil || ok != (i < 2) {
+			t.Fatalf("спроба %d: отримав %v, %v", i+1, ok, err)
+		}
+	}
+	c1 := &models.LoginCode{ID: "c1", Email: "a@example.com"}
+	for i := 0; i < 2; i++ {
+		ok, err := repos.LoginCodes.IncrementAttempts(ctx, c1, limits)
+		if err != nil || ok != (i < 1) {
+			t.Fatalf("спроба %d для c1: отримав %v, %v", i+1, ok, err)
+		}
+	}
+
+	// видача коду з лімітом: третій ще можна, четвертий – ні
+	for i, want := range []bool{true, false} {
+		ok, err := repos.LoginCodes.CreateLimited(ctx, &models.LoginCode{
+			ID: fmt.Sprintf("c%d", i+3), Email: "a@example.com", CodeHash: "x", ExpiresAt: now,
+		}, limits)
+		if err != nil || ok != want {
+			t.Fatalf("код %d: очікував %v, отримав %v, %v", i+3, want, ok, err)
+		}
+	}
+	if used, err := repos.LoginCodes.MarkUsed(ctx, "c2", time.Now()); err != nil || !used {
+		t.Fatalf("очікував успішне використання, отримав %v, %v", used, err)
+	}
+	if used, _ := repos.LoginCodes.MarkUsed(ctx, "c2", time.Now()); used {
+		t.Error("код не можна використати двічі")
+	}
+
+	// c2 використано, c1 спливає через хвилину
+	lc, err = repos.LoginCodes.LatestActive(ctx, "a@example.com", time.Now())
+	if err != nil || lc.ID != "c1" {
+		t.Errorf("очікував c1, отримав %+v, %v", lc, err)
+	}
+	if _, err := repos.LoginCodes.LatestActive(ctx, "a@example.com", now.Add(2*time.Minute)); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("прострочені коди: очікував ErrNotFound, отримав %v", err)
+	}
+
+	// Prune прибирає коди, видані до межі, і лишає новіші
+	if err := repos.LoginCodes.Prune(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := repos.LoginCodes.CountSince(ctx, "a@example.com", now.Add(-time.Hour)); n != 0 {
+		t.Errorf("після Prune: очікував 0 кодів, отримав %d", n)
+	}
+}
+
+func TestSQLite_Sessions(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+
+	for _, s := range []models.Session{
+		{ID: "s1", FamilyID: "fam-1", TokenHash: "h1"},
+		{ID: "s2", FamilyID: "fam-2", TokenHash: "h2"},
+	} {
+		s.UserID, s.ExpiresAt = "user-1", time.Now().Add(time.Hour)
+		if err := repos.Sessions.Create(ctx, &s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := repos.Sessions.GetByTokenHash(ctx, "h1")
+	if err != nil || s.FamilyID != "fam-1" || s.RotatedAt != nil {
+		t.Fatalf("неправильна сесія: %+v, %v", s, err)
+	}
+	if ok, _ := repos.Sessions.MarkRotated(ctx, "s1", time.Now()); !ok {
+		t.Error("очікував успішну ротацію")
+	}
+	if ok, _ := repos.Sessions.MarkRotated(ctx, "s1", time.Now()); ok {
+		t.Error("токен не можна ротувати двічі")
+	}
+
+	if err := repos.Sessions.RevokeFamily(ctx, "fam-1", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := repos.Sessions.IsActive(ctx, "fam-1", "user-1"); active {
+		t.Error("fam-1 мала бути відкликана")
+	}
+	if active, _ := repos.Sessions.IsActive(ctx, "fam-2", "user-1"); !active {
+		t.Error("fam-2 мала лишитися активною")
+	}
+	if err := repos.Sessions.RevokeUser(ctx, "user-1", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := repos.Sessions.IsActive(ctx, "fam-2", "user-1"); active {
+		t.Error("усі сесії user-1 мали бути відкликані")
+	}
+	if s, _ := repos.Sessions.GetByTokenHash(ctx, "h2"); s.RevokedAt == nil {
+		t.Error("revoked_at не прочитано")
+	}
+
+	// відкликані до межі токени видаляються, а свіжий чинний лишається
+	s3 := models.Session{ID: "s3", FamilyID: "fam-3", UserID: "user-1", TokenHash: "h3", ExpiresAt: time.Now().Add(2 * time.Hour)}
+	if err := repos.Sessions.Create(ctx, &s3); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Sessions.Prune(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Sessions.GetByTokenHash(ctx, "h1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("відкликаний токен мав бути видалений: %v", err)
+	}
+	if active, _ := repos.Sessions.IsActive(ctx, "fam-3", "user-1"); !active {
+		t.Error("чинна сесія fam-3 мала лишитися")
+	}
+}
+
+func TestSQLite_Identities(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+
+	now := time.Now()
+	for _, st := range []models.OIDCState{
+		{State: "fresh", Nonce: "n", CodeVerifier: "v", ExpiresAt: now.Add(time.Minute)},
+		{State: "stale", Nonce: "n", CodeVerifier: "v", ExpiresAt: now.Add(-time.Minute)},
+	} {
+		if err := repos.Identities.SaveState(ctx, &st); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st, err := repos.Identities.ConsumeState(ctx, "fresh", now)
+	if err != nil || st.Nonce != "n" || st.CodeVerifier != "v" {
+		t.Fatalf("неправильний state: %+v, %v", st, err)
+	}
+	if _, err := repos.Identities.ConsumeState(ctx, "fresh", now); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("state має бути одноразовим, отримав %v", err)
+	}
+	if _, err := repos.Identities.ConsumeState(ctx, "stale", now); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("прострочений state: очікував ErrNotFound, отримав %v", err)
+	}
+	// прострочений рядок лишається в таблиці, доки його не прибере PruneStates
+	if err := repos.Identities.PruneStates(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Identities.ConsumeState(ctx, "stale", now.Add(-2*time.Minute)); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("після PruneStates: очікував ErrNotFound, отримав %v", err)
+	}
+
+	if _, err := repos.Identities.FindUserID(ctx, "https://idp", "sub"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+	if err := repos.Identities.Link(ctx, &models.Identity{Issuer: "https://idp", Subject: "sub", UserID: "user-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := repos.Identities.FindUserID(ctx, "https://idp", "sub"); err != nil || id != "user-1" {
+		t.Errorf("очікував user-1, отримав %q, %v", id, err)
+	}
+	// зовнішній ключ на users увімкнено
+	if err := repos.Identities.Link(ctx, &models.Identity{Issuer: "https://idp", Subject: "other", UserID: "ghost"}); err == nil {
+		t.Error("очікував порушення зовнішнього ключа")
+	}
+}
+
+func TestSQLite_Icons(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+
+	mi := &models.MoodIcon{Icon: "🦄", Label: "Казково", Score: 5, Color: "#8e24aa"}
+	if err := repos.Icons.Create(ctx, "user-1", mi); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Icons.Create(ctx, "user-1", mi); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("повторна іконка: очікував ErrConflict, отримав %v", err)
+	}
+	if err := repos.Icons.Create(ctx, "user-1", &models.MoodIcon{Icon: "🙃", Label: "x", Score: 9, Color: "#000000"}); err == nil {
+		t.Error("бал поза шкалою мав порушити CHECK")
+	}
+
+	got, err := repos.Icons.Get(ctx, "user-1", "🦄")
+	if err != nil || got.Score != 5 || got.Label != "Казково" || !got.Custom {
+		t.Errorf("неправильна іконка: %+v, %v", got, err)
+	}
+	list, err := repos.Icons.List(ctx, "user-1")
+	if err != nil || len(list) != 1 {
+		t.Errorf("очікував одну іконку, отримав %+v, %v", list, err)
+	}
+
+	score := got.Score
+	m := models.Mood{ID: "m1", UserID: "user-1", Date: mustDate("2025-01-01"), Icon: "🦄", Score: &score, OnePerDay: true}
+	if err := repos.Moods.Create(ctx, &m); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repos.Moods.Get(ctx, "user-1", "m1")
+	if err != nil || stored.Score == nil || *stored.Score != 5 {
+		t.Errorf("бал не збережено: %+v, %v", stored, err)
+	}
+
+	if err := repos.Icons.Delete(ctx, "user-1", "🦄"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Icons.Get(ctx, "user-1", "🦄"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+	if err := repos.Icons.Delete(ctx, "user-1", "🦄"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне видалення: очікував ErrNotFound, отримав %v", err)
+	}
+}
+
+func TestSQLite_Streaks(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+
+	seedMood(t, repos, "m1", "user-1", "2025-01-03", "🙂")
+	seedMood(t, repos, "m2", "user-1", "2025-01-01", "🙂")
+	// другий запис того ж дня не дублює день
+	if err := repos.Moods.Create(ctx, &models.Mood{ID: "m3", UserID: "user-1", Date: mustDate("2025-01-03"), Icon: "😞"}); err != nil {
+		t.Fatal(err)
+	}
+	logged, err := repos.Moods.LoggedDays(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 2 || day(logged[0]) != "2025-01-01" || day(logged[1]) != "2025-01-03" {
+		t.Errorf("неправильні дні із записами: %v", logged)
+	}
+
+	if err := repos.Freezes.Add(ctx, "user-1", mustDate("2025-01-02")); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Freezes.Add(ctx, "user-1", mustDate("2025-01-02")); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("повторне заморожування: очікував ErrConflict, отримав %v", err)
+	}
+	frozen, err := repos.Freezes.List(ctx, "user-1")
+	if err != nil || len(frozen) != 1 || day(frozen[0]) != "2025-01-02" {
+		t.Errorf("неправильні заморожені дні: %v, %v", frozen, err)
+	}
+	if err := repos.Freezes.Remove(ctx, "user-1", mustDate("2025-01-02")); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Freezes.Remove(ctx, "user-1", mustDate("2025-01-02")); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне видалення: очікував ErrNotFound, отримав %v", err)
+	}
+}
+
+func TestSQLite_Reminders(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "u1@example.com")
+
+	if _, err := repos.Reminders.Get(ctx, "user-1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("без налаштувань: очікував ErrNotFound, отримав %v", err)
+	}
+
+	rs := &models.ReminderSettings{
+		UserID: "user-1", Times: []string{"08:00", "20:30"}, Weekdays: []int{1, 3, 5},
+		QuietStart: "22:00", QuietEnd: "07:00", ReportFrequency: models.ReportMonthly,
+	}
+	if err := repos.Reminders.Save(ctx, rs); err != nil {
+		t.Fatal(err)
+	}
+	// повторне збереження замінює запис
+	rs.Times, rs.Weekdays = []string{}, []int{}
+	if err := repos.Reminders.Save(ctx, rs); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repos.Reminders.Get(ctx, "user-1")
+	if err != nil || len(got.Times) != 0 || len(got.Weekdays) != 0 || got.QuietEnd != "07:00" || got.ReportFrequency != models.ReportMonthly {
+		t.Errorf("неправильні налаштування: %+v, %v", got, err)
+	}
+
+	rs.Times, rs.Weekdays = []string{"09:15"}, []int{0, 6}
+	repos.Reminders.Save(ctx, rs)
+	list, err := repos.Reminders.List(ctx)
+	if err != nil || len(list) != 1 || list[0].Times[0] != "09:15" || list[0].Weekdays[1] != 6 {
+		t.Errorf("неправильний список: %+v, %v", list, err)
+	}
+
+	if err := repos.Reminders.Delete(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Reminders.Delete(ctx, "user-1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне видалення: очікував ErrNotFound, отримав %v", err)
+	}
+}
+
+func TestSQLite_Jobs(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	now := time.Now()
+	job := func(id, key string, runAt time.Time) *models.Job {
+		return &models.Job{ID: id, IdempotencyKey: key, Kind: models.JobTelegram, Recipient: "42", Payload: "hi",
+			RunAt: runAt, CreatedAt: now, UpdatedAt: now}
+	}
+
+	if err := repos.Jobs.Enqueue(ctx, job("job-1", "k1", now.Add(-time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Jobs.Enqueue(ctx, job("job-2", "k1", now)); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("той самий ключ: очікував ErrConflict, отримав %v", err)
+	}
+	if err := repos.Jobs.Enqueue(ctx, job("job-3", "k3", now.Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := repos.Jobs.Claim(ctx, now, 10, time.Minute)
+	if err != nil || len(jobs) != 1 || jobs[0].ID != "job-1" || jobs[0].Status != models.JobSending || jobs[0].LockedUntil == nil {
+		t.Fatalf("очікував лише job-1: %+v, %v", jobs, err)
+	}
+	// узяте завдання не віддається вдруге, доки не скінчилася оренда
+	if again, _ := repos.Jobs.Claim(ctx, now, 10, time.Minute); len(again) != 0 {
+		t.Errorf("завдання забрано двічі: %+v", again)
+	}
+	expired, _ := repos.Jobs.Claim(ctx, now.Add(2*time.Minute), 10, time.Minute)
+	if len(expired) != 1 || expired[0].ID != "job-1" {
+		t.Fatalf("після оренди завдання має повернутися: %+v", expired)
+	}
+	// перший воркер утратив оренду: його результат не має затерти роботу другого
+	lost := jobs[0]
+	lost.Status = models.JobSent
+	if err := repos.Jobs.Release(ctx, &lost); !errors.Is(err, repository.ErrStale) {
+		t.Errorf("утрачена оренда: очікував ErrStale, отримав %v", err)
+	}
+
+	j := expired[0]
+	j.Status, j.Attempts, j.RunAt, j.LastError = models.JobPending, 1, now.Add(-time.Second), "timeout"
+	if err := repos.Jobs.Release(ctx, &j); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Jobs.Release(ctx, &j); !errors.Is(err, repository.ErrStale) {
+		t.Errorf("повторний Release: очікував ErrStale, отримав %v", err)
+	}
+	jobs, err = repos.Jobs.Claim(ctx, now, 10, time.Minute)
+	if err != nil || len(jobs) != 1 || jobs[0].Attempts != 1 || jobs[0].LastError != "timeout" {
+		t.Fatalf("очікував повтор job-1: %+v, %v", jobs, err)
+	}
+
+	j = jobs[0]
+	j.Status = models.JobSent
+	repos.Jobs.Release(ctx, &j)
+	if left, _ := repos.Jobs.Claim(ctx, now.Add(2*time.Hour), 10, time.Minute); len(left) != 1 || left[0].ID != "job-3" {
+		t.Errorf("надіслане завдання не має повертатися: %+v", left)
+	}
+	if err := repos.Jobs.Release(ctx, &models.Job{ID: "missing", Status: models.JobSent}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+
+	// надіслане завдання старше за межу видаляється і звільняє свій ключ; очікуване лишається
+	if err := repos.Jobs.Prune(ctx, time.Now().Add(time.Minute), now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Jobs.Enqueue(ctx, job("job-4", "k1", now)); err != nil {
+		t.Errorf("після Prune ключ k1 має звільнитися: %v", err)
+	}
+	if err := repos.Jobs.Enqueue(ctx, job("job-5", "k3", now)); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("очікуване завдання не видаляється: %v", err)
+	}
+}
+
+func TestSQLite_Leases(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if ok, err := repos.Leases.Acquire(ctx, "scheduler", "a", now, time.Minute); err != nil || !ok {
+		t.Fatalf("перша оренда: %v, %v", ok, err)
+	}
+	if ok, _ := repos.Leases.Acquire(ctx, "scheduler", "b", now, time.Minute); ok {
+		t.Error("чинну оренду іншого інстансу не можна забрати")
+	}
+	if ok, _ := repos.Leases.Acquire(ctx, "scheduler", "a", now.Add(30*time.Second), time.Minute); !ok {
+		t.Error("власник має продовжити оренду")
+	}
+	if ok, _ := repos.Leases.Acquire(ctx, "scheduler", "b", now.Add(time.Minute), time.Minute); ok {
+		t.Error("продовжена оренда ще чинна")
+	}
+	if ok, _ := repos.Leases.Acquire(ctx, "scheduler", "b", now.Add(2*time.Minute), time.Minute); !ok {
+		t.Error("прострочену оренду має забрати інший інстанс")
+	}
+
+	// Release чужої оренди нічого не робить
+	repos.Leases.Release(ctx, "scheduler", "a")
+	if ok, _ := repos.Leases.Acquire(ctx, "scheduler", "a", now.Add(2*time.Minute), time.Minute); ok {
+		t.Error("оренду b відпустив не власник")
+	}
+	repos.Leases.Release(ctx, "scheduler", "b")
+	if ok, _ := repos.Leases.Acquire(ctx, "scheduler", "a", now.Add(2*time.Minute), time.Minute); !ok {
+		t.Error("після Release оренду можна взяти одразу")
+	}
+}
+
+func TestSQLite_TelegramLinks(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "u1@example.com")
+	seedUser(t, repos, "user-2", "u2@example.com")
+	now := time.Now()
+
+	repos.TelegramLinks.Create(ctx, &models.TelegramLink{Token: "tok", UserID: "user-1", ExpiresAt: now.Add(time.Minute)})
+	repos.TelegramLinks.Create(ctx, &models.TelegramLink{Token: "old", UserID: "user-1", ExpiresAt: now.Add(-time.Minute)})
+	if _, err := repos.TelegramLinks.Consume(ctx, "old", now); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("прострочений токен: очікував ErrNotFound, отримав %v", err)
+	}
+	l, err := repos.TelegramLinks.Consume(ctx, "tok", now)
+	if err != nil || l.UserID != "user-1" {
+		t.Fatalf("неправильний токен: %+v, %v", l, err)
+	}
+	if _, err := repos.TelegramLinks.Consume(ctx, "tok", now); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне використання: очікував ErrNotFound, отримав %v", err)
+	}
+
+	if err := repos.Users.SetTelegramChatID(ctx, "user-1", 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Users.SetTelegramChatID(ctx, "user-2", 42); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("чужий чат: очікував ErrConflict, отримав %v", err)
+	}
+	// Redeem: чат зайнятий – токен лишається; вільний чат – прив'язка і токен витрачено
+	repos.TelegramLinks.Create(ctx, &models.TelegramLink{Token: "tok2", UserID: "user-2", ExpiresAt: now.Add(time.Minute)})
+	if _, err := repos.TelegramLinks.Redeem(ctx, "tok2", 42, now); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("чужий чат: очікував ErrConflict, отримав %v", err)
+	}
+	if l, err := repos.TelegramLinks.Redeem(ctx, "tok2", 43, now); err != nil || l.UserID != "user-2" {
+		t.Fatalf("токен мав лишитися після відмови: %+v, %v", l, err)
+	}
+	if u, err := repos.Users.GetByTelegramChatID(ctx, 43); err != nil || u.ID != "user-2" {
+		t.Errorf("чат 43 мав прив'язатися до user-2: %+v, %v", u, err)
+	}
+	if _, err := repos.TelegramLinks.Redeem(ctx, "tok2", 44, now); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне використання: очікував ErrNotFound, отримав %v", err)
+	}
+	if u, err := repos.Users.GetByTelegramChatID(ctx, 42); err != nil || u.ID != "user-1" {
+		t.Errorf("пошук за чатом: %+v, %v", u, err)
+	}
+	if err := repos.Users.UnlinkTelegram(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if u, err := repos.Users.GetByID(ctx, "user-1"); err != nil || u.TelegramChatID != nil {
+		t.Errorf("чат не від'єднано: %+v, %v", u, err)
+	}
+	if err := repos.Users.UnlinkTelegram(ctx, "user-1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне від'єднання: очікував ErrNotFound, отримав %v", err)
+	}
+	if _, err := repos.Users.GetByID(ctx, "missing"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+}
+
+func TestSQLite_TelegramUpdates(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	now := time.Now()
+	updates := repos.TelegramUpdates
+
+	if ok, err := updates.Claim(ctx, 100, now.Add(-48*time.Hour), time.Minute); err != nil || !ok {
+		t.Fatalf("перше взяття: %v, %v", ok, err)
+	}
+	if err := updates.Done(ctx, 100, now.Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := updates.Claim(ctx, 100, now, time.Minute); err != nil || ok {
+		t.Errorf("повторна доставка: очікував false, отримав %v, %v", ok, err)
+	}
+	if err := updates.Done(ctx, 404, now); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Done невідомого оновлення: очікував ErrNotFound, отримав %v", err)
+	}
+
+	// процес упав до Done: у межах оренди оновлення зайняте, після неї – знову вільне
+	if ok, err := updates.Claim(ctx, 200, now, time.Minute); err != nil || !ok {
+		t.Fatalf("взяття 200: %v, %v", ok, err)
+	}
+	if _, err := updates.Claim(ctx, 200, now.Add(30*time.Second), time.Minute); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("у межах оренди: очікував ErrConflict, отримав %v", err)
+	}
+	if ok, err := updates.Claim(ctx, 200, now.Add(2*time.Minute), time.Minute); err != nil || !ok {
+		t.Errorf("після оренди: очікував true, отримав %v, %v", ok, err)
+	}
+
+	if err := updates.Prune(ctx, now.Add(-24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// після очищення старих позначок той самий update_id знову новий
+	if ok, err := updates.Claim(ctx, 100, now, time.Minute); err != nil || !ok {
+		t.Errorf("після Prune: очікував true, отримав %v, %v", ok, err)
+	}
+}
+
+func TestSQLite_Notifications(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+
+	if _, err := repos.Notifications.Get(ctx, "user-1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("очікував ErrNotFound, отримав %v", err)
+	}
+	s := &models.NotificationSettings{
+		UserID: "user-1", Reminders: []string{models.JobTelegram, models.JobWebPush}, Reports: []string{},
+		WebhookURL: "https://example.com/hook", WebhookSecret: "secret",
+	}
+	for i := 0; i < 2; i++ {
+		if err := repos.Notifications.Save(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+		s.Reports = []string{models.JobWebhook}
+	}
+	got, err := repos.Notifications.Get(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Reminders) != 2 || got.Reminders[1] != models.JobWebPush || len(got.Reports) != 1 || got.Reports[0] != models.JobWebhook ||
+		got.WebhookURL != s.WebhookURL || got.WebhookSecret != "secret" {
+		t.Errorf("неправильні налаштування: %+v", got)
+	}
+
+	if err := repos.Notifications.Delete(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Notifications.Delete(ctx, "user-1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне видалення: очікував ErrNotFound, отримав %v", err)
+	}
+}
+
+func TestSQLite_PushSubscriptions(t *testing.T) {
+	repos := setupSQLite(t)
+	ctx := context.Background()
+	seedUser(t, repos, "user-1", "a@example.com")
+	seedUser(t, repos, "user-2", "b@example.com")
+
+	s := &models.PushSubscription{ID: "s1", UserID: "user-1", Endpoint: "https://push.example.com/1", P256dh: "k1", Auth: "a1", CreatedAt: time.Now()}
+	if err := repos.PushSubs.Save(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	// той самий endpoint під іншим акаунтом: рядок і id ті самі, власник і ключі нові
+	again := &models.PushSubscription{ID: "s2", UserID: "user-2", Endpoint: s.Endpoint, P256dh: "k2", Auth: "a2", CreatedAt: time.Now()}
+	if err := repos.PushSubs.Save(ctx, again); err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != "s1" {
+		t.Errorf("очікував id s1, отримав %s", again.ID)
+	}
+	got, err := repos.PushSubs.Get(ctx, "s1")
+	if err != nil || got.UserID != "user-2" || got.P256dh != "k2" || got.Auth != "a2" {
+		t.Fatalf("неправильна підписка: %+v, %v", got, err)
+	}
+	if list, _ := repos.PushSubs.ListByUser(ctx, "user-1"); len(list) != 0 {
+		t.Errorf("user-1 не має підписок, отримав %d", len(list))
+	}
+	if list, _ := repos.PushSubs.ListByUser(ctx, "user-2"); len(list) != 1 {
+		t.Errorf("user-2 має одну підписку, отримав %d", len(list))
+	}
+
+	// чужу підписку видалити не можна
+	if err := repos.PushSubs.Delete(ctx, "user-1", "s1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+	if err := repos.PushSubs.Delete(ctx, "user-2", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.PushSubs.Get(ctx, "s1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+}
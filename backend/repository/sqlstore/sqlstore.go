@@ -0,0 +1,96 @@
+// Package sqlstore – реалізація репозиторіїв поверх sqlx для PostgreSQL і SQLite.
+// Запити пишуться з плейсхолдерами "?" і проходять через db.Rebind, а дати та
+// інтервали обчислюються в Go, тож SQL однаковий для обох діалектів.
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"moodtracker/repository"
+)
+
+const dateLayout = "2006-01-02"
+
+// New повертає набір репозиторіїв, що працюють з однією БД
+func New(db *sqlx.DB) repository.Set {
+	return repository.Set{
+		Moods:           &MoodRepo{db: db},
+		Users:           &UserRepo{db: db},
+		Icons:           &IconRepo{db: db},
+		Freezes:         &FreezeRepo{db: db},
+		Reminders:       &ReminderRepo{db: db},
+		Notifications:   &NotificationRepo{db: db},
+		PushSubs:        &PushSubscriptionRepo{db: db},
+		Jobs:            &JobRepo{db: db},
+		Leases:          &LeaseRepo{db: db},
+		LoginCodes:      &LoginCodeRepo{db: db},
+		Sessions:        &SessionRepo{db: db},
+		Identities:      &IdentityRepo{db: db},
+		TelegramLinks:   &TelegramLinkRepo{db: db},
+		TelegramUpdates: &TelegramUpdateRepo{db: db},
+	}
+}
+
+// notFound перетворює sql.ErrNoRows на repository.ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+// conflict перетворює порушення унікальності (PostgreSQL і SQLite) на repository.ErrConflict
+func conflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: %s", repository.ErrConflict, pqErr.Constraint)
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) && (liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY) {
+		return fmt.Errorf("%w: %s", repository.ErrConflict, liteErr.Error())
+	}
+	return err
+}
+
+// affected повертає true, якщо запит змінив хоча б один рядок
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// existing повертає ErrNotFound, якщо запит не змінив жодного рядка
+func existing(res sql.Result, err error) error {
+	ok, err := affected(res, err)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// day переводить момент часу в календарну дату для порівняння з колонкою DATE
+func day(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// ts переводить момент часу в UTC: SQLite зберігає мітки текстом, і порівняння
+// рядків коректне лише за однакового зсуву. Для TIMESTAMPTZ у PostgreSQL це той самий момент.
+func ts(t time.Time) time.Time {
+	return t.UTC()
+}
@@ -0,0 +1,276 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"moodtracker/models"
+	"moodtracker/repository"
+)
+
+func setupStore(t *testing.T) (repository.Set, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("не вдалося створити sqlmock: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return New(sqlx.NewDb(sqlDB, "postgres")), mock
+}
+
+func checkExpectations(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("не виконані очікування sqlmock: %v", err)
+	}
+}
+
+func TestMoodRepo_ListWithFilter(t *testing.T) {
+	repos, mock := setupStore(t)
+	from := time.Date(2025, 1, 1, 15, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM mood WHERE user_id=$1 AND date >= $2 AND date <= $3")).
+		WithArgs("user-1", "2025-01-01", "2025-01-31").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "date", "icon", "comment", "created_at", "updated_at"}).
+			AddRow("m1", "user-1", now, "🙂", "fine", now, now))
+
+	moods, err := repos.Moods.List(context.Background(), "user-1", repository.MoodFilter{From: &from, To: &to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moods) != 1 || moods[0].ID != "m1" {
+		t.Errorf("неправильні записи: %+v", moods)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestMoodRepo_CreateUniqueViolation(t *testing.T) {
+	repos, mock := setupStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO mood")).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "ux_user_date", Message: "duplicate key value violates unique constraint"})
+
+	now := time.Now()
+	err := repos.Moods.Create(context.Background(), &models.Mood{ID: "m1", UserID: "user-1", Date: now, CreatedAt: now, UpdatedAt: now})
+	if !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("очікував ErrConflict, отримав %v", err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestMoodRepo_PageSearch(t *testing.T) {
+	repos, mock := setupStore(t)
+	after := &repository.MoodKey{Date: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), ID: "m1"}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM mood WHERE user_id=$1 AND to_tsvector('simple', COALESCE(comment, '')) @@ to_tsquery('simple', $2)"+
+		" AND (date < $3 OR (date = $4 AND id < $5)) ORDER BY date DESC, id DESC LIMIT $6")).
+		WithArgs("user-1", "погано:* & спав:*", "2025-01-10", "2025-01-10", "m1", 11).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "date", "icon", "comment"}))
+
+	list, err := repos.Moods.Page(context.Background(), "user-1", repository.MoodQuery{Search: "Погано, спав!", Desc: true, After: after, Limit: 11})
+	if err != nil || len(list) != 0 {
+		t.Errorf("очікував порожню сторінку, отримав %v %v", list, err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestMoodRepo_GetNotFound(t *testing.T) {
+	repos, mock := setupStore(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM mood WHERE id=$1 AND user_id=$2")).
+		WithArgs("m1", "user-1").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repos.Moods.Get(context.Background(), "user-1", "m1")
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+}
+
+func TestMoodRepo_UpdateNotFound(t *testing.T) {
+	repos, mock := setupStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE mood SET date=$1, logged_at=$2, icon=$3, score=$4, comment=$5, updated_at=$6, version=version+1")).
+		WithArgs("2025-01-15", nil, "😃", 5, "ok", sqlmock.AnyArg(), "m1", "user-1", 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// жоден рядок не змінено: з'ясовуємо, чи запис зник, чи змінилась версія
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version FROM mood WHERE id=$1 AND user_id=$2")).
+		WithArgs("m1", "user-1").
+		WillReturnError(sql.ErrNoRows)
+
+	score := 5
+	date := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	err := repos.Moods.Update(context.Background(), &models.Mood{ID: "m1", UserID: "user-1", Date: date, Icon: "😃", Score: &score, Comment: "ok", Version: 2})
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував ErrNotFound, отримав %v", err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestMoodRepo_DeleteError(t *testing.T) {
+	repos, mock := setupStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM mood WHERE id=$1 AND user_id=$2")).
+		WithArgs("m1", "user-1").
+		WillReturnError(errors.New("delete fail"))
+
+	err := repos.Moods.Delete(context.Background(), "user-1", "m1", 0)
+	if err == nil || errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("очікував помилку БД, отримав %v", err)
+	}
+}
+
+func TestMoodRepo_DeleteStale(t *testing.T) {
+	repos, mock := setupStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM mood WHERE id=$1 AND user_id=$2 AND version=$3")).
+		WithArgs("m1", "user-1", 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version FROM mood WHERE id=$1 AND user_id=$2")).
+		WithArgs("m1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+
+	err := repos.Moods.Delete(context.Background(), "user-1", "m1", 1)
+	if !errors.Is(err, repository.ErrStale) {
+		t.Errorf("очікував ErrStale, отримав %v", err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestUserRepo_ListWithoutMood(t *testing.T) {
+	repos, mock := setupStore(t)
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id FROM mood WHERE date = $1")).
+		WithArgs("2025-03-10").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "telegram_chat_id", "created_at", "updated_at"}).
+			AddRow("user-1", "a@example.com", int64(42), now, now))
+
+	users, err := repos.Users.ListWithoutMood(context.Background(), time.Date(2025, 3, 10, 20, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].TelegramChatID == nil || *users[0].TelegramChatID != 42 {
+		t.Errorf("неправильні користувачі: %+v", users)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestJobRepo_ClaimSkipLocked(t *testing.T) {
+	repos, mock := setupStore(t)
+	now := time.Now()
+	mock.ExpectQuery(`ORDER BY run_at LIMIT \$8 FOR UPDATE SKIP LOCKED\s*\)\s*RETURNING \*`).
+		WithArgs(models.JobSending, sqlmock.AnyArg(), sqlmock.AnyArg(), models.JobPending, sqlmock.AnyArg(), models.JobSending, sqlmock.AnyArg(), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "idempotency_key", "status", "run_at"}).
+			AddRow("job-2", "k2", models.JobSending, now).
+			AddRow("job-1", "k1", models.JobSending, now.Add(-time.Minute)))
+
+	jobs, err := repos.Jobs.Claim(context.Background(), now, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "job-1" {
+		t.Errorf("очікував завдання в порядку run_at: %+v", jobs)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestJobRepo_ReleaseLostLease(t *testing.T) {
+	repos, mock := setupStore(t)
+	until := time.Date(2025, 3, 10, 12, 1, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id=$6 AND status=$7 AND locked_until=$8")).
+		WithArgs(models.JobSent, 1, sqlmock.AnyArg(), "", sqlmock.AnyArg(), "job-1", models.JobSending, until).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM outbox WHERE id=$1")).
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.JobSending))
+
+	j := &models.Job{ID: "job-1", Status: models.JobSent, Attempts: 1, LockedUntil: &until}
+	if err := repos.Jobs.Release(context.Background(), j); !errors.Is(err, repository.ErrStale) {
+		t.Errorf("очікував ErrStale, отримав %v", err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestLoginCodeRepo_CreateLimitedLocksEmail(t *testing.T) {
+	repos, mock := setupStore(t)
+	since := time.Date(2025, 3, 10, 11, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock(hashtext($1))")).
+		WithArgs("login_codes:a@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM login_codes WHERE email=$1 AND created_at > $2")).
+		WithArgs("a@example.com", since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectRollback()
+
+	c := &models.LoginCode{ID: "code-1", Email: "a@example.com", ExpiresAt: since.Add(time.Hour)}
+	created, err := repos.LoginCodes.CreateLimited(context.Background(), c, repository.LoginLimits{Since: since, Codes: 5})
+	if err != nil || created {
+		t.Errorf("ліміт вичерпано: очікував false, отримав %v, %v", created, err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestLoginCodeRepo_MarkUsedOnce(t *testing.T) {
+	repos, mock := setupStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE login_codes SET used_at=$1 WHERE id=$2 AND used_at IS NULL")).
+		WithArgs(sqlmock.AnyArg(), "code-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	used, err := repos.LoginCodes.MarkUsed(context.Background(), "code-1", time.Now())
+	if err != nil || used {
+		t.Errorf("вже використаний код: очікував false, отримав %v, %v", used, err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestSessionRepo_MarkRotated(t *testing.T) {
+	repos, mock := setupStore(t)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE sessions SET rotated_at=$1 WHERE id=$2 AND rotated_at IS NULL AND revoked_at IS NULL")).
+		WithArgs(sqlmock.AnyArg(), "s-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rotated, err := repos.Sessions.MarkRotated(context.Background(), "s-1", time.Now())
+	if err != nil || !rotated {
+		t.Errorf("очікував успішну ротацію, отримав %v, %v", rotated, err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestSessionRepo_IsActive(t *testing.T) {
+	repos, mock := setupStore(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM sessions WHERE family_id=$1 AND user_id=$2 AND revoked_at IS NULL")).
+		WithArgs("fam-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	active, err := repos.Sessions.IsActive(context.Background(), "fam-1", "user-1")
+	if err != nil || active {
+		t.Errorf("очікував неактивну сесію, отримав %v, %v", active, err)
+	}
+	checkExpectations(t, mock)
+}
+
+func TestIdentityRepo_ConsumeState(t *testing.T) {
+	repos, mock := setupStore(t)
+	exp := time.Now().Add(time.Minute)
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM oidc_states WHERE state=$1 AND expires_at > $2 RETURNING")).
+		WithArgs("st-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "nonce", "code_verifier", "expires_at"}).
+			AddRow("st-1", "n-1", "v-1", exp))
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM oidc_states")).
+		WithArgs("st-1", sqlmock.AnyArg()).
+		WillReturnError(sql.ErrNoRows)
+
+	ctx := context.Background()
+	st, err := repos.Identities.ConsumeState(ctx, "st-1", time.Now())
+	if err != nil || st.Nonce != "n-1" || st.CodeVerifier != "v-1" {
+		t.Fatalf("неправильний state: %+v, %v", st, err)
+	}
+	if _, err := repos.Identities.ConsumeState(ctx, "st-1", time.Now()); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("повторне використання: очікував ErrNotFound, отримав %v", err)
+	}
+	checkExpectations(t, mock)
+}
@@ -0,0 +1,44 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"moodtracker/repository"
+)
+
+type TelegramUpdateRepo struct {
+	db *sqlx.DB
+}
+
+func (r *TelegramUpdateRepo) Claim(ctx context.Context, updateID int64, now time.Time, lease time.Duration) (bool, error) {
+	// Повторна доставка перезаписує рядок, лише якщо оновлення не оброблене і оренда минула
+	ok, err := affected(r.db.ExecContext(ctx, r.db.Rebind(`
+        INSERT INTO telegram_updates (update_id, received_at) VALUES (?, ?)
+        ON CONFLICT (update_id) DO UPDATE SET received_at = excluded.received_at
+        WHERE telegram_updates.handled_at IS NULL AND telegram_updates.received_at < ?`),
+		updateID, ts(now), ts(now.Add(-lease))))
+	if err != nil || ok {
+		return ok, err
+	}
+	var handled sql.NullTime
+	if err := r.db.GetContext(ctx, &handled, r.db.Rebind(`SELECT handled_at FROM telegram_updates WHERE update_id = ?`), updateID); err != nil {
+		return false, err
+	}
+	if !handled.Valid {
+		return false, repository.ErrConflict
+	}
+	return false, nil
+}
+
+func (r *TelegramUpdateRepo) Done(ctx context.Context, updateID int64, at time.Time) error {
+	return existing(r.db.ExecContext(ctx, r.db.Rebind(`UPDATE telegram_updates SET handled_at = ? WHERE update_id = ?`), ts(at), updateID))
+}
+
+func (r *TelegramUpdateRepo) Prune(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`DELETE FROM telegram_updates WHERE received_at < ?`), ts(before))
+	return err
+}
@@ -0,0 +1,96 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"moodtracker/models"
+)
+
+type UserRepo struct {
+	db *sqlx.DB
+}
+
+const userColumns = `id, email, telegram_chat_id, entry_mode, timezone, locale, created_at, updated_at`
+
+func (r *UserRepo) Create(ctx context.Context, u *models.User) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`INSERT INTO users (id, email) VALUES (?, ?)`), u.ID, u.Email)
+	return err
+}
+
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var u models.User
+	err := r.db.GetContext(ctx, &u,
+		r.db.Rebind(`SELECT `+userColumns+` FROM users WHERE email=?`), email)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+	var u models.User
+	err := r.db.GetContext(ctx, &u,
+		r.db.Rebind(`SELECT `+userColumns+` FROM users WHERE id=?`), id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) GetByTelegramChatID(ctx context.Context, chatID int64) (*models.User, error) {
+	var u models.User
+	err := r.db.GetContext(ctx, &u,
+		r.db.Rebind(`SELECT `+userColumns+` FROM users WHERE telegram_chat_id=?`), chatID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) SetTelegramChatID(ctx context.Context, userID string, chatID int64) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`UPDATE users SET telegram_chat_id=? WHERE id=?`), chatID, userID)
+	return conflict(err)
+}
+
+func (r *UserRepo) UnlinkTelegram(ctx context.Context, userID string) error {
+	return existing(r.db.ExecContext(ctx,
+		r.db.Rebind(`UPDATE users SET telegram_chat_id=NULL WHERE id=? AND telegram_chat_id IS NOT NULL`), userID))
+}
+
+func (r *UserRepo) GetSettings(ctx context.Context, userID string) (*models.UserSettings, error) {
+	var s models.UserSettings
+	err := r.db.GetContext(ctx, &s, r.db.Rebind(`SELECT entry_mode, timezone, locale FROM users WHERE id=?`), userID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &s, nil
+}
+
+func (r *UserRepo) UpdateSettings(ctx context.Context, userID string, s models.UserSettings) error {
+	return existing(r.db.ExecContext(ctx,
+		r.db.Rebind(`UPDATE users SET entry_mode=?, timezone=?, locale=?, updated_at=? WHERE id=?`),
+		s.EntryMode, s.Timezone, s.Locale, ts(time.Now()), userID))
+}
+
+func (r *UserRepo) List(ctx context.Context) ([]models.User, error) {
+	users := []models.User{}
+	err := r.db.SelectContext(ctx, &users, `SELECT `+userColumns+` FROM users ORDER BY id`)
+	return users, err
+}
+
+func (r *UserRepo) ListWithoutMood(ctx context.Context, d time.Time) ([]models.User, error) {
+	// день передаємо з Go замість CURRENT_DATE: той самий запит працює в обох діалектах
+	const query = `
+        SELECT ` + userColumns + `
+        FROM users
+        WHERE id NOT IN (
+            SELECT user_id FROM mood WHERE date = ?
+          )
+        ORDER BY id`
+	users := []models.User{}
+	err := r.db.SelectContext(ctx, &users, r.db.Rebind(query), day(d))
+	return users, err
+}
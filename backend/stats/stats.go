@@ -0,0 +1,194 @@
+// Package stats агрегує записи настрою за період: кількість за іконками,
+// середній бал, серії днів поспіль, пропущені дні та групування за періодами.
+// Його використовують і /api/mood/stats, і щотижневий звіт у Telegram.
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"moodtracker/i18n"
+	"moodtracker/models"
+	"moodtracker/streaks"
+)
+
+const dateLayout = "2006-01-02"
+
+// GroupBy – одиниця групування записів
+type GroupBy string
+
+const (
+	ByDay     GroupBy = "day"
+	ByWeek    GroupBy = "week"  // ISO-тиждень, ключ "2025-W03"
+	ByMonth   GroupBy = "month" // ключ "2025-01"
+	ByWeekday GroupBy = "weekday"
+)
+
+// ParseGroupBy розбирає параметр group_by; порожнє значення – групування за днями
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch g := GroupBy(s); g {
+	case "":
+		return ByDay, nil
+	case ByDay, ByWeek, ByMonth, ByWeekday:
+		return g, nil
+	default:
+		return "", i18n.NewError("invalid_group_by")
+	}
+}
+
+// IconCount – скільки разів користувач обрав іконку
+type IconCount struct {
+	Icon  string `json:"icon"`
+	Count int    `json:"count"`
+}
+
+// Group – записи одного періоду
+type Group struct {
+	Key      string         `json:"key"`
+	Entries  int            `json:"entries"`
+	Counts   map[string]int `json:"counts"`
+	AvgScore *float64       `json:"avg_score"` // nil, якщо в періоді немає записів з балом
+}
+
+// Report – підсумок за період [From, To] включно
+type Report struct {
+	From          string      `json:"from"`
+	To            string      `json:"to"`
+	GroupBy       GroupBy     `json:"group_by"`
+	Entries       int         `json:"entries"`
+	Counts        []IconCount `json:"counts"` // від найчастішої іконки
+	AvgScore      *float64    `json:"avg_score"`
+	MostCommon    string      `json:"most_common,omitempty"`
+	DaysLogged    int         `json:"days_logged"`
+	MissingDays   []string    `json:"missing_days"`
+	CurrentStreak int         `json:"current_streak"` // днів поспіль із записом, що закінчуються в To (або напередодні)
+	LongestStreak int         `json:"longest_streak"`
+	Groups        []Group     `json:"groups"`
+}
+
+// average – накопичувач середнього балу
+type average struct {
+	sum, n int
+}
+
+func (a *average) add(score *int) {
+	if score != nil {
+		a.sum += *score
+		a.n++
+	}
+}
+
+// value повертає середнє з точністю до сотих або nil
+func (a average) value() *float64 {
+	if a.n == 0 {
+		return nil
+	}
+	v := math.Round(float64(a.sum)/float64(a.n)*100) / 100
+	return &v
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// groupKey – ключ періоду, до якого належить день d
+func groupKey(g GroupBy, d time.Time) string {
+	switch g {
+	case ByWeek:
+		y, w := d.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	case ByMonth:
+		return d.Format("2006-01")
+	case ByWeekday:
+		return strings.ToLower(d.Weekday().String())
+	default:
+		return d.Format(dateLayout)
+	}
+}
+
+// Compute рахує звіт за записами moods у межах [from, to]; записи поза межами ігноруються
+func Compute(moods []models.Mood, from, to time.Time, g GroupBy) Report {
+	from, to = dateOnly(from), dateOnly(to)
+	r := Report{
+		From:        from.Format(dateLayout),
+		To:          to.Format(dateLayout),
+		GroupBy:     g,
+		Counts:      []IconCount{},
+		MissingDays: []string{},
+		Groups:      []Group{},
+	}
+
+	// Порожні групи для всього діапазону, щоб графік не мав "дірок"
+	index := map[string]int{}
+	addGroup := func(key string) {
+		if _, ok := index[key]; !ok {
+			index[key] = len(r.Groups)
+			r.Groups = append(r.Groups, Group{Key: key, Counts: map[string]int{}})
+		}
+	}
+	if g == ByWeekday {
+		for _, wd := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+			addGroup(strings.ToLower(wd.String()))
+		}
+	} else {
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			addGroup(groupKey(g, d))
+		}
+	}
+	groupAvg := make([]average, len(r.Groups))
+
+	counts := map[string]int{}
+	logged := map[string]bool{}
+	var total average
+	for _, m := range moods {
+		d := dateOnly(m.Date)
+		if d.Before(from) || d.After(to) {
+			continue
+		}
+		r.Entries++
+		counts[m.Icon]++
+		logged[d.Format(dateLayout)] = true
+		total.add(m.Score)
+
+		i := index[groupKey(g, d)]
+		r.Groups[i].Entries++
+		r.Groups[i].Counts[m.Icon]++
+		groupAvg[i].add(m.Score)
+	}
+	for i := range r.Groups {
+		r.Groups[i].AvgScore = groupAvg[i].value()
+	}
+	r.AvgScore = total.value()
+
+	for icon, n := range counts {
+		r.Counts = append(r.Counts, IconCount{Icon: icon, Count: n})
+	}
+	sort.Slice(r.Counts, func(i, j int) bool {
+		if r.Counts[i].Count != r.Counts[j].Count {
+			return r.Counts[i].Count > r.Counts[j].Count
+		}
+		return r.Counts[i].Icon < r.Counts[j].Icon
+	})
+	if len(r.Counts) > 0 {
+		r.MostCommon = r.Counts[0].Icon
+	}
+
+	var days []time.Time
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if logged[d.Format(dateLayout)] {
+			r.DaysLogged++
+			days = append(days, d)
+		} else {
+			r.MissingDays = append(r.MissingDays, d.Format(dateLayout))
+		}
+	}
+	// Серії в межах періоду рахує той самий рушій, що й /mood/streaks
+	st := streaks.Compute(days, nil, to)
+	r.CurrentStreak, r.LongestStreak = st.Current, st.Longest
+
+	return r
+}
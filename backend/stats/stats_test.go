@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"moodtracker/models"
+)
+
+func day(s string) time.Time {
+	d, _ := time.Parse(dateLayout, s)
+	return d
+}
+
+func mood(date, icon string, score int) models.Mood {
+	return models.Mood{Date: day(date), Icon: icon, Score: &score}
+}
+
+func TestCompute_Totals(t *testing.T) {
+	moods := []models.Mood{
+		mood("2025-01-01", "😃", 5),
+		mood("2025-01-02", "😞", 2),
+		mood("2025-01-02", "😃", 5), // другий запис того ж дня
+		mood("2025-01-04", "😐", 3),
+		mood("2025-01-05", "😃", 5),
+		mood("2025-02-01", "😡", 0),           // поза періодом
+		{Date: day("2025-01-05"), Icon: "🙂"}, // старий запис без балу
+	}
+
+	r := Compute(moods, day("2025-01-01"), day("2025-01-07"), ByDay)
+	if r.Entries != 6 || r.DaysLogged != 4 {
+		t.Errorf("очікував 6 записів за 4 дні, отримав %d за %d", r.Entries, r.DaysLogged)
+	}
+	if r.AvgScore == nil || *r.AvgScore != 4 {
+		t.Errorf("очікував середній бал 4, отримав %v", r.AvgScore)
+	}
+	if r.MostCommon != "😃" || r.Counts[0] != (IconCount{Icon: "😃", Count: 3}) {
+		t.Errorf("неправильні лічильники: %+v", r.Counts)
+	}
+	want := []string{"2025-01-03", "2025-01-06", "2025-01-07"}
+	if len(r.MissingDays) != len(want) {
+		t.Fatalf("очікував пропущені дні %v, отримав %v", want, r.MissingDays)
+	}
+	for i := range want {
+		if r.MissingDays[i] != want[i] {
+			t.Errorf("очікував пропущені дні %v, отримав %v", want, r.MissingDays)
+		}
+	}
+	if r.LongestStreak != 2 || r.CurrentStreak != 0 {
+		t.Errorf("серії: очікував 2 і 0, отримав %d і %d", r.LongestStreak, r.CurrentStreak)
+	}
+	if len(r.Groups) != 7 || r.Groups[1].Key != "2025-01-02" || r.Groups[1].Entries != 2 || *r.Groups[1].AvgScore != 3.5 {
+		t.Errorf("неправильна група дня: %+v", r.Groups)
+	}
+	if r.Groups[2].AvgScore != nil {
+		t.Errorf("день без записів не має середнього: %+v", r.Groups[2])
+	}
+}
+
+func TestCompute_CurrentStreak(t *testing.T) {
+	moods := []models.Mood{
+		mood("2025-01-03", "😃", 5),
+		mood("2025-01-04", "😃", 5),
+		mood("2025-01-05", "😃", 5),
+	}
+	// останній день ще без запису – серія не обривається
+	if r := Compute(moods, day("2025-01-01"), day("2025-01-06"), ByDay); r.CurrentStreak != 3 {
+		t.Errorf("очікував серію 3, отримав %d", r.CurrentStreak)
+	}
+	if r := Compute(moods, day("2025-01-01"), day("2025-01-05"), ByDay); r.CurrentStreak != 3 || r.LongestStreak != 3 {
+		t.Errorf("очікував серії 3 і 3, отримав %d і %d", r.CurrentStreak, r.LongestStreak)
+	}
+	if r := Compute(moods, day("2025-01-01"), day("2025-01-07"), ByDay); r.CurrentStreak != 0 {
+		t.Errorf("два порожні дні обривають серію, отримав %d", r.CurrentStreak)
+	}
+}
+
+func TestCompute_GroupBy(t *testing.T) {
+	moods := []models.Mood{
+		mood("2024-12-30", "😃", 5), // понеділок, ISO-тиждень 2025-W01
+		mood("2025-01-05", "😞", 2), // неділя, той самий тиждень
+		mood("2025-01-06", "😐", 3), // понеділок, 2025-W02
+	}
+	from, to := day("2024-12-30"), day("2025-01-06")
+
+	tests := []struct {
+		groupBy GroupBy
+		keys    []string
+		entries []int
+	}{
+		{ByWeek, []string{"2025-W01", "2025-W02"}, []int{2, 1}},
+		{ByMonth, []string{"2024-12", "2025-01"}, []int{1, 2}},
+		{ByWeekday, []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}, []int{2, 0, 0, 0, 0, 0, 1}},
+	}
+	for _, tt := range tests {
+		r := Compute(moods, from, to, tt.groupBy)
+		if len(r.Groups) != len(tt.keys) {
+			t.Errorf("%s: очікував групи %v, отримав %+v", tt.groupBy, tt.keys, r.Groups)
+			continue
+		}
+		for i, g := range r.Groups {
+			if g.Key != tt.keys[i] || g.Entries != tt.entries[i] {
+				t.Errorf("%s: група %d: очікував %s/%d, отримав %s/%d", tt.groupBy, i, tt.keys[i], tt.entries[i], g.Key, g.Entries)
+			}
+		}
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	if g, err := ParseGroupBy(""); err != nil || g != ByDay {
+		t.Errorf("порожнє значення: очікував day, отримав %q, %v", g, err)
+	}
+	if _, err := ParseGroupBy("year"); err == nil {
+		t.Error("очікував помилку для year")
+	}
+}
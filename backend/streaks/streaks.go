@@ -0,0 +1,130 @@
+// Package streaks рахує серії днів поспіль із записом настрою.
+// День зберігає серію, якщо в ньому є запис або його "заморожено"; заморожені
+// дні серію не обривають, але й не додають до неї.
+package streaks
+
+import (
+	"context"
+	"time"
+
+	"moodtracker/repository"
+)
+
+// Milestones – довжини серій, з якими бот вітає користувача
+var Milestones = []int{3, 7, 14, 30, 50, 100, 200, 365}
+
+// Streaks – серії користувача на день Today
+type Streaks struct {
+	Current       int        `json:"current"`
+	Longest       int        `json:"longest"`
+	TodayLogged   bool       `json:"today_logged"`
+	LastLogged    *time.Time `json:"last_logged,omitempty"`
+	NextMilestone int        `json:"next_milestone,omitempty"` // 0, якщо всі віхи вже пройдено
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Compute рахує серії за днями із записами logged і замороженими днями frozen.
+// today – поточна дата в часовому поясі користувача. Сьогоднішній запис ще може
+// з'явитися, тож порожнє "сьогодні" поточну серію не обриває.
+func Compute(logged, frozen []time.Time, today time.Time) Streaks {
+	today = dateOnly(today)
+	var s Streaks
+	if len(logged) == 0 {
+		s.NextMilestone = NextMilestone(0)
+		return s
+	}
+
+	isLogged := map[time.Time]bool{}
+	start := today
+	for _, d := range logged {
+		d = dateOnly(d)
+		if d.After(today) {
+			continue
+		}
+		isLogged[d] = true
+		if d.Before(start) {
+			start = d
+		}
+		if s.LastLogged == nil || d.After(*s.LastLogged) {
+			last := d
+			s.LastLogged = &last
+		}
+	}
+	isFrozen := map[time.Time]bool{}
+	for _, d := range frozen {
+		isFrozen[dateOnly(d)] = true
+	}
+	s.TodayLogged = isLogged[today]
+
+	// Найдовша серія: проходимо всю історію від першого запису
+	run := 0
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		switch {
+		case isLogged[d]:
+			run++
+			s.Longest = max(s.Longest, run)
+		case isFrozen[d], d.Equal(today):
+		default:
+			run = 0
+		}
+	}
+
+	// Поточна серія: від сьогодні (або вчора) назад до першого пропуску
+	for d := today; !d.Before(start); d = d.AddDate(0, 0, -1) {
+		if isLogged[d] {
+			s.Current++
+		} else if !isFrozen[d] && !d.Equal(today) {
+			break
+		}
+	}
+
+	s.NextMilestone = NextMilestone(s.Current)
+	return s
+}
+
+// NextMilestone повертає найближчу віху, більшу за current, або 0
+func NextMilestone(current int) int {
+	for _, m := range Milestones {
+		if m > current {
+			return m
+		}
+	}
+	return 0
+}
+
+// IsMilestone повідомляє, чи є n віхою
+func IsMilestone(n int) bool {
+	for _, m := range Milestones {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+// Service рахує серії за даними репозиторіїв
+type Service struct {
+	Moods   repository.MoodRepository
+	Freezes repository.FreezeRepository
+}
+
+func NewService(moods repository.MoodRepository, freezes repository.FreezeRepository) *Service {
+	return &Service{Moods: moods, Freezes: freezes}
+}
+
+// Get рахує серії користувача на дату today (у його часовому поясі)
+func (s *Service) Get(ctx context.Context, userID string, today time.Time) (Streaks, error) {
+	logged, err := s.Moods.LoggedDays(ctx, userID)
+	if err != nil {
+		return Streaks{}, err
+	}
+	frozen, err := s.Freezes.List(ctx, userID)
+	if err != nil {
+		return Streaks{}, err
+	}
+	return Compute(logged, frozen, today), nil
+}
@@ -0,0 +1,69 @@
+package streaks
+
+import (
+	"testing"
+	"time"
+)
+
+func days(ss ...string) []time.Time {
+	out := make([]time.Time, 0, len(ss))
+	for _, s := range ss {
+		d, _ := time.Parse("2006-01-02", s)
+		out = append(out, d)
+	}
+	return out
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name             string
+		logged, frozen   []time.Time
+		today            string
+		current, longest int
+	}{
+		{"без записів", nil, nil, "2025-01-10", 0, 0},
+		{"серія до сьогодні", days("2025-01-08", "2025-01-09", "2025-01-10"), nil, "2025-01-10", 3, 3},
+		{"сьогодні ще без запису", days("2025-01-08", "2025-01-09"), nil, "2025-01-10", 2, 2},
+		{"пропуск учора обриває серію", days("2025-01-07", "2025-01-08"), nil, "2025-01-10", 0, 2},
+		{"найдовша в минулому",
+			days("2025-01-01", "2025-01-02", "2025-01-03", "2025-01-04", "2025-01-08", "2025-01-09"), nil, "2025-01-09", 2, 4},
+		{"заморожений день тримає серію",
+			days("2025-01-06", "2025-01-07", "2025-01-09", "2025-01-10"), days("2025-01-08"), "2025-01-10", 4, 4},
+		{"заморожене сьогодні й учора",
+			days("2025-01-07", "2025-01-08"), days("2025-01-09", "2025-01-10"), "2025-01-10", 2, 2},
+		{"записи в майбутньому не враховуються", days("2025-01-10", "2025-01-11"), nil, "2025-01-10", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			today, _ := time.Parse("2006-01-02", tt.today)
+			s := Compute(tt.logged, tt.frozen, today)
+			if s.Current != tt.current || s.Longest != tt.longest {
+				t.Errorf("очікував %d/%d, отримав %d/%d", tt.current, tt.longest, s.Current, s.Longest)
+			}
+		})
+	}
+}
+
+func TestCompute_Details(t *testing.T) {
+	today, _ := time.Parse("2006-01-02", "2025-01-10")
+
+	s := Compute(days("2025-01-04", "2025-01-05", "2025-01-06", "2025-01-07", "2025-01-08", "2025-01-09", "2025-01-10"), nil, today)
+	if !s.TodayLogged || s.LastLogged == nil || !s.LastLogged.Equal(today) {
+		t.Errorf("неправильний останній запис: %+v", s)
+	}
+	if s.Current != 7 || s.NextMilestone != 14 {
+		t.Errorf("очікував серію 7 і наступну віху 14, отримав %+v", s)
+	}
+	if s := Compute(nil, nil, today); s.TodayLogged || s.LastLogged != nil || s.NextMilestone != 3 {
+		t.Errorf("порожня історія: %+v", s)
+	}
+}
+
+func TestMilestones(t *testing.T) {
+	if !IsMilestone(7) || IsMilestone(8) {
+		t.Error("неправильна перевірка віхи")
+	}
+	if NextMilestone(365) != 0 || NextMilestone(0) != 3 {
+		t.Error("неправильна наступна віха")
+	}
+}
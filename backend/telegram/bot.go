@@ -0,0 +1,451 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"moodtracker/entries"
+	"moodtracker/i18n"
+	"moodtracker/leader"
+	"moodtracker/models"
+	"moodtracker/reminders"
+	"moodtracker/repository"
+	"moodtracker/stats"
+	"moodtracker/streaks"
+	"moodtracker/validate"
+)
+
+// Префікс callback-даних кнопки з іконкою; Telegram обмежує дані 64 байтами
+const moodCallback = "mood:"
+
+// Telegram зберігає непідтверджені оновлення не довше доби, старіші позначки update_id не потрібні
+const updateTTL = 24 * time.Hour
+
+// updateLease – скільки оновлення вважається взятим в обробку: якщо процес упав до
+// позначки Done, повторну доставку після оренди обробить наступний
+const updateLease = time.Minute
+
+// botCommands – меню команд бота; описи – "bot.command.<команда>" у каталозі i18n
+var botCommands = []string{"mood", "today", "week", "stats", "undo", "stop"}
+
+// commandMenu – меню команд мовою l
+func commandMenu(l i18n.Locale) []tgbotapi.BotCommand {
+	menu := make([]tgbotapi.BotCommand, len(botCommands))
+	for i, cmd := range botCommands {
+		menu[i] = tgbotapi.BotCommand{Command: cmd, Description: i18n.T(l, "bot.command."+cmd)}
+	}
+	return menu
+}
+
+// setCommands реєструє меню типовою мовою і окремо для кожної мови каталогу:
+// Telegram показує його за мовою інтерфейсу користувача
+func setCommands(bot sender) {
+	configs := []tgbotapi.SetMyCommandsConfig{tgbotapi.NewSetMyCommands(commandMenu(i18n.Default)...)}
+	for _, l := range i18n.Locales {
+		configs = append(configs, tgbotapi.NewSetMyCommandsWithScopeAndLanguage(
+			tgbotapi.NewBotCommandScopeDefault(), string(l), commandMenu(l)...))
+	}
+	for _, cfg := range configs {
+		if _, err := bot.Request(cfg); err != nil {
+			log.Printf("failed to set bot commands: %v", err)
+		}
+	}
+}
+
+// senderLocale – мова інтерфейсу Telegram відправника; для ще не прив'язаних чатів
+func senderLocale(from *tgbotapi.User) i18n.Locale {
+	if from == nil {
+		return i18n.Default
+	}
+	return i18n.Match(from.LanguageCode)
+}
+
+// promptIcon дістає іконку з першого рядка запиту коментаря будь-якою мовою.
+// Іконка зберігається в самому тексті, тож бот не тримає стану між оновленнями.
+func promptIcon(line string) (string, bool) {
+	for _, l := range i18n.Locales {
+		if icon, ok := strings.CutPrefix(line, i18n.T(l, "bot.prompt.prefix")); ok {
+			return icon, true
+		}
+	}
+	return "", false
+}
+
+// commands відповідає на повідомлення, які користувачі пишуть боту
+type commands struct {
+	bot     sender
+	users   repository.UserRepository
+	links   repository.TelegramLinkRepository
+	updates repository.TelegramUpdateRepository
+	moods   repository.MoodRepository
+	icons   repository.IconRepository
+	entries *entries.Service
+	streaks *streaks.Service
+}
+
+func newCommands(bot sender, repos repository.Set) *commands {
+	return &commands{
+		bot:     bot,
+		users:   repos.Users,
+		links:   repos.TelegramLinks,
+		updates: repos.TelegramUpdates,
+		moods:   repos.Moods,
+		icons:   repos.Icons,
+		entries: &entries.Service{Moods: repos.Moods, Users: repos.Users, Icons: repos.Icons},
+		streaks: streaks.NewService(repos.Moods, repos.Freezes),
+	}
+}
+
+// process обробляє оновлення щонайменше раз: update_id позначається обробленим лише
+// після handle, тож повторна доставка (повтор webhook, зміна лідера до підтвердження
+// offset) пропускається, тільки якщо попередня обробка завершилась. Оновлення, яке ще
+// обробляє інший процес, повертає помилку – Telegram доставить його знову
+func (c *commands) process(ctx context.Context, upd tgbotapi.Update) error {
+	id := int64(upd.UpdateID)
+	fresh, err := c.updates.Claim(ctx, id, time.Now(), updateLease)
+	if err != nil || !fresh {
+		return err
+	}
+	c.handle(ctx, upd)
+	return c.updates.Done(ctx, id, time.Now())
+}
+
+// pruneLoop щогодини видаляє позначки оновлень, які Telegram уже не доставить повторно
+func (c *commands) pruneLoop(ctx context.Context, elector *leader.Elector) {
+	t := time.NewTicker(time.Hour)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if !elector.IsLeader() {
+				continue
+			}
+			if err := c.updates.Prune(ctx, now.Add(-updateTTL)); err != nil {
+				log.Printf("telegram updates prune err: %v", err)
+			}
+		}
+	}
+}
+
+// handle обробляє одне оновлення Telegram
+func (c *commands) handle(ctx context.Context, upd tgbotapi.Update) {
+	switch msg := upd.Message; {
+	case upd.CallbackQuery != nil:
+		c.callback(ctx, upd.CallbackQuery)
+	case msg == nil:
+	case msg.IsCommand():
+		c.command(ctx, msg)
+	case msg.ReplyToMessage != nil:
+		c.comment(ctx, msg)
+	}
+}
+
+func (c *commands) command(ctx context.Context, msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	if msg.Command() == "start" && msg.CommandArguments() != "" {
+		c.reply(chatID, c.start(ctx, msg))
+		return
+	}
+	u, ok := c.linked(ctx, chatID, msg.From)
+	if !ok {
+		return
+	}
+
+	switch msg.Command() {
+	case "mood":
+		c.mood(ctx, u, chatID, msg.CommandArguments())
+	case "today":
+		c.reply(chatID, c.today(ctx, u))
+	case "week":
+		today := u.Today(time.Now())
+		c.reply(chatID, c.report(ctx, u, i18n.T(u.Locale, "bot.week.title"), today.AddDate(0, 0, -6), today))
+	case "stats":
+		today := u.Today(time.Now())
+		c.reply(chatID, c.report(ctx, u, i18n.T(u.Locale, "bot.stats.title"), today.AddDate(0, 0, -29), today))
+	case "undo":
+		c.reply(chatID, c.undo(ctx, u))
+	case "stop":
+		c.reply(chatID, c.stop(ctx, u))
+	default:
+		c.reply(chatID, helpText(u.Locale))
+	}
+}
+
+// start прив'язує чат за токеном із посилання t.me/<bot>?start=<token>.
+// chat_id береться з самого оновлення, тож прив'язати чужий чат неможливо.
+// До прив'язки бот відповідає мовою Telegram, після – мовою користувача застосунку.
+func (c *commands) start(ctx context.Context, msg *tgbotapi.Message) string {
+	lang := senderLocale(msg.From)
+	if !msg.Chat.IsPrivate() {
+		return i18n.T(lang, "bot.start.private")
+	}
+
+	// Токен витрачається лише разом з успішною прив'язкою: якщо чат зайнятий або
+	// сталася тимчасова помилка, те саме посилання можна використати ще раз
+	link, err := c.links.Redeem(ctx, msg.CommandArguments(), msg.Chat.ID, time.Now())
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return i18n.T(lang, "bot.start.invalid")
+	case errors.Is(err, repository.ErrConflict):
+		return i18n.T(lang, "bot.start.taken")
+	case err != nil:
+		log.Printf("telegram link chat %d err: %v", msg.Chat.ID, err)
+		return i18n.T(lang, "bot.start.failed")
+	}
+	if u, err := c.users.GetByID(ctx, link.UserID); err == nil {
+		lang = u.Locale
+	}
+	return i18n.T(lang, "bot.start.done") + "\n\n" + helpText(lang)
+}
+
+// linked повертає користувача, до якого прив'язано чат; інакше відповідає підказкою мовою from
+func (c *commands) linked(ctx context.Context, chatID int64, from *tgbotapi.User) (*models.User, bool) {
+	u, err := c.users.GetByTelegramChatID(ctx, chatID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.reply(chatID, i18n.T(senderLocale(from), "bot.not_linked"))
+		return nil, false
+	} else if err != nil {
+		log.Printf("telegram chat %d lookup err: %v", chatID, err)
+		c.reply(chatID, i18n.T(senderLocale(from), "bot.error"))
+		return nil, false
+	}
+	return u, true
+}
+
+// mood: без аргументів – клавіатура з іконками; "/mood 😊 коментар" – одразу запис
+func (c *commands) mood(ctx context.Context, u *models.User, chatID int64, args string) {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		c.keyboard(ctx, u, chatID)
+		return
+	}
+	icon, comment, _ := strings.Cut(args, " ")
+	if comment = strings.TrimSpace(comment); comment == "" {
+		c.prompt(chatID, u.Locale, icon)
+		return
+	}
+	c.reply(chatID, c.create(ctx, u, icon, comment))
+}
+
+// keyboard надсилає іконки каталогу і власні іконки користувача кнопками
+func (c *commands) keyboard(ctx context.Context, u *models.User, chatID int64) {
+	custom, err := c.icons.List(ctx, u.ID)
+	if err != nil {
+		log.Printf("telegram icons err for %s: %v", u.ID, err)
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, mi := range append(models.LocalCatalog(u.Locale), custom...) {
+		data := moodCallback + mi.Icon
+		if len(data) > 64 {
+			continue // задовга для callback-даних; таку іконку можна вказати в "/mood <іконка> <коментар>"
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(mi.Icon+" "+mi.Label, data))
+		if len(row) == 3 {
+			rows, row = append(rows, row), nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, i18n.T(u.Locale, "bot.keyboard"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := c.bot.Send(msg); err != nil {
+		log.Printf("failed to send keyboard to %d: %v", chatID, err)
+	}
+}
+
+// callback обробляє натискання кнопки з іконкою
+func (c *commands) callback(ctx context.Context, q *tgbotapi.CallbackQuery) {
+	// відповідь на callback прибирає індикатор очікування на кнопці
+	if _, err := c.bot.Request(tgbotapi.NewCallback(q.ID, "")); err != nil {
+		log.Printf("failed to answer callback: %v", err)
+	}
+	icon, ok := strings.CutPrefix(q.Data, moodCallback)
+	if !ok || q.Message == nil {
+		return
+	}
+	chatID := q.Message.Chat.ID
+	u, ok := c.linked(ctx, chatID, q.From)
+	if !ok {
+		return
+	}
+	c.prompt(chatID, u.Locale, icon)
+}
+
+// prompt просить коментар відповіддю на повідомлення з обраною іконкою
+func (c *commands) prompt(chatID int64, l i18n.Locale, icon string) {
+	msg := tgbotapi.NewMessage(chatID, i18n.T(l, "bot.prompt.prefix")+icon+"\n"+i18n.T(l, "bot.prompt"))
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, InputFieldPlaceholder: i18n.T(l, "bot.prompt.placeholder")}
+	if _, err := c.bot.Send(msg); err != nil {
+		log.Printf("failed to send prompt to %d: %v", chatID, err)
+	}
+}
+
+// comment – відповідь на prompt: створює запис з іконкою з prompt і текстом відповіді
+func (c *commands) comment(ctx context.Context, msg *tgbotapi.Message) {
+	first, _, _ := strings.Cut(msg.ReplyToMessage.Text, "\n")
+	icon, ok := promptIcon(first)
+	if !ok {
+		return
+	}
+	u, ok := c.linked(ctx, msg.Chat.ID, msg.From)
+	if !ok {
+		return
+	}
+	c.reply(msg.Chat.ID, c.create(ctx, u, icon, strings.TrimSpace(msg.Text)))
+}
+
+// create вносить настрій через entries.Service – з тими самими перевірками, що й POST /api/mood
+func (c *commands) create(ctx context.Context, u *models.User, icon, comment string) string {
+	l := u.Locale
+	m, err := c.entries.Create(ctx, u.ID, entries.Input{Icon: icon, Comment: comment})
+	var invalid validate.Errors
+	switch {
+	case errors.Is(err, entries.ErrExists):
+		return i18n.T(l, "bot.create.exists")
+	case errors.Is(err, entries.ErrUnknownIcon):
+		return i18n.T(l, "bot.create.unknown_icon")
+	case errors.Is(err, entries.ErrCommentRequired):
+		return i18n.T(l, "bot.create.no_comment")
+	case errors.As(err, &invalid):
+		return i18n.T(l, "bot.create.invalid", invalid[0].Err.Localize(l))
+	case err != nil:
+		log.Printf("telegram create mood err for %s: %v", u.ID, err)
+		return i18n.T(l, "bot.create.failed")
+	}
+
+	text := i18n.T(l, "bot.created", m.Icon, m.Comment)
+	if st, err := c.streaks.Get(ctx, u.ID, m.Date); err == nil && st.Current > 1 {
+		text += "\n" + i18n.N(l, "bot.streak", st.Current)
+		if streaks.IsMilestone(st.Current) {
+			text += " 🎉"
+		}
+	}
+	return text
+}
+
+// todayMoods – записи користувача за його сьогоднішній день
+func (c *commands) todayMoods(ctx context.Context, u *models.User) ([]models.Mood, error) {
+	today := u.Today(time.Now())
+	return c.moods.List(ctx, u.ID, repository.MoodFilter{From: &today, To: &today})
+}
+
+func (c *commands) today(ctx context.Context, u *models.User) string {
+	list, err := c.todayMoods(ctx, u)
+	if err != nil {
+		log.Printf("telegram today err for %s: %v", u.ID, err)
+		return i18n.T(u.Locale, "bot.today.failed")
+	}
+	if len(list) == 0 {
+		return i18n.T(u.Locale, "bot.today.empty")
+	}
+	text := i18n.T(u.Locale, "bot.today.title")
+	for _, m := range list {
+		at := m.CreatedAt
+		if m.LoggedAt != nil {
+			at = *m.LoggedAt
+		}
+		text += fmt.Sprintf("\n%s %s — %s", at.In(u.Location()).Format("15:04"), m.Icon, m.Comment)
+	}
+	return text
+}
+
+// report – статистика і серії за from..to
+func (c *commands) report(ctx context.Context, u *models.User, title string, from, to time.Time) string {
+	list, err := c.moods.List(ctx, u.ID, repository.MoodFilter{From: &from, To: &to})
+	if err != nil {
+		log.Printf("telegram stats err for %s: %v", u.ID, err)
+		return i18n.T(u.Locale, "bot.stats.failed")
+	}
+	st, err := c.streaks.Get(ctx, u.ID, to)
+	if err != nil {
+		log.Printf("telegram streaks err for %s: %v", u.ID, err)
+		return i18n.T(u.Locale, "bot.stats.failed")
+	}
+	return reminders.ReportText(u.Locale, title, stats.Compute(list, from, to, stats.ByDay), st)
+}
+
+// undo видаляє останній сьогоднішній запис
+func (c *commands) undo(ctx context.Context, u *models.User) string {
+	list, err := c.todayMoods(ctx, u)
+	if err != nil {
+		log.Printf("telegram undo err for %s: %v", u.ID, err)
+		return i18n.T(u.Locale, "bot.undo.failed")
+	}
+	if len(list) == 0 {
+		return i18n.T(u.Locale, "bot.undo.empty")
+	}
+	last := list[0]
+	for _, m := range list[1:] {
+		if m.CreatedAt.After(last.CreatedAt) {
+			last = m
+		}
+	}
+	if err := c.moods.Delete(ctx, u.ID, last.ID, last.Version); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Printf("telegram undo delete err for %s: %v", u.ID, err)
+		return i18n.T(u.Locale, "bot.undo.failed")
+	}
+	return i18n.T(u.Locale, "bot.undo.done", last.Icon, last.Comment)
+}
+
+// stop від'єднує чат: нагадування і звіти більше не надходять
+func (c *commands) stop(ctx context.Context, u *models.User) string {
+	if err := c.users.UnlinkTelegram(ctx, u.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Printf("telegram unlink err for %s: %v", u.ID, err)
+		return i18n.T(u.Locale, "bot.stop.failed")
+	}
+	return i18n.T(u.Locale, "bot.stop.done")
+}
+
+func helpText(l i18n.Locale) string {
+	text := i18n.T(l, "bot.help")
+	for _, cmd := range commandMenu(l) {
+		text += fmt.Sprintf("\n/%s — %s", cmd.Command, cmd.Description)
+	}
+	return text
+}
+
+// reply відповідає одразу, без черги: користувач чекає на відповідь зараз
+func (c *commands) reply(chatID int64, text string) {
+	if _, err := c.bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		log.Printf("failed to reply to %d: %v", chatID, err)
+	}
+}
+
+// poll отримує оновлення через long polling. Telegram віддає getUpdates лише одному
+// клієнту, тож опитує тільки лідер.
+func poll(ctx context.Context, api *tgbotapi.BotAPI, elector *leader.Elector, c *commands) {
+	cfg := tgbotapi.NewUpdate(0)
+	cfg.Timeout = 30
+	for ctx.Err() == nil {
+		if !elector.IsLeader() {
+			time.Sleep(time.Second)
+			continue
+		}
+		updates, err := api.GetUpdates(cfg)
+		if err != nil {
+			log.Printf("telegram getUpdates err: %v", err)
+			time.Sleep(3 * time.Second)
+			continue
+		}
+		for _, upd := range updates {
+			if err := c.process(ctx, upd); err != nil {
+				// offset не зсуваємо: оновлення прийде знову з наступним getUpdates
+				log.Printf("telegram update %d err: %v", upd.UpdateID, err)
+				time.Sleep(3 * time.Second)
+				break
+			}
+			cfg.Offset = upd.UpdateID + 1
+		}
+	}
+}
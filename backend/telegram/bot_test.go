@@ -0,0 +1,342 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"moodtracker/i18n"
+	"moodtracker/leader"
+	"moodtracker/models"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
+)
+
+// apiCall – один запит бота до Telegram API
+type apiCall struct {
+	method string
+	form   url.Values
+}
+
+// fakeAPI імітує Telegram Bot API: записує виклики і віддає getUpdates з черги
+type fakeAPI struct {
+	mu      sync.Mutex
+	calls   []apiCall
+	updates []tgbotapi.Update
+	nextID  int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	method := path.Base(r.URL.Path)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, apiCall{method: method, form: r.PostForm})
+
+	var result any = true
+	switch method {
+	case "getMe":
+		result = tgbotapi.User{ID: 1, IsBot: true, UserName: "mood_bot"}
+	case "sendMessage":
+		f.nextID++
+		var chatID int64
+		fmt.Sscan(r.PostForm.Get("chat_id"), &chatID)
+		result = tgbotapi.Message{MessageID: f.nextID, Chat: &tgbotapi.Chat{ID: chatID}, Text: r.PostForm.Get("text")}
+	case "getUpdates":
+		if len(f.updates) == 0 {
+			// замість long polling коротка пауза, щоб poll не крутився вхолосту
+			f.mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			f.mu.Lock()
+		}
+		result, f.updates = f.updates, []tgbotapi.Update{}
+	}
+	raw, _ := json.Marshal(result)
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+// take повертає виклики method і очищує журнал
+func (f *fakeAPI) take(method string) []url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []url.Values
+	for _, c := range f.calls {
+		if c.method == method {
+			out = append(out, c.form)
+		}
+	}
+	f.calls = nil
+	return out
+}
+
+// called перевіряє, чи був виклик method з параметром key=value
+func (f *fakeAPI) called(method, key, value string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.calls {
+		if c.method == method && c.form.Get(key) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// texts – тексти повідомлень, надісланих у чат після попереднього виклику
+func (f *fakeAPI) texts() []string {
+	var out []string
+	for _, form := range f.take("sendMessage") {
+		out = append(out, form.Get("text"))
+	}
+	return out
+}
+
+func setupAPI(t *testing.T) (*fakeAPI, *tgbotapi.BotAPI) {
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("test-token", srv.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.take("getMe")
+	return api, bot
+}
+
+// setupLinked: користувач user-1 з прив'язаним чатом 555 і бот на фейковому API
+func setupLinked(t *testing.T) (*commands, repository.Set, *fakeAPI) {
+	api, bot := setupAPI(t)
+	repos := memory.New()
+	ctx := context.Background()
+	if err := repos.Users.Create(ctx, &models.User{ID: "user-1", Email: "user-1@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Users.SetTelegramChatID(ctx, "user-1", 555); err != nil {
+		t.Fatal(err)
+	}
+	return newCommands(bot, repos), repos, api
+}
+
+func command(chatID int64, text string) tgbotapi.Update {
+	cmd, _, _ := strings.Cut(text, " ")
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:     text,
+		Chat:     &tgbotapi.Chat{ID: chatID, Type: "private"},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(cmd)}},
+	}}
+}
+
+func TestCommands_MoodKeyboardFlow(t *testing.T) {
+	c, repos, api := setupLinked(t)
+	ctx := context.Background()
+
+	c.handle(ctx, command(555, "/mood"))
+	sent := api.take("sendMessage")
+	if len(sent) != 1 || !strings.Contains(sent[0].Get("reply_markup"), `"callback_data":"mood:😊"`) {
+		t.Fatalf("очікував клавіатуру з іконками, отримав %+v", sent)
+	}
+	if !strings.Contains(sent[0].Get("reply_markup"), `"text":"😊 Добре"`) {
+		t.Errorf("очікував назви іконок мовою користувача: %s", sent[0].Get("reply_markup"))
+	}
+
+	c.handle(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "cb1",
+		Data:    "mood:😊",
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 555}},
+	}})
+	api.mu.Lock()
+	calls := append([]apiCall{}, api.calls...)
+	api.mu.Unlock()
+	api.take("")
+	if len(calls) != 2 || calls[0].method != "answerCallbackQuery" || calls[0].form.Get("callback_query_id") != "cb1" {
+		t.Fatalf("callback не підтверджено: %+v", calls)
+	}
+	prompt := calls[1].form.Get("text")
+	if !strings.HasPrefix(prompt, "Настрій: 😊\n") || !strings.Contains(calls[1].form.Get("reply_markup"), "force_reply") {
+		t.Fatalf("неправильний запит коментаря: %+v", calls[1].form)
+	}
+
+	c.handle(ctx, tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:           "гарний день",
+		Chat:           &tgbotapi.Chat{ID: 555, Type: "private"},
+		ReplyToMessage: &tgbotapi.Message{Text: prompt},
+	}})
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "Записано: 😊 — гарний день") {
+		t.Errorf("неправильна відповідь на коментар: %q", texts)
+	}
+	list, _ := repos.Moods.List(ctx, "user-1", repository.MoodFilter{})
+	if len(list) != 1 || list[0].Icon != "😊" || list[0].Comment != "гарний день" {
+		t.Errorf("запис не створено: %+v", list)
+	}
+}
+
+func TestCommands_Validation(t *testing.T) {
+	c, repos, api := setupLinked(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		upd  tgbotapi.Update
+		want string
+	}{
+		{command(999, "/today"), "Чат не підключено"},
+		{command(555, "/mood 🦄 привіт"), "немає в каталозі"},
+		{command(555, "/mood 😊 перший"), "Записано"},
+		{command(555, "/mood 😢 другий"), "уже записано"},
+		{command(555, "/mood 😐"), "Настрій: 😐"},
+		{command(555, "/unknown"), "/undo"},
+	} {
+		c.handle(ctx, tc.upd)
+		if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], tc.want) {
+			t.Errorf("%q: очікував відповідь з %q, отримав %q", tc.upd.Message.Text, tc.want, texts)
+		}
+	}
+
+	// відповідь не на запит коментаря ігнорується
+	c.handle(ctx, tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:           "просто текст",
+		Chat:           &tgbotapi.Chat{ID: 555, Type: "private"},
+		ReplyToMessage: &tgbotapi.Message{Text: "інше повідомлення"},
+	}})
+	if texts := api.texts(); len(texts) != 0 {
+		t.Errorf("очікував тишу, отримав %q", texts)
+	}
+	if list, _ := repos.Moods.List(ctx, "user-1", repository.MoodFilter{}); len(list) != 1 {
+		t.Errorf("очікував 1 запис, отримав %+v", list)
+	}
+}
+
+func TestCommands_TodayUndoStop(t *testing.T) {
+	c, repos, api := setupLinked(t)
+	ctx := context.Background()
+	if err := repos.Users.UpdateSettings(ctx, "user-1", models.UserSettings{EntryMode: models.EntryModeMultiple, Timezone: "UTC"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.handle(ctx, command(555, "/today"))
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "ще немає записів") {
+		t.Errorf("/today без записів: %q", texts)
+	}
+
+	c.handle(ctx, command(555, "/mood 😊 зранку"))
+	time.Sleep(time.Millisecond)
+	c.handle(ctx, command(555, "/mood 😞 ввечері"))
+	api.take("")
+
+	c.handle(ctx, command(555, "/today"))
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "😊 — зранку") || !strings.Contains(texts[0], "😞 — ввечері") {
+		t.Errorf("/today: %q", texts)
+	}
+
+	c.handle(ctx, command(555, "/week"))
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "останні 7 днів") {
+		t.Errorf("/week: %q", texts)
+	}
+	c.handle(ctx, command(555, "/stats"))
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "30 днів") {
+		t.Errorf("/stats: %q", texts)
+	}
+
+	c.handle(ctx, command(555, "/undo"))
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "Видалено: 😞 — ввечері") {
+		t.Errorf("/undo: %q", texts)
+	}
+	if list, _ := repos.Moods.List(ctx, "user-1", repository.MoodFilter{}); len(list) != 1 || list[0].Comment != "зранку" {
+		t.Errorf("після /undo: %+v", list)
+	}
+
+	c.handle(ctx, command(555, "/stop"))
+	api.take("")
+	if u, _ := repos.Users.GetByID(ctx, "user-1"); u.TelegramChatID != nil {
+		t.Errorf("після /stop чат має бути від'єднано: %+v", u)
+	}
+	c.handle(ctx, command(555, "/today"))
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "Чат не підключено") {
+		t.Errorf("після /stop: %q", texts)
+	}
+}
+
+func TestCommands_Locale(t *testing.T) {
+	c, repos, api := setupLinked(t)
+	ctx := context.Background()
+	settings := models.UserSettings{EntryMode: models.EntryModeSingle, Timezone: "UTC", Locale: i18n.EN}
+	if err := repos.Users.UpdateSettings(ctx, "user-1", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	c.handle(ctx, command(555, "/mood"))
+	if sent := api.take("sendMessage"); len(sent) != 1 || !strings.Contains(sent[0].Get("reply_markup"), `"text":"😊 Good"`) {
+		t.Fatalf("очікував англійські назви іконок на клавіатурі, отримав %+v", sent)
+	}
+
+	// прив'язаний чат – мова користувача застосунку, навіть якщо Telegram українською
+	upd := command(555, "/mood 😊")
+	upd.Message.From = &tgbotapi.User{ID: 1, LanguageCode: "uk"}
+	c.handle(ctx, upd)
+	prompt := api.texts()
+	if len(prompt) != 1 || !strings.HasPrefix(prompt[0], "Mood: 😊\n") {
+		t.Fatalf("очікував англійський запит коментаря, отримав %q", prompt)
+	}
+	// відповідь на запит англійською теж створює запис
+	c.handle(ctx, tgbotapi.Update{Message: &tgbotapi.Message{
+		Text: "nice day", Chat: &tgbotapi.Chat{ID: 555, Type: "private"},
+		ReplyToMessage: &tgbotapi.Message{Text: prompt[0]},
+	}})
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "Logged: 😊 — nice day") {
+		t.Errorf("очікував англійське підтвердження, отримав %q", texts)
+	}
+
+	// неприв'язаний чат – мова інтерфейсу Telegram
+	upd = command(999, "/today")
+	upd.Message.From = &tgbotapi.User{ID: 2, LanguageCode: "en-GB"}
+	c.handle(ctx, upd)
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "not connected") {
+		t.Errorf("очікував англійську підказку, отримав %q", texts)
+	}
+}
+
+func TestPoll_FakeAPI(t *testing.T) {
+	c, repos, api := setupLinked(t)
+	bot := c.bot.(*tgbotapi.BotAPI)
+	api.updates = []tgbotapi.Update{command(555, "/mood 😃 через getUpdates")}
+	api.updates[0].UpdateID = 10
+
+	elector := leader.New(repos.Leases, "telegram")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if !elector.Step(ctx) {
+		t.Fatal("очікував лідерство")
+	}
+	done := make(chan struct{})
+	go func() {
+		poll(ctx, bot, elector, c)
+		close(done)
+	}()
+
+	// наступний getUpdates з offset 11 підтверджує, що оновлення 10 оброблено
+	deadline := time.Now().Add(2 * time.Second)
+	for !api.called("getUpdates", "offset", "11") {
+		if time.Now().After(deadline) {
+			t.Fatal("оновлення з getUpdates не оброблено")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if list, _ := repos.Moods.List(context.Background(), "user-1", repository.MoodFilter{}); len(list) != 1 || list[0].Comment != "через getUpdates" {
+		t.Errorf("запис з оновлення не створено: %+v", list)
+	}
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "Записано") {
+		t.Errorf("неправильна відповідь: %q", texts)
+	}
+}
@@ -1,110 +1,105 @@
 package telegram
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/go-co-op/gocron"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/jmoiron/sqlx"
+
+	"moodtracker/leader"
+	"moodtracker/models"
+	"moodtracker/notify"
+	"moodtracker/outbox"
+	"moodtracker/repository"
 )
 
-// Start запускає бота і планувальник
-func Start(db *sqlx.DB) {
-	token := os.Getenv("TELEGRAM_BOT_TOKEN")
-	if token == "" {
+// sender – частина tgbotapi.BotAPI, потрібна для розсилки і відповідей
+type sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// Bot – запущений бот: канал сповіщень і, в режимі webhook, обробник оновлень
+type Bot struct {
+	Notifier notify.Notifier
+	Webhook  http.Handler // nil у режимі polling
+}
+
+// Start запускає бота. Отримання оновлень через getUpdates і очищення позначок
+// update_id виконує лише лідер elector. Повертає nil, якщо токен не задано.
+func Start(repos repository.Set, cfg Config, elector *leader.Elector) *Bot {
+	if cfg.Token == "" {
 		log.Println("TELEGRAM_BOT_TOKEN not set, skipping Telegram bot")
-		return
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Telegram config: %v", err)
 	}
 
-	bot, err := tgbotapi.NewBotAPI(token)
+	bot, err := tgbotapi.NewBotAPI(cfg.Token)
 	if err != nil {
 		log.Fatalf("Failed to create botAPI: %v", err)
 	}
 	log.Printf("Authorized on account %s", bot.Self.UserName)
 
-	// Запускаємо планувальник
-	s := gocron.NewScheduler(time.Local)
-
-	// Щоденне нагадування о 20:00
-	s.Every(1).Day().At("20:00").Do(func() {
-		sendDailyReminder(bot, db)
-	})
-	// ТЕСТ нагадування кожні 10сек
-	s.Every(10).Second().Do(func() { sendDailyReminder(bot, db) })
+	// /start <token> від посилання з застосунку прив'язує чат, далі працюють команди бота
+	setCommands(bot)
+	cmds := newCommands(bot, repos)
+	go cmds.pruneLoop(context.Background(), elector)
+	b := &Bot{Notifier: &notifier{bot: bot}}
 
-	// Щотижневий звіт кожного понеділка о 09:00
-	s.Every(1).Week().Monday().At("09:00").Do(func() {
-		sendWeeklyReport(bot, db)
-	})
-	// ТЕСТ звіт кожні 30сек
-	s.Every(30).Second().Do(func() {
-		sendWeeklyReport(bot, db)
-	})
+	// Оновлення приходять або на webhook будь-якої репліки, або через getUpdates лідера
+	if cfg.Mode == ModeWebhook {
+		if err := setWebhook(bot, cfg); err != nil {
+			log.Fatalf("Failed to set Telegram webhook: %v", err)
+		}
+		log.Printf("Telegram updates via webhook %s", cfg.WebhookURL)
+		b.Webhook = &webhook{secret: cfg.WebhookSecret, c: cmds}
+		return b
+	}
+	// Поки зареєстровано webhook, Telegram відхиляє getUpdates
+	if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		log.Printf("failed to delete Telegram webhook: %v", err)
+	}
+	go poll(context.Background(), bot, elector, cmds)
+	return b
+}
 
-	s.StartAsync()
+// notifier – канал сповіщень у прив'язаний Telegram-чат; адреса – chat_id
+type notifier struct {
+	bot sender
 }
 
-// sendDailyReminder знаходить користувачів, які не додали сьогоднішній настрій, і надсилає їм повідомлення
-func sendDailyReminder(bot *tgbotapi.BotAPI, db *sqlx.DB) {
-	const query = `
-        SELECT telegram_chat_id
-        FROM users
-        WHERE telegram_chat_id IS NOT NULL
-          AND id NOT IN (
-            SELECT user_id FROM mood WHERE date = CURRENT_DATE
-          )`
-	var chatIDs []int64
-	if err := db.Select(&chatIDs, query); err != nil {
-		log.Printf("sendDailyReminder db error: %v", err)
-		return
-	}
+func (n *notifier) Channel() string { return models.JobTelegram }
 
-	for _, chatID := range chatIDs {
-		msg := tgbotapi.NewMessage(chatID, "Не забудь внести сьогоднішній настрій")
-		if _, err := bot.Send(msg); err != nil {
-			log.Printf("failed to send daily reminder to %d: %v", chatID, err)
-		}
+func (n *notifier) Recipients(_ context.Context, u models.User, _ models.NotificationSettings) ([]string, error) {
+	if u.TelegramChatID == nil {
+		return nil, nil
 	}
+	return []string{strconv.FormatInt(*u.TelegramChatID, 10)}, nil
 }
 
-// sendWeeklyReport збирає статистику за попередній тиждень і надсилає її користувачам із зареєстрованим чат-ID
-func sendWeeklyReport(bot *tgbotapi.BotAPI, db *sqlx.DB) {
-	const usersQuery = `SELECT telegram_chat_id, id FROM users WHERE telegram_chat_id IS NOT NULL`
-	type userRec struct {
-		ChatID int64  `db:"telegram_chat_id"`
-		UserID string `db:"id"`
-	}
-	var users []userRec
-	if err := db.Select(&users, usersQuery); err != nil {
-		log.Printf("sendWeeklyReport users err: %v", err)
-		return
+// Send надсилає лише msg.Body: текст уже містить заголовок звіту
+func (n *notifier) Send(_ context.Context, recipient string, msg notify.Message) error {
+	chatID, err := strconv.ParseInt(recipient, 10, 64)
+	if err != nil {
+		return outbox.Permanent(fmt.Errorf("invalid chat id %q", recipient))
 	}
-
-	for _, u := range users {
-		const statsQuery = `
-            SELECT icon, COUNT(*) AS cnt
-            FROM mood
-            WHERE user_id = $1
-              AND date >= CURRENT_DATE - INTERVAL '7 days'
-            GROUP BY icon`
-		rows, err := db.Queryx(statsQuery, u.UserID)
-		if err != nil {
-			log.Printf("stats query err for %s: %v", u.UserID, err)
-			continue
-		}
-		text := "Твій звіт за останній тиждень:\n"
-		for rows.Next() {
-			var icon string
-			var cnt int
-			rows.Scan(&icon, &cnt)
-			text += fmt.Sprintf("%s — %d\n", icon, cnt)
-		}
-		msg := tgbotapi.NewMessage(u.ChatID, text)
-		if _, err := bot.Send(msg); err != nil {
-			log.Printf("failed to send weekly report to %d: %v", u.ChatID, err)
+	_, err = n.bot.Send(tgbotapi.NewMessage(chatID, msg.Body))
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == 429:
+			return outbox.RetryAfter(time.Duration(apiErr.RetryAfter)*time.Second, err)
+		case apiErr.Code == 400 || apiErr.Code == 403:
+			// чат не існує або користувач заблокував бота
+			return outbox.Permanent(err)
 		}
 	}
+	return err
 }
@@ -0,0 +1,157 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"moodtracker/models"
+	"moodtracker/notify"
+	"moodtracker/outbox"
+	"moodtracker/repository"
+	"moodtracker/repository/memory"
+)
+
+// fakeBot запам'ятовує надіслані повідомлення замість виклику Telegram API
+type fakeBot struct {
+	sent []tgbotapi.MessageConfig
+	err  error
+}
+
+func (b *fakeBot) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if b.err != nil {
+		return tgbotapi.Message{}, b.err
+	}
+	b.sent = append(b.sent, c.(tgbotapi.MessageConfig))
+	return tgbotapi.Message{}, nil
+}
+
+func (b *fakeBot) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return &tgbotapi.APIResponse{Ok: true}, b.err
+}
+
+func TestNotifier(t *testing.T) {
+	bot := &fakeBot{}
+	n := &notifier{bot: bot}
+	chat := int64(42)
+	if got, _ := n.Recipients(context.Background(), models.User{ID: "u", TelegramChatID: &chat}, models.NotificationSettings{}); len(got) != 1 || got[0] != "42" {
+		t.Errorf("неправильні адреси: %v", got)
+	}
+	if got, _ := n.Recipients(context.Background(), models.User{ID: "u"}, models.NotificationSettings{}); len(got) != 0 {
+		t.Errorf("без чату адрес бути не має: %v", got)
+	}
+
+	send := n.Send
+	if err := send(context.Background(), "42", notify.Message{Title: "Нагадування", Body: "привіт"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(bot.sent) != 1 || bot.sent[0].ChatID != 42 || bot.sent[0].Text != "привіт" {
+		t.Errorf("неправильне повідомлення: %+v", bot.sent)
+	}
+
+	bot.err = &tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 7}}
+	var retry *outbox.RetryAfterError
+	if err := send(context.Background(), "42", notify.Message{}); !errors.As(err, &retry) || retry.After != 7*time.Second {
+		t.Errorf("429: очікував RetryAfter 7s, отримав %v", err)
+	}
+
+	bot.err = &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	if err := send(context.Background(), "42", notify.Message{}); !outbox.IsPermanent(err) {
+		t.Errorf("403: очікував постійну помилку, отримав %v", err)
+	}
+}
+
+// startUpdate – повідомлення "/start <args>" з чату chatID
+func startUpdate(chatID int64, chatType, args string) tgbotapi.Update {
+	text := "/start"
+	if args != "" {
+		text += " " + args
+	}
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:     text,
+		Chat:     &tgbotapi.Chat{ID: chatID, Type: chatType},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/start")}},
+	}}
+}
+
+func setupCommands(t *testing.T) (*commands, repository.Set, *fakeBot) {
+	repos := memory.New()
+	bot := &fakeBot{}
+	for _, id := range []string{"user-1", "user-2"} {
+		if err := repos.Users.Create(context.Background(), &models.User{ID: id, Email: id + "@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return newCommands(bot, repos), repos, bot
+}
+
+func addLink(t *testing.T, repos repository.Set, token, userID string, expires time.Time) {
+	if err := repos.TelegramLinks.Create(context.Background(), &models.TelegramLink{Token: token, UserID: userID, ExpiresAt: expires}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStart_LinksChat(t *testing.T) {
+	c, repos, bot := setupCommands(t)
+	ctx := context.Background()
+	addLink(t, repos, "tok1", "user-1", time.Now().Add(time.Minute))
+
+	c.handle(ctx, startUpdate(555, "private", "tok1"))
+	u, _ := repos.Users.GetByID(ctx, "user-1")
+	if u.TelegramChatID == nil || *u.TelegramChatID != 555 {
+		t.Fatalf("чат не прив'язано: %+v", u)
+	}
+	if len(bot.sent) != 1 || bot.sent[0].ChatID != 555 || !strings.Contains(bot.sent[0].Text, "Готово") {
+		t.Errorf("неправильна відповідь: %+v", bot.sent)
+	}
+
+	// токен одноразовий
+	c.handle(ctx, startUpdate(556, "private", "tok1"))
+	if u, _ := repos.Users.GetByID(ctx, "user-1"); *u.TelegramChatID != 555 || !strings.Contains(bot.sent[1].Text, "недійсне") {
+		t.Errorf("повторне використання токена: %+v, %q", u, bot.sent[1].Text)
+	}
+}
+
+func TestStart_Rejected(t *testing.T) {
+	c, repos, bot := setupCommands(t)
+	ctx := context.Background()
+	addLink(t, repos, "expired", "user-1", time.Now().Add(-time.Second))
+	addLink(t, repos, "group", "user-1", time.Now().Add(time.Minute))
+	addLink(t, repos, "taken", "user-2", time.Now().Add(time.Minute))
+	if err := repos.Users.SetTelegramChatID(ctx, "user-1", 777); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		upd  tgbotapi.Update
+		want string
+	}{
+		{startUpdate(1, "private", ""), "Підключити Telegram"},
+		{startUpdate(1, "private", "expired"), "недійсне"},
+		{startUpdate(-100, "group", "group"), "особистому чаті"},
+		{startUpdate(777, "private", "taken"), "іншого акаунта"},
+	} {
+		bot.sent = nil
+		c.handle(ctx, tc.upd)
+		if len(bot.sent) != 1 || !strings.Contains(bot.sent[0].Text, tc.want) {
+			t.Errorf("%q: очікував відповідь з %q, отримав %+v", tc.upd.Message.Text, tc.want, bot.sent)
+		}
+	}
+	if u, _ := repos.Users.GetByID(ctx, "user-2"); u.TelegramChatID != nil {
+		t.Errorf("чужий чат не можна прив'язати: %+v", u)
+	}
+
+	// відмова не витрачає токен: після відключення чату те саме посилання спрацьовує
+	if err := repos.Users.UnlinkTelegram(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	bot.sent = nil
+	c.handle(ctx, startUpdate(777, "private", "taken"))
+	if u, _ := repos.Users.GetByID(ctx, "user-2"); u.TelegramChatID == nil || *u.TelegramChatID != 777 || !strings.Contains(bot.sent[0].Text, "Готово") {
+		t.Errorf("токен мав лишитися чинним: %+v, %+v", u, bot.sent)
+	}
+}
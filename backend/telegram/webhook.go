@@ -0,0 +1,109 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"moodtracker/problem"
+)
+
+// Режими отримання оновлень (TELEGRAM_MODE)
+const (
+	ModePolling = "polling" // getUpdates з лідера; не потребує публічної адреси
+	ModeWebhook = "webhook" // Telegram сам надсилає оновлення на TELEGRAM_WEBHOOK_URL
+)
+
+// secretHeader – заголовок, у якому Telegram передає secret_token із setWebhook
+const secretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Telegram дозволяє в secret_token лише 1–256 символів A-Z, a-z, 0-9, _ і -
+var validSecret = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// Config – налаштування бота
+type Config struct {
+	Token         string
+	Mode          string
+	WebhookURL    string // публічна https-адреса, що веде на /api/telegram/webhook
+	WebhookSecret string
+}
+
+// ConfigFromEnv читає налаштування з TELEGRAM_BOT_TOKEN, TELEGRAM_MODE,
+// TELEGRAM_WEBHOOK_URL і TELEGRAM_WEBHOOK_SECRET. Типовий режим – polling.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Token:         os.Getenv("TELEGRAM_BOT_TOKEN"),
+		Mode:          os.Getenv("TELEGRAM_MODE"),
+		WebhookURL:    os.Getenv("TELEGRAM_WEBHOOK_URL"),
+		WebhookSecret: os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModePolling
+	}
+	return cfg
+}
+
+// Validate перевіряє, що для обраного режиму задано все потрібне
+func (c Config) Validate() error {
+	switch c.Mode {
+	case ModePolling:
+		return nil
+	case ModeWebhook:
+	default:
+		return fmt.Errorf("unknown TELEGRAM_MODE %q, want %q or %q", c.Mode, ModePolling, ModeWebhook)
+	}
+	u, err := url.Parse(c.WebhookURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("TELEGRAM_WEBHOOK_URL must be an absolute https URL")
+	}
+	if !validSecret.MatchString(c.WebhookSecret) {
+		return errors.New("TELEGRAM_WEBHOOK_SECRET must be 1-256 characters of A-Z, a-z, 0-9, _ or -")
+	}
+	return nil
+}
+
+// setWebhook реєструє адресу webhook разом із secret_token. tgbotapi.WebhookConfig
+// не вміє передавати secret_token, тому запит складаємо вручну.
+func setWebhook(bot *tgbotapi.BotAPI, cfg Config) error {
+	params := tgbotapi.Params{"url": cfg.WebhookURL, "secret_token": cfg.WebhookSecret}
+	if err := params.AddInterface("allowed_updates", []string{"message", "callback_query"}); err != nil {
+		return err
+	}
+	_, err := bot.MakeRequest("setWebhook", params)
+	return err
+}
+
+// webhook приймає оновлення, які Telegram надсилає POST-запитом
+type webhook struct {
+	secret string
+	c      *commands
+}
+
+func (h *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Без правильного секрету запит міг надіслати будь-хто, хто знає адресу
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretHeader)), []byte(h.secret)) != 1 {
+		problem.Write(w, r, http.StatusUnauthorized, "invalid_webhook_secret")
+		return
+	}
+
+	var upd tgbotapi.Update
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&upd); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_update")
+		return
+	}
+	// Не вдалося взяти чи позначити update_id – 500, і Telegram повторить доставку пізніше
+	if err := h.c.process(r.Context(), upd); err != nil {
+		log.Printf("telegram webhook update %d err: %v", upd.UpdateID, err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
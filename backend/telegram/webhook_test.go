@@ -0,0 +1,152 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"moodtracker/problem"
+	"moodtracker/repository"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		cfg Config
+		ok  bool
+	}{
+		{Config{Mode: ModePolling}, true},
+		{Config{Mode: ModeWebhook, WebhookURL: "https://example.com/api/telegram/webhook", WebhookSecret: "s3cr3t_-"}, true},
+		{Config{Mode: "push"}, false},
+		{Config{Mode: ModeWebhook, WebhookURL: "http://example.com/hook", WebhookSecret: "s3cr3t"}, false},
+		{Config{Mode: ModeWebhook, WebhookURL: "https://example.com/hook"}, false},
+		{Config{Mode: ModeWebhook, WebhookURL: "https://example.com/hook", WebhookSecret: "не латиниця"}, false},
+	} {
+		if err := tc.cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%+v: очікував ok=%v, отримав %v", tc.cfg, tc.ok, err)
+		}
+	}
+}
+
+func TestConfigFromEnv_DefaultPolling(t *testing.T) {
+	t.Setenv("TELEGRAM_MODE", "")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "tok")
+	if cfg := ConfigFromEnv(); cfg.Mode != ModePolling || cfg.Token != "tok" {
+		t.Errorf("неправильна конфігурація: %+v", cfg)
+	}
+}
+
+func TestSetWebhook_SendsSecret(t *testing.T) {
+	api, bot := setupAPI(t)
+	cfg := Config{Mode: ModeWebhook, WebhookURL: "https://example.com/api/telegram/webhook", WebhookSecret: "s3cr3t"}
+	if err := setWebhook(bot, cfg); err != nil {
+		t.Fatal(err)
+	}
+	calls := api.take("setWebhook")
+	if len(calls) != 1 || calls[0].Get("url") != cfg.WebhookURL || calls[0].Get("secret_token") != "s3cr3t" {
+		t.Errorf("неправильний setWebhook: %+v", calls)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	c, repos, api := setupLinked(t)
+	h := &webhook{secret: "s3cr3t", c: c}
+	body := `{"update_id": 7, "message": {"message_id": 1, "date": 0, "text": "/mood 😊 з webhook",
+		"chat": {"id": 555, "type": "private"}, "entities": [{"type": "bot_command", "offset": 0, "length": 5}]}}`
+
+	post := func(secret string) int {
+		req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(body))
+		if secret != "" {
+			req.Header.Set(secretHeader, secret)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for _, secret := range []string{"", "wrong"} {
+		if code := post(secret); code != http.StatusUnauthorized {
+			t.Errorf("секрет %q: очікував 401, отримав %d", secret, code)
+		}
+	}
+	// помилки – у форматі problem+json, як і решта API
+	req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader("{"))
+	req.Header.Set(secretHeader, "s3cr3t")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != problem.ContentType || !strings.Contains(rec.Body.String(), `"code":"invalid_update"`) {
+		t.Errorf("некоректне оновлення: очікував 400 problem+json, отримав %d %s: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(body)))
+	if rec.Header().Get("Content-Type") != problem.ContentType || !strings.Contains(rec.Body.String(), `"code":"invalid_webhook_secret"`) {
+		t.Errorf("без секрету: очікував problem+json, отримав %s: %s", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	if texts := api.texts(); len(texts) != 0 {
+		t.Fatalf("без секрету оновлення не обробляється, отримав %q", texts)
+	}
+
+	if code := post("s3cr3t"); code != http.StatusOK {
+		t.Fatalf("очікував 200, отримав %d", code)
+	}
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "Записано") {
+		t.Errorf("неправильна відповідь: %q", texts)
+	}
+
+	// повторна доставка того самого update_id: 200, але без повторної обробки
+	if code := post("s3cr3t"); code != http.StatusOK {
+		t.Fatalf("повтор: очікував 200, отримав %d", code)
+	}
+	if texts := api.texts(); len(texts) != 0 {
+		t.Errorf("повтор обробився вдруге: %q", texts)
+	}
+	if list, _ := repos.Moods.List(context.Background(), "user-1", repository.MoodFilter{}); len(list) != 1 {
+		t.Errorf("очікував 1 запис, отримав %+v", list)
+	}
+}
+
+func TestWebhook_RedeliveryAfterCrash(t *testing.T) {
+	c, repos, api := setupLinked(t)
+	h := &webhook{secret: "s3cr3t", c: c}
+	body := `{"update_id": 8, "message": {"message_id": 1, "date": 0, "text": "/mood 😊 після падіння",
+		"chat": {"id": 555, "type": "private"}, "entities": [{"type": "bot_command", "offset": 0, "length": 5}]}}`
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(body))
+		req.Header.Set(secretHeader, "s3cr3t")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// інший процес щойно взяв оновлення і ще не відповів: 500, Telegram доставить пізніше
+	if _, err := repos.TelegramUpdates.Claim(context.Background(), 8, time.Now(), updateLease); err != nil {
+		t.Fatal(err)
+	}
+	if rec := post(); rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != problem.ContentType {
+		t.Fatalf("оновлення в обробці: очікував 500 problem+json, отримав %d: %s", rec.Code, rec.Body.String())
+	}
+	if texts := api.texts(); len(texts) != 0 {
+		t.Fatalf("оновлення в обробці не має оброблятися вдруге, отримав %q", texts)
+	}
+
+	// процес упав до Done: після оренди повторна доставка обробляється, а не губиться
+	if _, err := repos.TelegramUpdates.Claim(context.Background(), 9, time.Now().Add(-2*updateLease), updateLease); err != nil {
+		t.Fatal(err)
+	}
+	body = strings.Replace(body, `"update_id": 8`, `"update_id": 9`, 1)
+	if rec := post(); rec.Code != http.StatusOK {
+		t.Fatalf("після падіння: очікував 200, отримав %d: %s", rec.Code, rec.Body.String())
+	}
+	if texts := api.texts(); len(texts) != 1 || !strings.Contains(texts[0], "Записано") {
+		t.Errorf("неправильна відповідь: %q", texts)
+	}
+	if list, _ := repos.Moods.List(context.Background(), "user-1", repository.MoodFilter{}); len(list) != 1 {
+		t.Errorf("очікував 1 запис, отримав %+v", list)
+	}
+	// після завершеної обробки повтор пропускається
+	if rec := post(); rec.Code != http.StatusOK || len(api.texts()) != 0 {
+		t.Errorf("повтор після обробки: %d", rec.Code)
+	}
+}
@@ -0,0 +1,52 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"moodtracker/i18n"
+)
+
+// ErrInvalidJSON – тіло запиту не є одним JSON-об'єктом
+var ErrInvalidJSON = i18n.NewError("invalid_json")
+
+// DecodeJSON суворо розбирає тіло r у dst: невідоме поле чи значення не того типу –
+// Errors з ім'ям поля, синтаксична помилка або кілька JSON-значень – ErrInvalidJSON.
+// Перевищення ліміту http.MaxBytesReader повертається як *http.MaxBytesError.
+func DecodeJSON(r io.Reader, dst any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(dst)
+	if err == nil {
+		if dec.More() {
+			return ErrInvalidJSON
+		}
+		// після об'єкта дозволені лише пробіли
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			return decodeError(err)
+		}
+		return nil
+	}
+	return decodeError(err)
+}
+
+func decodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return ErrInvalidJSON
+	case errors.As(err, &tooLarge):
+		return err
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return Errors{Field(typeErr.Field, InvalidType)}
+	}
+	// encoding/json не має окремого типу для невідомого поля
+	if field, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		return Errors{Field(strings.TrimSuffix(field, `"`), UnknownField)}
+	}
+	return ErrInvalidJSON
+}
@@ -0,0 +1,38 @@
+package validate
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Optional – поле тіла PATCH за JSON Merge Patch (RFC 7386): відсутнє (Set=false),
+// null (Null=true – прибрати значення) або нове значення Value.
+// Правила validate застосовуються лише до переданого значення; required забороняє null.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// Some – передане значення v
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Set: true, Value: v}
+}
+
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	if string(b) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(b, &o.Value)
+}
+
+// optional – Optional будь-якого типу для Struct
+type optional interface {
+	state() (set, null bool, v reflect.Value)
+}
+
+func (o Optional[T]) state() (bool, bool, reflect.Value) {
+	return o.Set, o.Null, reflect.ValueOf(o.Value)
+}
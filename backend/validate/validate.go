@@ -0,0 +1,208 @@
+// Package validate – декларативна перевірка тіл запитів (DTO) з помилками по полях.
+// Правила задаються тегом validate, ім'я поля в помилці – тегом json:
+//
+//	Icon    string `json:"icon" validate:"required,max=50"`
+//	Date    string `json:"date" validate:"date"`
+//	Comment *string `json:"comment" validate:"max=1000"`
+//	Date    Optional[string] `json:"date" validate:"required,date"` // PATCH: не null
+//
+// Перевірки, що залежать від контексту (дата в поясі користувача, наявність іконки),
+// доповнюють той самий список помилок через Field.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"moodtracker/i18n"
+	"moodtracker/problem"
+)
+
+// CodeInvalid – код відповіді, коли хоча б одне поле некоректне
+const CodeInvalid = "validation_failed"
+
+// Коди помилок полів (вони ж ключі каталогу i18n)
+const (
+	Required     = "required"
+	TooLong      = "too_long"
+	TooSmall     = "too_small"
+	TooLarge     = "too_large"
+	InvalidDate  = "invalid_date"
+	UnknownField = "unknown_field"
+	InvalidType  = "invalid_type"
+)
+
+// Error – помилка одного поля: Field – ім'я в JSON, Err – код і повідомлення з каталогу
+type Error struct {
+	Field string
+	Err   *i18n.Error
+}
+
+// Field створює помилку поля field з повідомленням key (див. i18n.NewError)
+func Field(field, key string, args ...any) Error {
+	return Error{Field: field, Err: i18n.NewError(key, args...)}
+}
+
+// FieldN – Field для повідомлення з кількістю n (див. i18n.NewPluralError)
+func FieldN(field, key string, n int, args ...any) Error {
+	return Error{Field: field, Err: i18n.NewPluralError(key, n, args...)}
+}
+
+func (e Error) Error() string { return e.Field + ": " + e.Err.Error() }
+
+// Errors – усі помилки полів запиту
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e Errors) Code() string { return CodeInvalid }
+
+// Localize – загальний текст помилки; подробиці по полях повертає Fields
+func (e Errors) Localize(l i18n.Locale) string { return i18n.T(l, CodeInvalid) }
+
+// Fields – помилки полів мовою l для відповіді API
+func (e Errors) Fields(l i18n.Locale) []problem.FieldError {
+	out := make([]problem.FieldError, len(e))
+	for i, fe := range e {
+		out[i] = problem.FieldError{Field: fe.Field, Code: fe.Err.Key, Detail: fe.Err.Localize(l)}
+	}
+	return out
+}
+
+// Is дозволяє шукати конкретну помилку поля: errors.Is(err, validate.Field("comment", validate.Required))
+func (e Errors) Is(target error) bool {
+	t, ok := target.(Error)
+	if !ok {
+		return false
+	}
+	for _, fe := range e {
+		if fe.Field == t.Field && fe.Err.Key == t.Err.Key {
+			return true
+		}
+	}
+	return false
+}
+
+// Err повертає e як помилку або nil, якщо помилок немає
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Struct перевіряє поля структури v (або вказівника на неї) за тегами validate:
+//
+//	required – рядок не порожній (без пробілів по краях), вказівник не nil
+//	max=N    – рядок не довший за N символів, число не більше N
+//	min=N    – число не менше N
+//	date     – непорожній рядок у форматі YYYY-MM-DD
+//
+// Поле-вказівник nil перевіряє лише required, поле Optional – лише передане значення.
+// Повертає Errors або nil.
+func Struct(v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+	var errs Errors
+	for i := 0; i < rt.NumField(); i++ {
+		tag, ok := rt.Field(i).Tag.Lookup("validate")
+		if !ok || tag == "" {
+			continue
+		}
+		if fe, failed := check(name(rt.Field(i)), rv.Field(i), tag); failed {
+			errs = append(errs, fe)
+		}
+	}
+	return errs.Err()
+}
+
+// name – ім'я поля в JSON
+func name(f reflect.StructField) string {
+	if n, _, _ := strings.Cut(f.Tag.Get("json"), ","); n != "" && n != "-" {
+		return n
+	}
+	return f.Name
+}
+
+// check застосовує правила tag до значення v і повертає першу помилку
+func check(field string, v reflect.Value, tag string) (Error, bool) {
+	if o, ok := v.Interface().(optional); ok {
+		set, null, value := o.state()
+		switch {
+		case !set:
+			return Error{}, false
+		case null && hasRule(tag, Required):
+			return Field(field, Required), true
+		case null:
+			return Error{}, false
+		}
+		v = value
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if hasRule(tag, Required) {
+				return Field(field, Required), true
+			}
+			return Error{}, false
+		}
+		v = v.Elem()
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		rule, arg, _ := strings.Cut(rule, "=")
+		switch rule {
+		case Required:
+			if v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "" {
+				return Field(field, Required), true
+			}
+		case "max":
+			n := limit(field, arg)
+			switch {
+			case v.Kind() == reflect.String && utf8.RuneCountInString(v.String()) > n:
+				return FieldN(field, TooLong, n), true
+			case v.CanInt() && v.Int() > int64(n):
+				return Field(field, TooLarge, n), true
+			}
+		case "min":
+			if n := limit(field, arg); v.CanInt() && v.Int() < int64(n) {
+				return Field(field, TooSmall, n), true
+			}
+		case "date":
+			if s := v.String(); s != "" {
+				if _, err := time.Parse("2006-01-02", s); err != nil {
+					return Field(field, InvalidDate), true
+				}
+			}
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q for field %s", rule, field))
+		}
+	}
+	return Error{}, false
+}
+
+func hasRule(tag, rule string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// limit розбирає аргумент правила max/min; помилка в тегу – помилка програміста
+func limit(field, arg string) int {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		panic(fmt.Sprintf("validate: bad limit %q for field %s", arg, field))
+	}
+	return n
+}
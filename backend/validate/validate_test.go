@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"moodtracker/i18n"
+)
+
+type sample struct {
+	Icon    string  `json:"icon" validate:"required,max=3"`
+	Date    string  `json:"date,omitempty" validate:"date"`
+	Comment *string `json:"comment" validate:"max=5"`
+	Score   int     `json:"score" validate:"min=1,max=5"`
+	Note    *string `json:"note" validate:"required"`
+	Free    string
+}
+
+// codes – "поле:код" для кожної помилки
+func codes(t *testing.T, err error) string {
+	t.Helper()
+	var errs Errors
+	if err != nil && !errors.As(err, &errs) {
+		t.Fatalf("очікував Errors, отримав %v", err)
+	}
+	var out []string
+	for _, fe := range errs {
+		out = append(out, fe.Field+":"+fe.Err.Key)
+	}
+	return strings.Join(out, " ")
+}
+
+func TestStruct(t *testing.T) {
+	long, note := "довгий", "ok"
+	for _, tc := range []struct {
+		in   sample
+		want string
+	}{
+		{sample{Icon: "😃", Score: 3, Note: &note}, ""},
+		{sample{Icon: "  ", Score: 3, Note: &note}, "icon:required"},
+		{sample{Icon: "😃😃😃😃", Date: "15.01.2025", Comment: &long, Score: 9}, "icon:too_long date:invalid_date comment:too_long score:too_large note:required"},
+		{sample{Icon: "abc", Date: "2025-01-15", Score: 0, Note: &note}, "score:too_small"},
+	} {
+		if got := codes(t, Struct(&tc.in)); got != tc.want {
+			t.Errorf("%+v: очікував %q, отримав %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	err := Struct(sample{Icon: "😃😃😃😃", Score: 1})
+	if !errors.Is(err, Field("note", Required)) || errors.Is(err, Field("icon", Required)) {
+		t.Errorf("errors.Is має розрізняти поле і код: %v", err)
+	}
+	if got := err.Error(); got != "icon: must be at most 3 characters; note: field is required" {
+		t.Errorf("отримав %q", got)
+	}
+	fields := err.(Errors).Fields(i18n.UK)
+	if len(fields) != 2 || fields[0].Code != TooLong || fields[0].Detail != "щонайбільше 3 символи" {
+		t.Errorf("неправильні поля: %+v", fields)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"icon":"😃","score":2}`:      "",
+		`{"icon":"😃","mood":5}`:       "mood:unknown_field",
+		`{"icon":"😃","score":"five"}`: "score:invalid_type",
+		`{"icon":"😃"} {"icon":"😞"}`:   "invalid_json",
+		`{"icon":"😃"}}`:               "invalid_json",
+		`{"icon":`:                    "invalid_json",
+		"{\"icon\":\"😃\"}\n  \n":      "",
+	} {
+		var dst sample
+		err := DecodeJSON(strings.NewReader(body), &dst)
+		got := ""
+		switch {
+		case errors.Is(err, ErrInvalidJSON):
+			got = "invalid_json"
+		case err != nil:
+			got = codes(t, err)
+		}
+		if got != want {
+			t.Errorf("%s: очікував %q, отримав %q (%v)", body, want, got, err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	body := http.MaxBytesReader(w, io.NopCloser(strings.NewReader(strings.Repeat(" ", 100)+`{}`)), 64)
+	var tooLarge *http.MaxBytesError
+	if err := DecodeJSON(body, &sample{}); !errors.As(err, &tooLarge) {
+		t.Errorf("очікував MaxBytesError, отримав %v", err)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	type patch struct {
+		Icon Optional[string] `json:"icon" validate:"required,max=3"`
+		Note Optional[string] `json:"note" validate:"max=3"`
+		Size Optional[int]    `json:"size" validate:"min=1"`
+	}
+	for body, want := range map[string]string{
+		`{}`:            "",
+		`{"note":null}`: "",
+		`{"icon":null}`: "icon:required",
+		`{"icon":"довге","note":"довге"}`: "icon:too_long note:too_long",
+		`{"icon":"ok","size":0}`:          "size:too_small",
+	} {
+		var p patch
+		err := DecodeJSON(strings.NewReader(body), &p)
+		if err == nil {
+			err = Struct(p)
+		}
+		if got := codes(t, err); got != want {
+			t.Errorf("%s: очікував %q, отримав %q", body, want, got)
+		}
+	}
+
+	var p patch
+	if err := DecodeJSON(strings.NewReader(`{"icon":"ok","note":null}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Icon.Set || p.Icon.Value != "ok" || !p.Note.Set || !p.Note.Null || p.Size.Set {
+		t.Errorf("неправильний стан полів: %+v", p)
+	}
+}
@@ -1,6 +1,7 @@
 import { BrowserRouter, Routes, Route, Navigate } from "react-router-dom";
 import { AuthProvider } from "./contexts/AuthContext";
 import Login from "./pages/Login";
+import LoginCallback from "./pages/LoginCallback";
 import MoodEntry from "./pages/MoodEntry";
 import MoodHistory from "./pages/MoodHistory";
 import MoodChart from "./pages/MoodChart";
@@ -19,6 +20,8 @@ export default function App() {
         <Navbar /> {/*дає посилання на сторінки й кнопку*/}
         <Routes>
           <Route path="/login" element={<Login />} />
+          <Route path="/login/verify" element={<Login />} />
+          <Route path="/login/callback" element={<LoginCallback />} />
           <Route
             path="/entry"
             element={
@@ -13,4 +13,45 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+// Один спільний запит на оновлення, навіть якщо 401 отримали кілька запитів одночасно
+let refreshing = null;
+
+const refreshTokens = async () => {
+  const refreshToken = localStorage.getItem("refresh_token");
+  if (!refreshToken) {
+    throw new Error("no refresh token");
+  }
+  const resp = await axios.post(`${import.meta.env.VITE_API_BASE_URL}/auth/refresh`, {
+    refresh_token: refreshToken,
+  });
+  localStorage.setItem("token", resp.data.token);
+  localStorage.setItem("refresh_token", resp.data.refresh_token);
+  return resp.data.token;
+};
+
+// Access-токен живе 15 хв: на 401 пробуємо один раз оновити його refresh-токеном
+api.interceptors.response.use(
+  (resp) => resp,
+  async (error) => {
+    const original = error.config;
+    if (error.response?.status !== 401 || original._retried || original.url.startsWith("/auth/")) {
+      return Promise.reject(error);
+    }
+    original._retried = true;
+    try {
+      refreshing = refreshing || refreshTokens();
+      const token = await refreshing;
+      original.headers.Authorization = `Bearer ${token}`;
+      return api(original);
+    } catch (e) {
+      localStorage.removeItem("token");
+      localStorage.removeItem("refresh_token");
+      window.location.assign("/login");
+      return Promise.reject(error);
+    } finally {
+      refreshing = null;
+    }
+  }
+);
+
 export default api;
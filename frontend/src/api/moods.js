@@ -0,0 +1,19 @@
+import api from "./axios";
+
+// Усі записи за період from..to. GET /mood віддає сторінки: проходимо їх за next_cursor
+export const fetchMoods = async (from, to) => {
+  const moods = [];
+  let cursor = "";
+  do {
+    const params = { from, to, limit: 200 };
+    if (cursor) params.cursor = cursor;
+    const resp = await api.get("/mood", { params });
+    moods.push(...resp.data.data);
+    cursor = resp.data.next_cursor;
+  } while (cursor);
+  return moods;
+};
+
+// Час запису в межах дня: сторінки йдуть за (date, id), тож записи дня впорядковуємо самі
+export const byEntryTime = (a, b) =>
+  (a.logged_at || a.created_at).localeCompare(b.logged_at || b.created_at);
@@ -1,11 +1,28 @@
-import { useState } from "react";
+import { useEffect, useState } from "react";
+import api from "../api/axios";
 
+// Поки каталог не завантажено, показуємо вбудовані іконки
+const defaultIcons = [
+  { icon: "😃", label: "Чудово" },
+  { icon: "😊", label: "Добре" },
+  { icon: "😐", label: "Нейтрально" },
+  { icon: "😞", label: "Сумно" },
+  { icon: "😢", label: "Погано" },
+  { icon: "😡", label: "Злість" },
+];
 
-//IconPicker відображає набір іконок, викликає onSelect(icon) коли користувач обрав іконку.
+//IconPicker відображає іконки з каталогу /moods/catalog (разом із власними), викликає onSelect(icon) коли користувач обрав іконку.
 export default function IconPicker({ onSelect }) {
-  const icons = ["😃", "😊", "😐", "😞", "😢", "😡"];
+  const [icons, setIcons] = useState(defaultIcons);
   const [selected, setSelected] = useState("");
 
+  useEffect(() => {
+    api
+      .get("/moods/catalog")
+      .then((resp) => setIcons(resp.data.icons))
+      .catch((err) => console.error(err));
+  }, []);
+
   const handleClick = (icon) => {
     setSelected(icon);
     onSelect(icon);
@@ -13,9 +30,10 @@ export default function IconPicker({ onSelect }) {
 
   return (
     <div style={{ display: "flex", gap: "1rem", marginTop: "1rem" }}>
-      {icons.map((icon) => (
+      {icons.map(({ icon, label }) => (
         <div
           key={icon}
+          title={label}
           onClick={() => handleClick(icon)}
           style={{
             fontSize: "2rem",
@@ -17,21 +17,39 @@ export function AuthProvider({ children }) {
     }
   }, [token]);
 
-  // робить "POST /auth/login" і зберігає токен в localStorage
-  const login = async (email) => {
-    const resp = await api.post("/auth/login", { email });
-    setToken(resp.data.token);
+  // робить "POST /auth/login" – бекенд надсилає одноразовий код на email
+  const requestCode = async (email) => {
+    await api.post("/auth/login", { email });
+  };
+
+  // зберігає пару токенів після успішного входу
+  const acceptTokens = (accessToken, refreshToken) => {
+    localStorage.setItem("refresh_token", refreshToken);
+    setToken(accessToken);
     navigate("/entry");
   };
 
-  const logout = () => {
+  // робить "POST /auth/verify" і зберігає токен в localStorage
+  const verify = async (email, code) => {
+    const resp = await api.post("/auth/verify", { email, code });
+    acceptTokens(resp.data.token, resp.data.refresh_token);
+  };
+
+  // відкликає сесію на сервері (allDevices – усі сесії користувача)
+  const logout = async (allDevices = false) => {
+    try {
+      await api.post(allDevices ? "/auth/logout-all" : "/auth/logout");
+    } catch (err) {
+      // токен міг уже закінчитися – локальний вихід все одно виконуємо
+    }
     localStorage.removeItem("token");
+    localStorage.removeItem("refresh_token");
     setToken("");
     navigate("/login");
   };
 
   return (
-    <AuthContext.Provider value={{ token, login, logout }}>
+    <AuthContext.Provider value={{ token, requestCode, verify, acceptTokens, logout }}>
       {children}
     </AuthContext.Provider>
   );
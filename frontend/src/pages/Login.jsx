@@ -1,24 +1,56 @@
-import { useState, useContext } from "react";
+import { useState, useContext, useEffect } from "react";
 import { AuthContext } from "../contexts/AuthContext";
-import { useNavigate } from "react-router-dom";
+import { useSearchParams } from "react-router-dom";
 
 export default function Login() {
-  const [email, setEmail] = useState("");
-  const { login } = useContext(AuthContext);
+  const [searchParams] = useSearchParams();
+  const [email, setEmail] = useState(searchParams.get("email") || "");
+  const [code, setCode] = useState(searchParams.get("code") || "");
+  const [codeSent, setCodeSent] = useState(false);
+  const { requestCode, verify } = useContext(AuthContext);
   const [error, setError] = useState("");
-  const navigate = useNavigate();
 
-  const handleSubmit = async (e) => {
+  // Якщо користувач перейшов за посиланням з листа – одразу підтверджуємо код
+  useEffect(() => {
+    const linkEmail = searchParams.get("email");
+    const linkCode = searchParams.get("code");
+    if (linkEmail && linkCode) {
+      verify(linkEmail, linkCode).catch(() => {
+        setCodeSent(true);
+        setError("Посилання недійсне або застаріле");
+      });
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  const handleRequestCode = async (e) => {
     e.preventDefault();
     if (!email) {
       setError("Email обов’язковий");
       return;
     }
     try {
-      await login(email);
-      navigate("/entry")
+      await requestCode(email);
+      setCodeSent(true);
     } catch (err) {
-      setError("Не вдалося увійти");
+      if (err.response && err.response.status === 429) {
+        setError("Забагато спроб, спробуйте пізніше");
+      } else {
+        setError("Не вдалося надіслати код");
+      }
+    }
+  };
+
+  const handleVerify = async (e) => {
+    e.preventDefault();
+    if (!code) {
+      setError("Введіть код з листа");
+      return;
+    }
+    try {
+      await verify(email, code);
+    } catch (err) {
+      setError("Невірний або застарілий код");
     }
   };
 
@@ -26,23 +58,51 @@ export default function Login() {
     <div className="app-container">
       <h2 className="page-title">Увійти</h2>
       <div className="form-card">
-        <form onSubmit={handleSubmit}>
+        {!codeSent ? (
+          <form onSubmit={handleRequestCode}>
             <div>
-            <label>Email:</label>
-            <input
+              <label>Email:</label>
+              <input
                 type="email"
                 value={email}
                 onChange={(e) => {
-                setEmail(e.target.value);
-                setError("");
+                  setEmail(e.target.value);
+                  setError("");
+                }}
+              />
+            </div>
+            {error && <div style={{ color: "red" }}>{error}</div>}
+            <button type="submit" style={{ marginTop: "1rem" }}>
+              Отримати код
+            </button>
+            <a
+              href={`${import.meta.env.VITE_API_BASE_URL}/auth/oidc/login`}
+              style={{ display: "block", marginTop: "1rem" }}
+            >
+              Увійти через SSO
+            </a>
+          </form>
+        ) : (
+          <form onSubmit={handleVerify}>
+            <p>Ми надіслали код і посилання для входу на {email}.</p>
+            <div>
+              <label>Код:</label>
+              <input
+                type="text"
+                inputMode="numeric"
+                value={code}
+                onChange={(e) => {
+                  setCode(e.target.value.trim());
+                  setError("");
                 }}
-            />
+              />
             </div>
             {error && <div style={{ color: "red" }}>{error}</div>}
             <button type="submit" style={{ marginTop: "1rem" }}>
-            Увійти
+              Увійти
             </button>
-        </form>
+          </form>
+        )}
       </div>
     </div>
   );
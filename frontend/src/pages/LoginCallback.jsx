@@ -0,0 +1,25 @@
+import { useEffect, useContext } from "react";
+import { useNavigate } from "react-router-dom";
+import { AuthContext } from "../contexts/AuthContext";
+
+// LoginCallback приймає токени після входу через SSO (бекенд передає їх у fragment)
+export default function LoginCallback() {
+  const { acceptTokens } = useContext(AuthContext);
+  const navigate = useNavigate();
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    const token = params.get("token");
+    const refreshToken = params.get("refresh_token");
+    // прибираємо токени з адресного рядка та історії
+    window.history.replaceState(null, "", window.location.pathname);
+    if (token && refreshToken) {
+      acceptTokens(token, refreshToken);
+    } else {
+      navigate("/login");
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  return <div className="app-container">Вхід…</div>;
+}
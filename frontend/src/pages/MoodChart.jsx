@@ -11,25 +11,6 @@ import {
 } from "recharts";
 import { format, parseISO, subDays, subMonths } from "date-fns";
 
-const iconToValue = (icon) => {
-  switch (icon) {
-    case "😡":
-        return 0;
-    case "😢":
-        return 1;
-    case "😞":
-        return 2;
-    case "😐":
-        return 3;
-    case "😊":
-        return 4;
-    case "😃":
-        return 5;
-    default:
-      return null;
-  }
-};
-
 const valueToIcon = (val) => {
   switch (val) {
     case 0:
@@ -66,35 +47,13 @@ export default function MoodChart() {
 
   const loadChartData = async (from, to) => {
     try {
-      const resp = await api.get(`/mood?from=${from}&to=${to}`);
-      const moods = resp.data;
-
-      // Обчислюємо кількість днів у проміжку (включно)
-      const start = parseISO(from);
-      const end = parseISO(to);
-      const diffMs = end.getTime() - start.getTime();
-      const dayCount = Math.floor(diffMs / (1000 * 60 * 60 * 24)) + 1;
-
-      // Ініціалізуємо обʼєкт із ключами "yyyy-MM-dd" і значенням null
-      const temp = {};
-      for (let i = 0; i < dayCount; i++) {
-        const d = new Date(start.getTime() + i * 86400000);
-        const key = format(d, "yyyy-MM-dd");
-        temp[key] = null;
-      }
-
-      // Заповнюємо temp з отриманих записів
-      moods.forEach((m) => {
-        const key = format(parseISO(m.date), "yyyy-MM-dd");
-        if (temp[key] !== undefined) {
-          temp[key] = iconToValue(m.icon);
-        }
-      });
-
-      // Перетворюємо temp у масив для Recharts
-      const chartData = Object.entries(temp).map(([day, val]) => ({
-        date: format(parseISO(day), "dd.MM"),
-        value: val,
+      // Сервер сам групує записи за днями й усереднює бал (кілька записів за день теж)
+      const resp = await api.get(`/mood/stats?from=${from}&to=${to}&group_by=day`);
+
+      // Перетворюємо групи у масив для Recharts; дні без записів мають avg_score = null
+      const chartData = resp.data.groups.map((g) => ({
+        date: format(parseISO(g.key), "dd.MM"),
+        value: g.avg_score,
       }));
 
       setData(chartData);
@@ -1,5 +1,6 @@
 import { useState, useEffect } from "react";
 import api from "../api/axios";
+import { fetchMoods, byEntryTime } from "../api/moods";
 import IconPicker from "../components/IconPicker";
 import { format } from "date-fns";
 
@@ -11,17 +12,24 @@ export default function MoodEntry() {
   const [message, setMessage] = useState("");
 
   const [loading, setLoading] = useState(true);
-  const [exists, setExists] = useState(false);
-  const [existingMood, setExistingMood] = useState(null);
+  const [todayMoods, setTodayMoods] = useState([]);
+  const [multiple, setMultiple] = useState(false);
 
   useEffect(() => {
-    // перевіряємо чи є сьогоднішній запис
+    // перевіряємо сьогоднішні записи і режим внесення (один чи кілька на день)
     const checkToday = async () => {
       try {
-        const resp = await api.get(`/mood?from=${today}&to=${today}`);
-        if (Array.isArray(resp.data) && resp.data.length > 0) {
-          setExists(true);
-          setExistingMood(resp.data[0]);
+        const [moods, settings] = await Promise.all([
+          fetchMoods(today, today),
+          api.get("/user/settings"),
+        ]);
+        setTodayMoods(moods.sort(byEntryTime));
+        setMultiple(settings.data.entry_mode === "multiple");
+
+        // Пояс за замовчуванням (UTC) замінюємо поясом браузера: від нього залежать "сьогодні" й нагадування
+        const browserTz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+        if (settings.data.timezone === "UTC" && browserTz && browserTz !== "UTC") {
+          await api.put("/user/settings", { timezone: browserTz });
         }
       } catch (err) {
         console.error(err);
@@ -40,18 +48,36 @@ export default function MoodEntry() {
       return;
     }
     try {
-      await api.post("/mood", { icon, comment });
+      // час запису з локальним зсувом: день запису збігається з днем користувача
+      const loggedAt = format(new Date(), "yyyy-MM-dd'T'HH:mm:ssxxx");
+      const resp = await api.post("/mood", { icon, comment, logged_at: loggedAt });
       setMessage("Сьогоднішній настрій успішно збережено!");
-      setExists(true);
-      setExistingMood({ icon, comment, date: today });
+      setTodayMoods([...todayMoods, resp.data]);
       setIcon("");
       setComment("");
     } catch (err) {
       console.error(err);
-      setMessage("Не вдалося зберегти сьогоднішній настрій");
+      if (err.response?.status === 409) {
+        setMessage("Сьогоднішній настрій уже задано!");
+      } else {
+        setMessage("Не вдалося зберегти сьогоднішній настрій");
+      }
     }
   };
 
+  const toggleMode = async () => {
+    const entry_mode = multiple ? "single" : "multiple";
+    try {
+      await api.put("/user/settings", { entry_mode });
+      setMultiple(!multiple);
+    } catch (err) {
+      console.error(err);
+      setMessage("Не вдалося змінити режим");
+    }
+  };
+
+  const exists = todayMoods.length > 0;
+
   if (loading) {
     return (
       <div className="app-container">
@@ -65,25 +91,41 @@ export default function MoodEntry() {
     <div className="app-container">
       <h2 className="page-title">Сьогоднішній настрій</h2>
 
-      {exists ? (
-        // Якщо вже є запис за сьогодні, показуємо
+      <label style={{ display: "block", marginBottom: "1rem" }}>
+        <input type="checkbox" checked={multiple} onChange={toggleMode} />{" "}
+        Кілька записів на день
+      </label>
+
+      {exists && (
+        // Показуємо вже зроблені сьогодні записи
         <div className="form-card">
-          <p className="message-error">Сьогоднішній настрій уже задано!</p>
-          <div style={{ marginTop: "1rem" }}>
-            <p>
-              <strong>Дата:</strong> {today}
-            </p>
-            <p>
-              <strong>Іконка:</strong>{" "}
-              <span style={{ fontSize: "1.5rem" }}>{existingMood.icon}</span>
-            </p>
-            <p>
-              <strong>Коментар:</strong> {existingMood.comment || "(немає)"}
-            </p>
-          </div>
+          {!multiple && (
+            <p className="message-error">Сьогоднішній настрій уже задано!</p>
+          )}
+          <p>
+            <strong>Дата:</strong> {today}
+          </p>
+          {todayMoods.map((m) => (
+            <div key={m.id} style={{ marginTop: "1rem" }}>
+              {m.logged_at && (
+                <p>
+                  <strong>Час:</strong> {format(new Date(m.logged_at), "HH:mm")}
+                </p>
+              )}
+              <p>
+                <strong>Іконка:</strong>{" "}
+                <span style={{ fontSize: "1.5rem" }}>{m.icon}</span>
+              </p>
+              <p>
+                <strong>Коментар:</strong> {m.comment || "(немає)"}
+              </p>
+            </div>
+          ))}
         </div>
-      ) : (
-        // Якщо запису ще немає, показуємо форму
+      )}
+
+      {(!exists || multiple) && (
+        // Форма: якщо запису ще немає або дозволено кілька на день
         <div className="form-card">
           <form onSubmit={handleSubmit}>
             <div>
@@ -1,5 +1,6 @@
 import { useState, useEffect } from "react";
 import api from "../api/axios";
+import { fetchMoods } from "../api/moods";
 import Calendar from "react-calendar";
 import "react-calendar/dist/Calendar.css";
 import { format, parseISO, startOfMonth, endOfMonth, startOfWeek, endOfWeek} from "date-fns";
@@ -27,12 +28,12 @@ export default function MoodHistory() {
       const to = format(calendarEnd, "yyyy-MM-dd");
 
       try {
-        const resp = await api.get(`/mood?from=${from}&to=${to}`);
+        const moods = await fetchMoods(from, to);
         // Формуємо мапу: ключ — "YYYY-MM-DD", значення — обʼєкт mood
         const mMap = {};
-        resp.data.forEach((m) => {
+        moods.forEach((m) => {
           const key = format(parseISO(m.date), "yyyy-MM-dd");
-          mMap[key] = { id: m.id, icon: m.icon, comment: m.comment, date: key };
+          mMap[key] = { id: m.id, icon: m.icon, comment: m.comment, date: key, version: m.version };
         });
         setMoodMap(mMap);
       } catch (err) {
@@ -57,8 +58,18 @@ export default function MoodHistory() {
   const handleSave = async ({ id, icon, comment, date }) => {
     try {
       if (id) {
-        // Оновлюємо
-        await api.put(`/mood/${id}`, { icon, comment, date });
+        // Оновлюємо лише змінені поля (JSON Merge Patch); зміна дати переносить запис
+        const patch = {};
+        if (icon !== modalData.icon) patch.icon = icon;
+        if (comment !== modalData.comment) patch.comment = comment;
+        if (date !== modalData.date) patch.date = date;
+        // If-Match: сервер відхилить зміну, якщо запис уже змінили з іншого пристрою
+        await api.patch(`/mood/${id}`, patch, {
+          headers: {
+            "Content-Type": "application/merge-patch+json",
+            "If-Match": `"${modalData.version}"`,
+          },
+        });
       } else {
         // Створюємо новий
         await api.post("/mood", { icon, comment, date });
@@ -71,18 +82,24 @@ export default function MoodHistory() {
       const from = format(calendarStart, "yyyy-MM-dd");
       const to = format(calendarEnd, "yyyy-MM-dd");
 
-      const resp = await api.get(`/mood?from=${from}&to=${to}`);
+      const moods = await fetchMoods(from, to);
       // Формуємо мапу: ключ — "YYYY-MM-DD", значення — обʼєкт mood
       const mMap = {};
-      resp.data.forEach((m) => {
+      moods.forEach((m) => {
         const key = format(parseISO(m.date), "yyyy-MM-dd");
-        mMap[key] = { id: m.id, icon: m.icon, comment: m.comment, date: key };
+        mMap[key] = { id: m.id, icon: m.icon, comment: m.comment, date: key, version: m.version };
       });
       setMoodMap(mMap);
       setShowModal(false);
     } catch (err) {
       console.error("Не вдалося зберегти запис:", err);
-      alert("Помилка при збереженні. Перевірте дані.");
+      if (err.response?.status === 409) {
+        alert("На цю дату вже є запис.");
+      } else if (err.response?.status === 412) {
+        alert("Запис змінено на іншому пристрої. Оновіть сторінку і спробуйте ще раз.");
+      } else {
+        alert("Помилка при збереженні. Перевірте дані.");
+      }
     }
   };
 
@@ -90,7 +107,9 @@ export default function MoodHistory() {
   const handleDelete = async (id) => {
     if (!window.confirm("Ви дійсно хочете видалити цей запис?")) return;
     try {
-      await api.delete(`/mood/${id}`);
+      await api.delete(`/mood/${id}`, {
+        headers: { "If-Match": `"${modalData.version}"` },
+      });
       // Перезавантажуємо після
       const monthStart = startOfMonth(viewDate); 
       const monthEnd = endOfMonth(viewDate);
@@ -99,18 +118,22 @@ export default function MoodHistory() {
       const from = format(calendarStart, "yyyy-MM-dd");
       const to = format(calendarEnd, "yyyy-MM-dd");
 
-      const resp = await api.get(`/mood?from=${from}&to=${to}`);
+      const moods = await fetchMoods(from, to);
       // Формуємо мапу: ключ — "YYYY-MM-DD", значення — обʼєкт mood
       const mMap = {};
-      resp.data.forEach((m) => {
+      moods.forEach((m) => {
         const key = format(parseISO(m.date), "yyyy-MM-dd");
-        mMap[key] = { id: m.id, icon: m.icon, comment: m.comment, date: key };
+        mMap[key] = { id: m.id, icon: m.icon, comment: m.comment, date: key, version: m.version };
       });
       setMoodMap(mMap);
       setShowModal(false);
     } catch (err) {
       console.error("Не вдалося видалити запис:", err);
-      alert("Помилка при видаленні.");
+      if (err.response?.status === 412) {
+        alert("Запис змінено на іншому пристрої. Оновіть сторінку і спробуйте ще раз.");
+      } else {
+        alert("Помилка при видаленні.");
+      }
     }
   };
 
@@ -169,13 +192,25 @@ export default function MoodHistory() {
 function ModalContent({ record, date, onSave, onDelete, onCancel }) {
   const [icon, setIcon] = useState(record ? record.icon : "");
   const [comment, setComment] = useState(record ? record.comment : "");
+  const [day, setDay] = useState(date);
   const isEditing = Boolean(record && record.id);
 
   return (
     <div style={{ display: "flex", flexDirection: "column", gap: "1rem" }}>
       <div>
         <label>Дата:</label>
-        <div style={{ marginTop: "4px", fontWeight: "bold" }}>{date}</div>
+        {isEditing ? (
+          // Запис можна перенести на інший день, але не в майбутнє
+          <input
+            type="date"
+            style={{ display: "block", marginTop: "4px" }}
+            value={day}
+            max={format(new Date(), "yyyy-MM-dd")}
+            onChange={(e) => setDay(e.target.value)}
+          />
+        ) : (
+          <div style={{ marginTop: "4px", fontWeight: "bold" }}>{date}</div>
+        )}
       </div>
 
       <div>
@@ -213,7 +248,7 @@ function ModalContent({ record, date, onSave, onDelete, onCancel }) {
         <button
           className="btn-small"
           style={{ backgroundColor: "#27ae60" }}
-          onClick={() => onSave({ id: record?.id, icon, comment, date })}
+          onClick={() => onSave({ id: record?.id, icon, comment, date: day })}
         >
           {isEditing ? "Зберегти" : "Створити"}
         </button>
@@ -1,33 +1,50 @@
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import api from "../api/axios";
 
 export default function TelegramConnect() {
-  const [chatId, setChatId] = useState("");
+  const [linked, setLinked] = useState(false);
+  const [link, setLink] = useState(null);
   const [message, setMessage] = useState("");
   const [error, setError] = useState("");
 
-  const handleSubmit = async (e) => {
-    e.preventDefault();
+  const loadStatus = async () => {
+    try {
+      const res = await api.get("/user/telegram");
+      setLinked(res.data.linked);
+    } catch (err) {
+      console.error(err);
+    }
+  };
+
+  useEffect(() => {
+    loadStatus();
+  }, []);
+
+  const handleLink = async () => {
     setMessage("");
     setError("");
-
-    // Перевіримо, що chatId — непорожній та є числом
-    if (!chatId || isNaN(Number(chatId))) {
-      setError("Введіть коректний числовий chat_id");
-      return;
+    try {
+      const res = await api.post("/user/telegram/link");
+      setLink(res.data.url);
+      // Посилання одноразове: бот прив'яже той чат, з якого його відкрили
+      window.open(res.data.url, "_blank", "noopener");
+    } catch (err) {
+      console.error(err);
+      setError("Не вдалося отримати посилання. Спробуйте пізніше.");
     }
+  };
 
+  const handleUnlink = async () => {
+    setMessage("");
+    setError("");
     try {
-      await api.post("/user/telegram/register", { chat_id: Number(chatId) });
-      setMessage("Telegram-чат успішно підключено! Тепер ви отримуватимете нотифікації.");
-      setChatId("");
+      await api.delete("/user/telegram");
+      setLinked(false);
+      setLink(null);
+      setMessage("Telegram відключено. Нагадування більше не надходитимуть.");
     } catch (err) {
       console.error(err);
-      if (err.response && err.response.data) {
-        setError(err.response.data);
-      } else {
-        setError("Не вдалося підключити Telegram. Спробуйте пізніше.");
-      }
+      setError("Не вдалося відключити Telegram. Спробуйте пізніше.");
     }
   };
 
@@ -36,35 +53,32 @@ export default function TelegramConnect() {
       <h2 className="page-title">Підключити Telegram-бот</h2>
 
       <div className="form-card">
-        <p>
-            Щоб отримувати щоденні й тижневі сповіщення у Telegram, необхідно пов’язати свій
-            чат з ботом. Для цього виконайте два кроки:
-        </p>
-        <p>
-          1. У Telegram знайдіть  бота @pvlkuz_moodtracker_bot, напишіть йому /start.
-        </p>
-        <p>
-          2. Скопіюйте свій chat_id і вставте його в поле нижче.
-            (Щоб дізнатися chat_id скористайтеся готовими ботами, наприклад @userinfobot, надішліть йому повідомлення, і він покаже ваш ID.)
-        </p>
-
-        <form onSubmit={handleSubmit} style={{ marginTop: "1rem", maxWidth: "400px" }}>
-            <div>
-            <label>Введіть chat_id:</label>
-            <input
-                type="text"
-                value={chatId}
-                onChange={(e) => setChatId(e.target.value.trim())}
-                placeholder="Наприклад, 123456789"
-                style={{ width: "100%", padding: "0.5rem", marginTop: "0.5rem" }}
-            />
-            </div>
-            {error && <div style={{ color: "red", marginTop: "0.5rem" }}>{error}</div>}
-            {message && <div style={{ color: "green", marginTop: "0.5rem" }}>{message}</div>}
-            <button type="submit" style={{ marginTop: "1rem", padding: "0.5rem 1rem" }}>
-            Підключити
+        {linked ? (
+          <>
+            <p>Telegram підключено: нагадування й звіти надходять у ваш чат з ботом.</p>
+            <button onClick={handleUnlink} style={{ marginTop: "1rem", padding: "0.5rem 1rem" }}>
+              Відключити
+            </button>
+          </>
+        ) : (
+          <>
+            <p>
+              Щоб отримувати щоденні й тижневі сповіщення у Telegram, натисніть кнопку нижче,
+              відкрийте бота і натисніть «Start». Посилання діє 15 хвилин.
+            </p>
+            <button onClick={handleLink} style={{ marginTop: "1rem", padding: "0.5rem 1rem" }}>
+              Підключити
             </button>
-        </form>
+            {link && (
+              <p style={{ marginTop: "1rem" }}>
+                Якщо Telegram не відкрився, перейдіть за <a href={link} target="_blank" rel="noreferrer">посиланням</a>,
+                а потім <button type="button" onClick={loadStatus}>оновіть статус</button>.
+              </p>
+            )}
+          </>
+        )}
+        {error && <div style={{ color: "red", marginTop: "0.5rem" }}>{error}</div>}
+        {message && <div style={{ color: "green", marginTop: "0.5rem" }}>{message}</div>}
       </div>
     </div>
   );